@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// adb only accepts 2048-bit keys.
+const adbKeyBits = 2048
+
+// adbKeyPath is where adb keeps its private key: $ANDROID_USER_HOME/adbkey,
+// or ~/.android/adbkey.
+func adbKeyPath() (string, error) {
+	if dir := os.Getenv("ANDROID_USER_HOME"); dir != "" {
+		return filepath.Join(dir, "adbkey"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".android", "adbkey"), nil
+}
+
+// loadAdbKey reads adb's private key, creating it and adbkey.pub the way adb
+// does when there is none yet. Sharing the key means a device that already
+// trusts this computer's adb accepts the in-process connection too.
+func loadAdbKey() (*rsa.PrivateKey, error) {
+	path, err := adbKeyPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate adb key: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return createAdbKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read adb key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM key", path)
+	}
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s holds an unsupported %q block", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse adb key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok || key.N.BitLen() != adbKeyBits {
+		return nil, fmt.Errorf("%s is not a %d-bit RSA key", path, adbKeyBits)
+	}
+	return key, nil
+}
+
+func createAdbKey(path string) (*rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, adbKeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate adb key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := encodeAdbPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write adb key: %w", err)
+	}
+	if err := os.WriteFile(path+".pub", []byte(publicKey+" "+adbKeyComment()+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write adb public key: %w", err)
+	}
+	return key, nil
+}
+
+// adbKeyComment is the user@host label adb appends to public keys; the
+// device shows it in the authorization prompt.
+func adbKeyComment() string {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USERNAME")
+	}
+	if user == "" {
+		user = "unknown"
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return user + "@" + host
+}
+
+// encodeAdbPublicKey writes key in the base64 RSAPublicKey struct adbd
+// stores in adb_keys: word count, -1/n[0] mod 2^32, the modulus, R^2 mod n
+// (both little-endian 32-bit words) and the exponent.
+func encodeAdbPublicKey(key *rsa.PublicKey) (string, error) {
+	if key.N.BitLen() != adbKeyBits {
+		return "", fmt.Errorf("adb keys must be %d-bit RSA", adbKeyBits)
+	}
+	words := adbKeyBits / 32
+
+	r32 := new(big.Int).Lsh(big.NewInt(1), 32)
+	n0inv := new(big.Int).ModInverse(new(big.Int).Mod(key.N, r32), r32)
+	n0inv.Sub(r32, n0inv)
+
+	rr := new(big.Int).Lsh(big.NewInt(1), 2*adbKeyBits)
+	rr.Mod(rr, key.N)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(words))
+	binary.Write(&buf, binary.LittleEndian, uint32(n0inv.Uint64()))
+	buf.Write(littleEndianBytes(key.N, adbKeyBits/8))
+	buf.Write(littleEndianBytes(rr, adbKeyBits/8))
+	binary.Write(&buf, binary.LittleEndian, uint32(key.E))
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func littleEndianBytes(n *big.Int, size int) []byte {
+	out := n.FillBytes(make([]byte, size))
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// signAdbToken signs an AUTH token the way adb's RSA_sign(NID_sha1) does:
+// the 20 random bytes are used as the SHA-1 digest directly.
+func signAdbToken(key *rsa.PrivateKey, token []byte) ([]byte, error) {
+	if len(token) != sha1.Size {
+		return nil, fmt.Errorf("unexpected adb auth token length %d", len(token))
+	}
+	return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, token)
+}
+
+// adbTLSConfig is the client side of STLS. adbd authenticates the host by the
+// key in its certificate, and adb does not verify adbd's self-signed one.
+func adbTLSConfig(key *rsa.PrivateKey) (*tls.Config, error) {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "adb-kit"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create adb TLS certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates:       []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS13,
+	}, nil
+}
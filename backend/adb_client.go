@@ -0,0 +1,496 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	adbServerAddress = "127.0.0.1:5037"
+	adbDialTimeout   = 2 * time.Second
+)
+
+// errAdbServerUnavailable means nothing is listening on the adb server port,
+// so callers should fall back to the bundled adb binary (which starts the server).
+var errAdbServerUnavailable = errors.New("adb server is not running")
+
+// adbClient speaks the adb host protocol directly to a running adb server.
+type adbClient struct {
+	address string
+
+	// direct are devices connected in-process over the adbd wire protocol,
+	// keyed by host:port. Their services bypass the adb server.
+	direct      map[string]*adbTransport
+	directMutex sync.Mutex
+}
+
+func newAdbClient() *adbClient {
+	return &adbClient{address: adbServerAddress, direct: make(map[string]*adbTransport)}
+}
+
+type adbShellResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+func (c *adbClient) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: adbDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", errAdbServerUnavailable, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// watchContext closes conn when ctx ends so blocked reads return immediately.
+func watchContext(ctx context.Context, conn net.Conn) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+func writeAdbRequest(conn net.Conn, request string) error {
+	_, err := fmt.Fprintf(conn, "%04x%s", len(request), request)
+	return err
+}
+
+// readAdbStatus consumes an OKAY or FAIL response. FAIL carries a length-prefixed message.
+func readAdbStatus(conn net.Conn) error {
+	status := make([]byte, 4)
+	if _, err := io.ReadFull(conn, status); err != nil {
+		return fmt.Errorf("failed to read adb server response: %w", err)
+	}
+
+	switch string(status) {
+	case "OKAY":
+		return nil
+	case "FAIL":
+		message, err := readAdbString(conn)
+		if err != nil {
+			return fmt.Errorf("adb server returned FAIL without a message: %w", err)
+		}
+		return fmt.Errorf("%s", message)
+	default:
+		return fmt.Errorf("unexpected adb server response %q", status)
+	}
+}
+
+func readAdbString(conn net.Conn) (string, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+
+	length, err := strconv.ParseUint(string(header), 16, 32)
+	if err != nil {
+		return "", fmt.Errorf("invalid length prefix %q", header)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// hostRequest sends a host:* request and returns its length-prefixed payload.
+func (c *adbClient) hostRequest(ctx context.Context, request string) (string, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	defer watchContext(ctx, conn)()
+
+	if err := writeAdbRequest(conn, request); err != nil {
+		return "", err
+	}
+	if err := readAdbStatus(conn); err != nil {
+		return "", err
+	}
+	return readAdbString(conn)
+}
+
+func (c *adbClient) Version(ctx context.Context) (int, error) {
+	payload, err := c.hostRequest(ctx, "host:version")
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := strconv.ParseInt(payload, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid adb server version %q", payload)
+	}
+	return int(version), nil
+}
+
+func (c *adbClient) Devices(ctx context.Context) ([]Device, error) {
+	direct := c.directDevices()
+	payload, err := c.hostRequest(ctx, "host:devices-l")
+	if err != nil {
+		// Without a server, the in-process connections are all there is.
+		if errors.Is(err, errAdbServerUnavailable) && len(direct) > 0 {
+			return direct, nil
+		}
+		return nil, err
+	}
+	return append(parseDevicesLong(payload), direct...), nil
+}
+
+// ConnectDirect connects to adbd at address without the adb server, or
+// returns the existing connection to it.
+func (c *adbClient) ConnectDirect(ctx context.Context, address string) (*adbTransport, error) {
+	if t := c.directTransport(address); t != nil {
+		return t, nil
+	}
+
+	key, err := loadAdbKey()
+	if err != nil {
+		return nil, err
+	}
+	t, err := dialAdbTransport(ctx, address, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.directMutex.Lock()
+	if previous := c.direct[address]; previous != nil {
+		previous.Close()
+	}
+	c.direct[address] = t
+	c.directMutex.Unlock()
+	return t, nil
+}
+
+// DisconnectDirect closes the in-process connection to address, reporting
+// whether there was one.
+func (c *adbClient) DisconnectDirect(address string) bool {
+	c.directMutex.Lock()
+	t := c.direct[address]
+	delete(c.direct, address)
+	c.directMutex.Unlock()
+
+	if t == nil {
+		return false
+	}
+	t.Close()
+	return true
+}
+
+// directTransport returns the live in-process connection for serial, if any.
+// Connections adbd has dropped are forgotten.
+func (c *adbClient) directTransport(serial string) *adbTransport {
+	c.directMutex.Lock()
+	defer c.directMutex.Unlock()
+
+	t := c.direct[serial]
+	if t != nil && t.isClosed() {
+		delete(c.direct, serial)
+		return nil
+	}
+	return t
+}
+
+func (c *adbClient) directDevices() []Device {
+	c.directMutex.Lock()
+	defer c.directMutex.Unlock()
+
+	var devices []Device
+	for serial, t := range c.direct {
+		if t.isClosed() {
+			delete(c.direct, serial)
+			continue
+		}
+		devices = append(devices, t.device)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Serial < devices[j].Serial })
+	return devices
+}
+
+// transport opens a connection already switched to the given device.
+// An empty serial lets the server pick the only connected device.
+func (c *adbClient) transport(ctx context.Context, serial string) (net.Conn, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request := "host:transport-any"
+	if serial != "" {
+		request = "host:transport:" + serial
+	}
+
+	if err := writeAdbRequest(conn, request); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := readAdbStatus(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// openService opens a device service such as "shell:ls" on a fresh transport.
+// Devices connected in-process get a stream on their own connection instead.
+func (c *adbClient) openService(ctx context.Context, serial string, service string) (net.Conn, error) {
+	if t := c.directTransport(serial); t != nil {
+		return t.openStream(ctx, service)
+	}
+
+	conn, err := c.transport(ctx, serial)
+	if errors.Is(err, errAdbServerUnavailable) && serial == "" {
+		if direct := c.directDevices(); len(direct) == 1 {
+			return c.openService(ctx, direct[0].Serial, service)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeAdbRequest(conn, service); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := readAdbStatus(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Shell runs command using the shell v2 protocol, which separates stdout/stderr
+// and reports the exit code. Devices without shell_v2 fall back to the legacy shell.
+func (c *adbClient) Shell(ctx context.Context, serial string, command string) (adbShellResult, error) {
+	var stdout, stderr bytes.Buffer
+	exitCode, err := c.ShellStream(ctx, serial, command, &stdout, &stderr)
+	return adbShellResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: exitCode,
+	}, err
+}
+
+// ShellStream is Shell for long-running commands such as logcat: output is
+// copied to stdout and stderr as it arrives instead of being buffered.
+func (c *adbClient) ShellStream(ctx context.Context, serial string, command string, stdout io.Writer, stderr io.Writer) (int, error) {
+	features, err := c.Features(ctx, serial)
+	if err != nil {
+		return 0, err
+	}
+	if !features["shell_v2"] {
+		return 0, c.streamService(ctx, serial, "shell:"+command, stdout)
+	}
+
+	conn, err := c.openService(ctx, serial, "shell,v2,raw:"+command)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	defer watchContext(ctx, conn)()
+
+	for {
+		id, data, err := readShellPacket(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return 0, nil
+			}
+			return 0, err
+		}
+
+		switch id {
+		case shellIDStdout:
+			if _, err := stdout.Write(data); err != nil {
+				return 0, err
+			}
+		case shellIDStderr:
+			if _, err := stderr.Write(data); err != nil {
+				return 0, err
+			}
+		case shellIDExit:
+			if len(data) > 0 {
+				return int(data[0]), nil
+			}
+			return 0, nil
+		}
+	}
+}
+
+// Exec runs command through the "exec:" service. It has no PTY and no shell
+// framing, so binary output such as a screencap PNG arrives byte for byte.
+func (c *adbClient) Exec(ctx context.Context, serial string, command string, w io.Writer) error {
+	return c.streamService(ctx, serial, "exec:"+command, w)
+}
+
+// Install streams an APK to `cmd package install -S` the way adb install does,
+// without the adb binary. It needs the cmd feature (Android 7 and newer).
+func (c *adbClient) Install(ctx context.Context, serial string, filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	features, err := c.Features(ctx, serial)
+	if err != nil {
+		return "", err
+	}
+	if !features["cmd"] {
+		return "", fmt.Errorf("the device is too old to install over an in-process connection")
+	}
+
+	command := fmt.Sprintf("exec:cmd package install -r -S %d", stat.Size())
+	conn, err := c.openService(ctx, serial, command)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	defer watchContext(ctx, conn)()
+
+	if _, err := io.Copy(conn, file); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	output, err := io.ReadAll(conn)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	result := strings.TrimSpace(string(output))
+	if !strings.HasPrefix(result, "Success") {
+		return "", newCommandError(serial, "adb install "+filePath, 1, result)
+	}
+	return result, nil
+}
+
+// streamService copies a service's output without the v2 framing: stdout and
+// stderr are merged and the exit code is lost.
+func (c *adbClient) streamService(ctx context.Context, serial string, service string, stdout io.Writer) error {
+	conn, err := c.openService(ctx, serial, service)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	defer watchContext(ctx, conn)()
+
+	_, err = io.Copy(stdout, conn)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Shell v2 packet ids, see adb's shell_protocol.h.
+const (
+	shellIDStdin      byte = 0
+	shellIDStdout     byte = 1
+	shellIDStderr     byte = 2
+	shellIDExit       byte = 3
+	shellIDCloseStdin byte = 4
+	shellIDWindowSize byte = 5
+)
+
+func readShellPacket(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[1:])
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	return header[0], data, nil
+}
+
+func writeShellPacket(w io.Writer, id byte, data []byte) error {
+	packet := make([]byte, 5+len(data))
+	packet[0] = id
+	binary.LittleEndian.PutUint32(packet[1:], uint32(len(data)))
+	copy(packet[5:], data)
+	_, err := w.Write(packet)
+	return err
+}
+
+// parseDevicesLong parses `adb devices -l` / host:devices-l output, e.g.
+// "emulator-5554 device product:sdk model:Pixel device:emu64x transport_id:1".
+func parseDevicesLong(output string) []Device {
+	var devices []Device
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "List of devices") || strings.HasPrefix(line, "*") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		device := Device{Serial: fields[0]}
+		var status []string
+		for _, field := range fields[1:] {
+			key, value, found := strings.Cut(field, ":")
+			if !found {
+				status = append(status, field)
+				continue
+			}
+
+			switch key {
+			case "product":
+				device.Product = value
+			case "model":
+				device.Model = value
+			case "device":
+				device.DeviceName = value
+			case "transport_id":
+				device.TransportID = value
+			case "usb":
+				device.USB = value
+			default:
+				status = append(status, field)
+			}
+		}
+		device.Status = strings.Join(status, " ")
+		devices = append(devices, device)
+	}
+	return devices
+}
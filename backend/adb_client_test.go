@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAdbServer answers host protocol requests on a local listener. handle
+// gets each request and the connection to reply on; it returns false to end
+// the connection.
+func fakeAdbServer(t *testing.T, handle func(conn net.Conn, request string) bool) *adbClient {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					request, err := readAdbString(conn)
+					if err != nil || !handle(conn, request) {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	client := newAdbClient()
+	client.address = ln.Addr().String()
+	return client
+}
+
+func replyOkay(conn net.Conn, payload string) {
+	fmt.Fprintf(conn, "OKAY%04x%s", len(payload), payload)
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestAdbHostRequestFraming(t *testing.T) {
+	client := fakeAdbServer(t, func(conn net.Conn, request string) bool {
+		switch request {
+		case "host:version":
+			replyOkay(conn, "0029")
+		case "host:devices-l":
+			replyOkay(conn, "emulator-5554          device product:sdk model:Pixel_7 device:emu64x transport_id:3\n")
+		default:
+			message := "unknown host service"
+			fmt.Fprintf(conn, "FAIL%04x%s", len(message), message)
+		}
+		return false
+	})
+	ctx := testContext(t)
+
+	version, err := client.Version(ctx)
+	if err != nil || version != 41 {
+		t.Fatalf("Version = %d, %v; want 41", version, err)
+	}
+
+	devices, err := client.Devices(ctx)
+	if err != nil || len(devices) != 1 {
+		t.Fatalf("Devices = %v, %v", devices, err)
+	}
+	if d := devices[0]; d.Serial != "emulator-5554" || d.Status != "device" || d.Model != "Pixel_7" || d.TransportID != "3" {
+		t.Errorf("device = %+v", d)
+	}
+
+	_, err = client.hostRequest(ctx, "host:bogus")
+	if err == nil || err.Error() != "unknown host service" {
+		t.Errorf("FAIL message = %v", err)
+	}
+}
+
+func TestAdbServerUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newAdbClient()
+	client.address = ln.Addr().String()
+	ln.Close()
+
+	if _, err := client.Version(testContext(t)); !errors.Is(err, errAdbServerUnavailable) {
+		t.Errorf("err = %v, want errAdbServerUnavailable", err)
+	}
+}
+
+func TestAdbShellProtocolChoice(t *testing.T) {
+	tests := []struct {
+		name     string
+		features string
+		wantOut  string
+		wantErr  string
+		wantExit int
+	}{
+		{name: "shell v2", features: "shell_v2,cmd", wantOut: "v2 out", wantErr: "v2 err", wantExit: 3},
+		// The legacy service is picked from the feature list, not from an error.
+		{name: "legacy", features: "cmd", wantOut: "legacy out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeAdbServer(t, func(conn net.Conn, request string) bool {
+				switch {
+				case request == "host-serial:emu:features":
+					replyOkay(conn, tt.features)
+					return false
+				case request == "host:transport:emu":
+					conn.Write([]byte("OKAY"))
+					return true
+				case strings.HasPrefix(request, "shell,v2,raw:"):
+					conn.Write([]byte("OKAY"))
+					writeShellPacket(conn, shellIDStdout, []byte("v2 out"))
+					writeShellPacket(conn, shellIDStderr, []byte("v2 err"))
+					writeShellPacket(conn, shellIDExit, []byte{3})
+				case strings.HasPrefix(request, "shell:"):
+					conn.Write([]byte("OKAY"))
+					conn.Write([]byte("legacy out"))
+				default:
+					t.Errorf("unexpected request %q", request)
+				}
+				return false
+			})
+
+			result, err := client.Shell(testContext(t), "emu", "echo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stdout != tt.wantOut || result.Stderr != tt.wantErr || result.ExitCode != tt.wantExit {
+				t.Errorf("result = %+v", result)
+			}
+		})
+	}
+}
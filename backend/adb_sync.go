@@ -0,0 +1,363 @@
+package backend
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// Sync protocol limits, see adb's file_sync_protocol.h.
+const (
+	syncMaxChunk    = 64 * 1024
+	syncMaxPathSize = 1024
+)
+
+const (
+	syncModeTypeMask = 0170000
+	syncModeDir      = 0040000
+	syncModeRegular  = 0100000
+	syncModeSymlink  = 0120000
+)
+
+type syncStat struct {
+	Mode  uint32
+	Size  uint64
+	Mtime int64
+}
+
+func (s syncStat) Exists() bool    { return s.Mode != 0 }
+func (s syncStat) IsDir() bool     { return s.Mode&syncModeTypeMask == syncModeDir }
+func (s syncStat) IsRegular() bool { return s.Mode&syncModeTypeMask == syncModeRegular }
+func (s syncStat) IsSymlink() bool { return s.Mode&syncModeTypeMask == syncModeSymlink }
+
+type syncDirEntry struct {
+	Name string
+	syncStat
+}
+
+// syncConn is an open "sync:" service on a device.
+type syncConn struct {
+	conn   net.Conn
+	statV2 bool
+	lsV2   bool
+}
+
+// Features returns the feature list the adb server negotiated with the device.
+func (c *adbClient) Features(ctx context.Context, serial string) (map[string]bool, error) {
+	if t := c.directTransport(serial); t != nil {
+		return t.features, nil
+	}
+
+	request := "host:features"
+	if serial != "" {
+		request = "host-serial:" + serial + ":features"
+	}
+
+	payload, err := c.hostRequest(ctx, request)
+	if errors.Is(err, errAdbServerUnavailable) && serial == "" {
+		if direct := c.directDevices(); len(direct) == 1 {
+			return c.Features(ctx, direct[0].Serial)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	features := make(map[string]bool)
+	for _, feature := range strings.Split(strings.TrimSpace(payload), ",") {
+		if feature != "" {
+			features[feature] = true
+		}
+	}
+	return features, nil
+}
+
+func (c *adbClient) openSync(ctx context.Context, serial string) (*syncConn, error) {
+	features, err := c.Features(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.openService(ctx, serial, "sync:")
+	if err != nil {
+		return nil, err
+	}
+
+	return &syncConn{
+		conn:   conn,
+		statV2: features["stat_v2"],
+		lsV2:   features["ls_v2"],
+	}, nil
+}
+
+func (s *syncConn) Close() error {
+	_ = s.sendRequest("QUIT", nil)
+	return s.conn.Close()
+}
+
+func (s *syncConn) sendRequest(id string, payload []byte) error {
+	header := make([]byte, 8)
+	copy(header, id)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(payload)))
+	if _, err := s.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *syncConn) sendPathRequest(id string, path string) error {
+	if len(path) > syncMaxPathSize {
+		return fmt.Errorf("path too long: %s", path)
+	}
+	return s.sendRequest(id, []byte(path))
+}
+
+func (s *syncConn) readID() (string, error) {
+	id := make([]byte, 4)
+	if _, err := io.ReadFull(s.conn, id); err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (s *syncConn) readUint32() (uint32, error) {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(s.conn, buf); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(buf), nil
+}
+
+// readFail reads the message that follows a FAIL id.
+func (s *syncConn) readFail() error {
+	length, err := s.readUint32()
+	if err != nil {
+		return err
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(s.conn, message); err != nil {
+		return err
+	}
+	return newCommandError("", "adb sync", -1, string(message))
+}
+
+func (s *syncConn) Stat(path string) (syncStat, error) {
+	if s.statV2 {
+		return s.statV2Request(path)
+	}
+
+	if err := s.sendPathRequest("STAT", path); err != nil {
+		return syncStat{}, err
+	}
+
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(s.conn, buf); err != nil {
+		return syncStat{}, err
+	}
+	if string(buf[:4]) != "STAT" {
+		return syncStat{}, fmt.Errorf("unexpected sync response %q", buf[:4])
+	}
+
+	return syncStat{
+		Mode:  binary.LittleEndian.Uint32(buf[4:]),
+		Size:  uint64(binary.LittleEndian.Uint32(buf[8:])),
+		Mtime: int64(binary.LittleEndian.Uint32(buf[12:])),
+	}, nil
+}
+
+// statV2Request uses STA2, which follows symlinks and reports 64-bit sizes.
+func (s *syncConn) statV2Request(path string) (syncStat, error) {
+	if err := s.sendPathRequest("STA2", path); err != nil {
+		return syncStat{}, err
+	}
+
+	buf := make([]byte, 72)
+	if _, err := io.ReadFull(s.conn, buf); err != nil {
+		return syncStat{}, err
+	}
+	if string(buf[:4]) != "STA2" {
+		return syncStat{}, fmt.Errorf("unexpected sync response %q", buf[:4])
+	}
+
+	if binary.LittleEndian.Uint32(buf[4:]) != 0 {
+		// A non-zero errno means the path does not exist or cannot be read.
+		return syncStat{}, nil
+	}
+	return decodeStatV2(buf[4:]), nil
+}
+
+// decodeStatV2 decodes the fields after the id of a STA2/LST2/DNT2 record:
+// error, dev, ino, mode, nlink, uid, gid, size, atime, mtime, ctime.
+func decodeStatV2(buf []byte) syncStat {
+	return syncStat{
+		Mode:  binary.LittleEndian.Uint32(buf[20:]),
+		Size:  binary.LittleEndian.Uint64(buf[36:]),
+		Mtime: int64(binary.LittleEndian.Uint64(buf[52:])),
+	}
+}
+
+func (s *syncConn) List(path string) ([]syncDirEntry, error) {
+	if s.lsV2 {
+		return s.listV2(path)
+	}
+
+	if err := s.sendPathRequest("LIST", path); err != nil {
+		return nil, err
+	}
+
+	var entries []syncDirEntry
+	for {
+		buf := make([]byte, 20)
+		if _, err := io.ReadFull(s.conn, buf); err != nil {
+			return nil, err
+		}
+
+		switch string(buf[:4]) {
+		case "DONE":
+			return entries, nil
+		case "DENT":
+		default:
+			return nil, fmt.Errorf("unexpected sync response %q", buf[:4])
+		}
+
+		name := make([]byte, binary.LittleEndian.Uint32(buf[16:]))
+		if _, err := io.ReadFull(s.conn, name); err != nil {
+			return nil, err
+		}
+
+		entries = appendDirEntry(entries, string(name), syncStat{
+			Mode:  binary.LittleEndian.Uint32(buf[4:]),
+			Size:  uint64(binary.LittleEndian.Uint32(buf[8:])),
+			Mtime: int64(binary.LittleEndian.Uint32(buf[12:])),
+		})
+	}
+}
+
+func (s *syncConn) listV2(path string) ([]syncDirEntry, error) {
+	if err := s.sendPathRequest("LIS2", path); err != nil {
+		return nil, err
+	}
+
+	var entries []syncDirEntry
+	for {
+		buf := make([]byte, 76)
+		if _, err := io.ReadFull(s.conn, buf); err != nil {
+			return nil, err
+		}
+
+		switch string(buf[:4]) {
+		case "DONE":
+			return entries, nil
+		case "DNT2":
+		default:
+			return nil, fmt.Errorf("unexpected sync response %q", buf[:4])
+		}
+
+		name := make([]byte, binary.LittleEndian.Uint32(buf[72:]))
+		if _, err := io.ReadFull(s.conn, name); err != nil {
+			return nil, err
+		}
+
+		entries = appendDirEntry(entries, string(name), decodeStatV2(buf[4:]))
+	}
+}
+
+func appendDirEntry(entries []syncDirEntry, name string, st syncStat) []syncDirEntry {
+	if name == "." || name == ".." {
+		return entries
+	}
+	return append(entries, syncDirEntry{Name: name, syncStat: st})
+}
+
+// Send streams r to remotePath. onChunk is called after every DATA packet.
+func (s *syncConn) Send(remotePath string, mode uint32, mtime int64, r io.Reader, onChunk func(int)) error {
+	if err := s.sendPathRequest("SEND", fmt.Sprintf("%s,%d", remotePath, mode&0777|syncModeRegular)); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, syncMaxChunk)
+	for {
+		n, err := r.Read(chunk)
+		if n > 0 {
+			if sendErr := s.sendRequest("DATA", chunk[:n]); sendErr != nil {
+				return sendErr
+			}
+			if onChunk != nil {
+				onChunk(n)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	header := make([]byte, 8)
+	copy(header, "DONE")
+	binary.LittleEndian.PutUint32(header[4:], uint32(mtime))
+	if _, err := s.conn.Write(header); err != nil {
+		return err
+	}
+
+	id, err := s.readID()
+	if err != nil {
+		return err
+	}
+	switch id {
+	case "OKAY":
+		_, err := s.readUint32()
+		return err
+	case "FAIL":
+		return s.readFail()
+	default:
+		return fmt.Errorf("unexpected sync response %q", id)
+	}
+}
+
+// Recv streams remotePath into w. onChunk is called after every DATA packet.
+func (s *syncConn) Recv(remotePath string, w io.Writer, onChunk func(int)) error {
+	if err := s.sendPathRequest("RECV", remotePath); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, syncMaxChunk)
+	for {
+		id, err := s.readID()
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case "DATA":
+			length, err := s.readUint32()
+			if err != nil {
+				return err
+			}
+			if length > syncMaxChunk {
+				return fmt.Errorf("sync chunk too large: %d bytes", length)
+			}
+			if _, err := io.ReadFull(s.conn, chunk[:length]); err != nil {
+				return err
+			}
+			if _, err := w.Write(chunk[:length]); err != nil {
+				return err
+			}
+			if onChunk != nil {
+				onChunk(int(length))
+			}
+		case "DONE":
+			_, err := s.readUint32()
+			return err
+		case "FAIL":
+			return s.readFail()
+		default:
+			return fmt.Errorf("unexpected sync response %q", id)
+		}
+	}
+}
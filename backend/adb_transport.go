@@ -0,0 +1,513 @@
+package backend
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Commands of the wire protocol adb speaks to adbd, see adb's protocol.txt.
+const (
+	adbCmdCnxn uint32 = 0x4e584e43
+	adbCmdAuth uint32 = 0x48545541
+	adbCmdOpen uint32 = 0x4e45504f
+	adbCmdOkay uint32 = 0x59414b4f
+	adbCmdClse uint32 = 0x45534c43
+	adbCmdWrte uint32 = 0x45545257
+	adbCmdStls uint32 = 0x534c5453
+
+	adbAuthToken        uint32 = 1
+	adbAuthSignature    uint32 = 2
+	adbAuthRSAPublicKey uint32 = 3
+
+	adbProtocolVersion uint32 = 0x01000001
+	adbStlsVersion     uint32 = 0x01000000
+	adbMaxPayload             = 256 * 1024
+	adbMessageHeader          = 24
+)
+
+// adbHostFeatures are the features the in-process transport offers adbd. They
+// are limited to what adbClient's services know how to speak.
+var adbHostFeatures = []string{"shell_v2", "cmd", "stat_v2", "ls_v2", "fixed_push_mkdir"}
+
+type adbMessage struct {
+	command uint32
+	arg0    uint32
+	arg1    uint32
+	data    []byte
+}
+
+func writeAdbMessage(w io.Writer, m adbMessage) error {
+	packet := make([]byte, adbMessageHeader+len(m.data))
+	binary.LittleEndian.PutUint32(packet[0:], m.command)
+	binary.LittleEndian.PutUint32(packet[4:], m.arg0)
+	binary.LittleEndian.PutUint32(packet[8:], m.arg1)
+	binary.LittleEndian.PutUint32(packet[12:], uint32(len(m.data)))
+	// Devices on protocol 0x01000000 still verify the checksum.
+	var checksum uint32
+	for _, b := range m.data {
+		checksum += uint32(b)
+	}
+	binary.LittleEndian.PutUint32(packet[16:], checksum)
+	binary.LittleEndian.PutUint32(packet[20:], m.command^0xffffffff)
+	copy(packet[adbMessageHeader:], m.data)
+	_, err := w.Write(packet)
+	return err
+}
+
+func readAdbMessage(r io.Reader) (adbMessage, error) {
+	header := make([]byte, adbMessageHeader)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return adbMessage{}, err
+	}
+
+	m := adbMessage{
+		command: binary.LittleEndian.Uint32(header[0:]),
+		arg0:    binary.LittleEndian.Uint32(header[4:]),
+		arg1:    binary.LittleEndian.Uint32(header[8:]),
+	}
+	if binary.LittleEndian.Uint32(header[20:]) != m.command^0xffffffff {
+		return adbMessage{}, fmt.Errorf("invalid adb message magic for command %08x", m.command)
+	}
+	length := binary.LittleEndian.Uint32(header[12:])
+	if length > adbMaxPayload {
+		return adbMessage{}, fmt.Errorf("adb message payload of %d bytes exceeds %d", length, adbMaxPayload)
+	}
+	m.data = make([]byte, length)
+	if _, err := io.ReadFull(r, m.data); err != nil {
+		return adbMessage{}, err
+	}
+	return m, nil
+}
+
+// adbTransport is a connection straight to adbd over TCP, without an adb
+// server in between. Services are multiplexed on it as adbStreams.
+type adbTransport struct {
+	address    string
+	conn       net.Conn
+	device     Device
+	features   map[string]bool
+	maxPayload int
+
+	writeMutex sync.Mutex
+
+	mu      sync.Mutex
+	streams map[uint32]*adbStream
+	nextID  uint32
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// dialAdbTransport connects to adbd at address and completes the CNXN
+// handshake, signing AUTH tokens with key and upgrading to TLS when adbd asks
+// for STLS. If the device does not know key yet, it offers the public key and
+// waits, within ctx, for the user to allow debugging on the device.
+func dialAdbTransport(ctx context.Context, address string, key *rsa.PrivateKey) (*adbTransport, error) {
+	dialer := net.Dialer{Timeout: adbDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+
+	stop := watchContext(ctx, conn)
+	conn, banner, err := adbHandshake(ctx, conn, key)
+	stop()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	t := &adbTransport{
+		address:    address,
+		conn:       conn,
+		maxPayload: int(banner.arg1),
+		streams:    make(map[uint32]*adbStream),
+		closed:     make(chan struct{}),
+	}
+	if t.maxPayload <= 0 || t.maxPayload > adbMaxPayload {
+		t.maxPayload = adbMaxPayload
+	}
+	t.device, t.features = parseAdbBanner(address, string(banner.data))
+	go t.readLoop()
+	return t, nil
+}
+
+func adbHandshake(ctx context.Context, conn net.Conn, key *rsa.PrivateKey) (net.Conn, adbMessage, error) {
+	banner := "host::features=" + strings.Join(adbHostFeatures, ",")
+	if err := writeAdbMessage(conn, adbMessage{command: adbCmdCnxn, arg0: adbProtocolVersion, arg1: adbMaxPayload, data: []byte(banner)}); err != nil {
+		return conn, adbMessage{}, err
+	}
+
+	signed := false
+	offeredKey := false
+	for {
+		m, err := readAdbMessage(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				if offeredKey {
+					return conn, adbMessage{}, &CommandError{Code: CodeUnauthorized, Message: "device did not allow USB debugging; accept the prompt on the device and connect again", Command: "connect " + conn.RemoteAddr().String()}
+				}
+				return conn, adbMessage{}, ctx.Err()
+			}
+			return conn, adbMessage{}, fmt.Errorf("adb handshake failed: %w", err)
+		}
+
+		switch m.command {
+		case adbCmdCnxn:
+			return conn, m, nil
+
+		case adbCmdStls:
+			if err := writeAdbMessage(conn, adbMessage{command: adbCmdStls, arg0: adbStlsVersion}); err != nil {
+				return conn, adbMessage{}, err
+			}
+			config, err := adbTLSConfig(key)
+			if err != nil {
+				return conn, adbMessage{}, err
+			}
+			tlsConn := tls.Client(conn, config)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				return conn, adbMessage{}, fmt.Errorf("adb TLS handshake failed (pair the device first): %w", err)
+			}
+			conn = tlsConn
+
+		case adbCmdAuth:
+			if m.arg0 != adbAuthToken {
+				return conn, adbMessage{}, fmt.Errorf("unexpected adb AUTH type %d", m.arg0)
+			}
+			// The first token is signed; a second one means adbd does not
+			// know the key, so it is offered for the user to accept.
+			if !signed {
+				signature, err := signAdbToken(key, m.data)
+				if err != nil {
+					return conn, adbMessage{}, err
+				}
+				if err := writeAdbMessage(conn, adbMessage{command: adbCmdAuth, arg0: adbAuthSignature, data: signature}); err != nil {
+					return conn, adbMessage{}, err
+				}
+				signed = true
+				continue
+			}
+			if offeredKey {
+				return conn, adbMessage{}, &CommandError{Code: CodeUnauthorized, Message: "device rejected the adb key", Command: "connect " + conn.RemoteAddr().String()}
+			}
+			publicKey, err := encodeAdbPublicKey(&key.PublicKey)
+			if err != nil {
+				return conn, adbMessage{}, err
+			}
+			payload := append([]byte(publicKey+" "+adbKeyComment()), 0)
+			if err := writeAdbMessage(conn, adbMessage{command: adbCmdAuth, arg0: adbAuthRSAPublicKey, data: payload}); err != nil {
+				return conn, adbMessage{}, err
+			}
+			offeredKey = true
+		}
+	}
+}
+
+// parseAdbBanner reads adbd's CNXN banner, e.g.
+// "device::ro.product.name=x;ro.product.model=y;ro.product.device=z;features=a,b".
+func parseAdbBanner(address string, banner string) (Device, map[string]bool) {
+	device := Device{Serial: address, Status: "device"}
+	features := make(map[string]bool)
+
+	state, props, _ := strings.Cut(strings.TrimRight(banner, "\x00"), "::")
+	if state != "" {
+		device.Status = state
+	}
+	for _, prop := range strings.Split(props, ";") {
+		key, value, _ := strings.Cut(prop, "=")
+		switch key {
+		case "ro.product.name":
+			device.Product = value
+		case "ro.product.model":
+			device.Model = value
+		case "ro.product.device":
+			device.DeviceName = value
+		case "features":
+			for _, feature := range strings.Split(value, ",") {
+				if feature != "" {
+					features[feature] = true
+				}
+			}
+		}
+	}
+	return device, features
+}
+
+func (t *adbTransport) send(m adbMessage) error {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+	return writeAdbMessage(t.conn, m)
+}
+
+func (t *adbTransport) isClosed() bool {
+	select {
+	case <-t.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close drops the connection and ends every open stream.
+func (t *adbTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		t.conn.Close()
+
+		t.mu.Lock()
+		streams := t.streams
+		t.streams = make(map[uint32]*adbStream)
+		t.mu.Unlock()
+		for _, s := range streams {
+			s.remoteClose()
+		}
+	})
+	return nil
+}
+
+func (t *adbTransport) stream(localID uint32) *adbStream {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.streams[localID]
+}
+
+func (t *adbTransport) removeStream(localID uint32) {
+	t.mu.Lock()
+	delete(t.streams, localID)
+	t.mu.Unlock()
+}
+
+func (t *adbTransport) readLoop() {
+	defer t.Close()
+	for {
+		m, err := readAdbMessage(t.conn)
+		if err != nil {
+			return
+		}
+
+		switch m.command {
+		case adbCmdOkay:
+			if s := t.stream(m.arg1); s != nil {
+				s.okay(m.arg0)
+			}
+		case adbCmdWrte:
+			if s := t.stream(m.arg1); s != nil {
+				s.data <- m.data
+			} else {
+				t.send(adbMessage{command: adbCmdClse, arg0: m.arg1, arg1: m.arg0})
+			}
+		case adbCmdClse:
+			if s := t.stream(m.arg1); s != nil {
+				t.removeStream(m.arg1)
+				s.remoteClose()
+			}
+		}
+	}
+}
+
+// openStream opens service, e.g. "shell,v2,raw:ls", on the device.
+func (t *adbTransport) openStream(ctx context.Context, service string) (*adbStream, error) {
+	t.mu.Lock()
+	t.nextID++
+	s := &adbStream{
+		transport: t,
+		localID:   t.nextID,
+		opened:    make(chan struct{}),
+		acks:      make(chan struct{}, 1),
+		// adbd waits for OKAY before sending more, so one chunk is in flight.
+		data: make(chan []byte, 1),
+		done: make(chan struct{}),
+	}
+	t.streams[s.localID] = s
+	t.mu.Unlock()
+
+	if err := t.send(adbMessage{command: adbCmdOpen, arg0: s.localID, data: append([]byte(service), 0)}); err != nil {
+		t.removeStream(s.localID)
+		return nil, err
+	}
+
+	select {
+	case <-s.opened:
+	case <-s.done:
+		if t.isClosed() {
+			return nil, fmt.Errorf("connection to %s closed", t.address)
+		}
+		return nil, fmt.Errorf("device closed service %q", service)
+	case <-ctx.Done():
+		s.Close()
+		return nil, ctx.Err()
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		s.SetDeadline(deadline)
+	}
+	return s, nil
+}
+
+// adbStream is one open service on an adbTransport. It is a net.Conn, so the
+// adbClient code written for adb server connections works on it unchanged.
+type adbStream struct {
+	transport *adbTransport
+	localID   uint32
+	remoteID  uint32
+
+	opened chan struct{}
+	acks   chan struct{}
+	data   chan []byte
+	done   chan struct{}
+
+	pending []byte
+
+	mu            sync.Mutex
+	isOpen        bool
+	isDone        bool
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+// okay handles OKAY, which first confirms the open and then acknowledges
+// each WRTE.
+func (s *adbStream) okay(remoteID uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isOpen {
+		s.isOpen = true
+		s.remoteID = remoteID
+		close(s.opened)
+		return
+	}
+	select {
+	case s.acks <- struct{}{}:
+	default:
+	}
+}
+
+func (s *adbStream) remoteClose() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isDone {
+		s.isDone = true
+		close(s.done)
+	}
+}
+
+func (s *adbStream) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		s.mu.Lock()
+		timeout, stop := deadlineTimer(s.readDeadline)
+		s.mu.Unlock()
+		defer stop()
+
+		select {
+		case chunk := <-s.data:
+			s.pending = chunk
+		case <-s.done:
+			// Data that arrived before CLSE is still delivered.
+			select {
+			case chunk := <-s.data:
+				s.pending = chunk
+			default:
+				return 0, io.EOF
+			}
+		case <-timeout:
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	if len(s.pending) == 0 {
+		s.transport.send(adbMessage{command: adbCmdOkay, arg0: s.localID, arg1: s.remoteID})
+	}
+	return n, nil
+}
+
+func (s *adbStream) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		end := min(written+s.transport.maxPayload, len(p))
+		if err := s.transport.send(adbMessage{command: adbCmdWrte, arg0: s.localID, arg1: s.remoteID, data: p[written:end]}); err != nil {
+			return written, err
+		}
+
+		s.mu.Lock()
+		timeout, stop := deadlineTimer(s.writeDeadline)
+		s.mu.Unlock()
+		select {
+		case <-s.acks:
+			stop()
+		case <-s.done:
+			stop()
+			return written, io.ErrClosedPipe
+		case <-timeout:
+			return written, os.ErrDeadlineExceeded
+		}
+		written = end
+	}
+	return written, nil
+}
+
+func (s *adbStream) Close() error {
+	s.mu.Lock()
+	alreadyDone := s.isDone
+	if !s.isDone {
+		s.isDone = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	s.transport.removeStream(s.localID)
+	if !alreadyDone {
+		return s.transport.send(adbMessage{command: adbCmdClse, arg0: s.localID, arg1: s.remoteID})
+	}
+	return nil
+}
+
+func (s *adbStream) LocalAddr() net.Addr  { return s.transport.conn.LocalAddr() }
+func (s *adbStream) RemoteAddr() net.Addr { return s.transport.conn.RemoteAddr() }
+
+func (s *adbStream) SetDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.readDeadline, s.writeDeadline = t, t
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *adbStream) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.readDeadline = t
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *adbStream) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.writeDeadline = t
+	s.mu.Unlock()
+	return nil
+}
+
+// deadlineTimer returns a channel that fires at deadline, or never for a zero
+// deadline.
+func deadlineTimer(deadline time.Time) (<-chan time.Time, func()) {
+	if deadline.IsZero() {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	return timer.C, func() { timer.Stop() }
+}
@@ -0,0 +1,367 @@
+package backend
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	testAdbKeyOnce sync.Once
+	testAdbKey     *rsa.PrivateKey
+)
+
+// adbTestKey is shared by the tests, since 2048-bit keys are slow to make.
+func adbTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testAdbKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, adbKeyBits)
+		if err != nil {
+			panic(err)
+		}
+		testAdbKey = key
+	})
+	return testAdbKey
+}
+
+// fakeAdbd plays adbd's side of one connection.
+type fakeAdbd struct {
+	t    *testing.T
+	conn net.Conn
+}
+
+// startFakeAdbd listens on a local port and runs handle on the first
+// connection. The test waits for handle to finish.
+func startFakeAdbd(t *testing.T, handle func(d *fakeAdbd)) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := listener.Accept()
+		listener.Close()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		handle(&fakeAdbd{t: t, conn: conn})
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		<-done
+	})
+	return listener.Addr().String()
+}
+
+func (d *fakeAdbd) fail(format string, args ...any) {
+	d.t.Errorf("adbd: "+format, args...)
+	d.conn.Close()
+	runtime.Goexit()
+}
+
+func (d *fakeAdbd) send(m adbMessage) {
+	if err := writeAdbMessage(d.conn, m); err != nil {
+		d.fail("write: %v", err)
+	}
+}
+
+// expect reads the next message and checks its command.
+func (d *fakeAdbd) expect(command uint32) adbMessage {
+	m, err := readAdbMessage(d.conn)
+	if err != nil {
+		d.fail("read: %v", err)
+	}
+	if m.command != command {
+		d.fail("got command %08x, want %08x", m.command, command)
+	}
+	return m
+}
+
+// authToken sends an AUTH token and returns it.
+func (d *fakeAdbd) authToken() []byte {
+	token := make([]byte, 20)
+	rand.Read(token)
+	d.send(adbMessage{command: adbCmdAuth, arg0: adbAuthToken, data: token})
+	return token
+}
+
+func (d *fakeAdbd) connect(maxPayload uint32, banner string) {
+	d.send(adbMessage{command: adbCmdCnxn, arg0: adbProtocolVersion, arg1: maxPayload, data: []byte(banner)})
+}
+
+func (d *fakeAdbd) expectHostBanner() {
+	m := d.expect(adbCmdCnxn)
+	if !strings.HasPrefix(string(m.data), "host::features=") || !strings.Contains(string(m.data), "shell_v2") {
+		d.fail("unexpected host banner %q", m.data)
+	}
+}
+
+func TestAdbHandshakeSignsToken(t *testing.T) {
+	key := adbTestKey(t)
+	address := startFakeAdbd(t, func(d *fakeAdbd) {
+		d.expectHostBanner()
+		token := d.authToken()
+		m := d.expect(adbCmdAuth)
+		if m.arg0 != adbAuthSignature {
+			d.fail("AUTH type %d, want a signature", m.arg0)
+		}
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, token, m.data); err != nil {
+			d.fail("signature does not verify: %v", err)
+		}
+		d.connect(4096, "device::ro.product.name=oriole;ro.product.model=Pixel 6;ro.product.device=oriole;features=shell_v2,cmd\x00")
+	})
+
+	transport, err := dialAdbTransport(testContext(t), address, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.Close()
+
+	if transport.device.Serial != address || transport.device.Model != "Pixel 6" || transport.device.Status != "device" {
+		t.Errorf("device = %+v", transport.device)
+	}
+	if !transport.features["shell_v2"] || !transport.features["cmd"] {
+		t.Errorf("features = %v", transport.features)
+	}
+	if transport.maxPayload != 4096 {
+		t.Errorf("maxPayload = %d, want 4096", transport.maxPayload)
+	}
+}
+
+func TestAdbHandshakeOffersPublicKey(t *testing.T) {
+	key := adbTestKey(t)
+	publicKey, err := encodeAdbPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(publicKey); err != nil || len(raw) != 524 {
+		t.Fatalf("encoded public key is %d bytes (%v), want 524", len(raw), err)
+	}
+
+	address := startFakeAdbd(t, func(d *fakeAdbd) {
+		d.expectHostBanner()
+		d.authToken()
+		d.expect(adbCmdAuth)
+		// An unknown key: adbd asks again, and the host offers its public key.
+		d.authToken()
+		m := d.expect(adbCmdAuth)
+		if m.arg0 != adbAuthRSAPublicKey || !strings.HasPrefix(string(m.data), publicKey+" ") || !strings.HasSuffix(string(m.data), "\x00") {
+			d.fail("AUTH %d %q, want the public key", m.arg0, m.data)
+		}
+		d.connect(adbMaxPayload, "device::features=shell_v2")
+	})
+
+	transport, err := dialAdbTransport(testContext(t), address, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport.Close()
+}
+
+func TestAdbHandshakeRejectedKey(t *testing.T) {
+	address := startFakeAdbd(t, func(d *fakeAdbd) {
+		d.expectHostBanner()
+		d.authToken()
+		d.expect(adbCmdAuth)
+		d.authToken()
+		d.expect(adbCmdAuth)
+		d.authToken()
+		// The host gives up; it closes the connection.
+		readAdbMessage(d.conn)
+	})
+
+	_, err := dialAdbTransport(testContext(t), address, adbTestKey(t))
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("dialAdbTransport error = %v, want unauthorized", err)
+	}
+}
+
+func TestAdbHandshakeSTLS(t *testing.T) {
+	key := adbTestKey(t)
+	serverKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(2), NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &serverKey.PublicKey, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := startFakeAdbd(t, func(d *fakeAdbd) {
+		d.expectHostBanner()
+		d.send(adbMessage{command: adbCmdStls, arg0: adbStlsVersion})
+		d.expect(adbCmdStls)
+
+		tlsConn := tls.Server(d.conn, &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: serverKey}},
+			ClientAuth:   tls.RequireAnyClientCert,
+			MinVersion:   tls.VersionTLS13,
+		})
+		if err := tlsConn.Handshake(); err != nil {
+			d.fail("TLS handshake: %v", err)
+		}
+		// adbd knows the host by the key in its certificate.
+		peer := tlsConn.ConnectionState().PeerCertificates
+		if len(peer) != 1 || !key.PublicKey.Equal(peer[0].PublicKey) {
+			d.fail("client certificate does not carry the adb key")
+		}
+		d.conn = tlsConn
+		d.connect(adbMaxPayload, "device::ro.product.model=TLS;features=shell_v2")
+		// Keep the session up until the host closes it.
+		readAdbMessage(d.conn)
+	})
+
+	transport, err := dialAdbTransport(testContext(t), address, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.Close()
+	if transport.device.Model != "TLS" {
+		t.Errorf("device = %+v, want the banner read over TLS", transport.device)
+	}
+}
+
+func TestAdbStreamFlowControl(t *testing.T) {
+	const remoteID = 7
+	var acked atomic.Int32
+
+	address := startFakeAdbd(t, func(d *fakeAdbd) {
+		d.expectHostBanner()
+		d.connect(4, "device::features=shell_v2")
+
+		open := d.expect(adbCmdOpen)
+		if string(open.data) != "shell:cat\x00" {
+			d.fail("OPEN %q", open.data)
+		}
+		localID := open.arg0
+		d.send(adbMessage{command: adbCmdOkay, arg0: remoteID, arg1: localID})
+
+		// The host writes in maxPayload pieces and waits for OKAY after each.
+		var received []byte
+		for len(received) < 10 {
+			m := d.expect(adbCmdWrte)
+			if m.arg0 != localID || m.arg1 != remoteID || len(m.data) > 4 {
+				d.fail("WRTE %d->%d of %d bytes", m.arg0, m.arg1, len(m.data))
+			}
+			received = append(received, m.data...)
+			time.Sleep(10 * time.Millisecond)
+			acked.Add(1)
+			d.send(adbMessage{command: adbCmdOkay, arg0: remoteID, arg1: localID})
+		}
+		if string(received) != "0123456789" {
+			d.fail("received %q", received)
+		}
+
+		// The device writes one chunk at a time, each acknowledged once read.
+		for _, chunk := range []string{"hel", "lo"} {
+			d.send(adbMessage{command: adbCmdWrte, arg0: remoteID, arg1: localID, data: []byte(chunk)})
+			if m := d.expect(adbCmdOkay); m.arg0 != localID || m.arg1 != remoteID {
+				d.fail("OKAY %d->%d", m.arg0, m.arg1)
+			}
+		}
+		d.send(adbMessage{command: adbCmdClse, arg0: remoteID, arg1: localID})
+
+		// A second stream is closed by the host.
+		open = d.expect(adbCmdOpen)
+		d.send(adbMessage{command: adbCmdOkay, arg0: remoteID + 1, arg1: open.arg0})
+		if m := d.expect(adbCmdClse); m.arg0 != open.arg0 || m.arg1 != remoteID+1 {
+			d.fail("CLSE %d->%d", m.arg0, m.arg1)
+		}
+		readAdbMessage(d.conn)
+	})
+
+	ctx := testContext(t)
+	transport, err := dialAdbTransport(ctx, address, adbTestKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.Close()
+
+	stream, err := transport.openStream(ctx, "shell:cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := stream.Write([]byte("0123456789")); err != nil || n != 10 {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if got := acked.Load(); got != 3 {
+		t.Errorf("Write returned after %d acknowledgements, want 3", got)
+	}
+
+	output, err := io.ReadAll(stream)
+	if err != nil || string(output) != "hello" {
+		t.Fatalf("ReadAll = %q, %v", output, err)
+	}
+	stream.Close()
+
+	second, err := transport.openStream(ctx, "shell:true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdbDirectInstall(t *testing.T) {
+	t.Setenv("ANDROID_USER_HOME", t.TempDir())
+	apk := filepath.Join(t.TempDir(), "app.apk")
+	if err := os.WriteFile(apk, []byte("PK\x03\x04apk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	address := startFakeAdbd(t, func(d *fakeAdbd) {
+		d.expectHostBanner()
+		d.authToken()
+		d.expect(adbCmdAuth)
+		d.connect(adbMaxPayload, "device::features=shell_v2,cmd")
+
+		open := d.expect(adbCmdOpen)
+		if string(open.data) != "exec:cmd package install -r -S 7\x00" {
+			d.fail("OPEN %q", open.data)
+		}
+		d.send(adbMessage{command: adbCmdOkay, arg0: 1, arg1: open.arg0})
+		if m := d.expect(adbCmdWrte); string(m.data) != "PK\x03\x04apk" {
+			d.fail("APK data %q", m.data)
+		}
+		d.send(adbMessage{command: adbCmdOkay, arg0: 1, arg1: open.arg0})
+		d.send(adbMessage{command: adbCmdWrte, arg0: 1, arg1: open.arg0, data: []byte("Success\n")})
+		d.expect(adbCmdOkay)
+		d.send(adbMessage{command: adbCmdClse, arg0: 1, arg1: open.arg0})
+		readAdbMessage(d.conn)
+	})
+
+	// No adb server listens here; the direct connection must not need one.
+	client := &adbClient{address: "127.0.0.1:1", direct: make(map[string]*adbTransport)}
+	ctx := testContext(t)
+	if _, err := client.ConnectDirect(ctx, address); err != nil {
+		t.Fatal(err)
+	}
+	defer client.DisconnectDirect(address)
+
+	output, err := client.Install(ctx, address, apk)
+	if err != nil || output != "Success" {
+		t.Fatalf("Install = %q, %v", output, err)
+	}
+}
@@ -15,8 +15,13 @@ const (
 )
 
 type Device struct {
-	Serial string
-	Status string
+	Serial      string
+	Status      string
+	Product     string
+	Model       string
+	DeviceName  string
+	TransportID string
+	USB         string
 }
 type DeviceInfo struct {
 	Model          string
@@ -31,6 +36,7 @@ type DeviceInfo struct {
 	StorageInfo    string
 	Brand          string
 	DeviceName     string
+	SecurityPatch  string
 }
 type FileEntry struct {
 	Name        string
@@ -48,21 +54,57 @@ type PackageInfo struct {
 
 type App struct {
 	ctx         context.Context
+	adb         *adbClient
+	watcher     *deviceWatcher
 	binaryCache map[string]string
 	cacheMutex  sync.RWMutex
-	
-	currentCancel context.CancelFunc
-	opMutex       sync.Mutex
+
+	// patchLevels remembers ro.build.version.security_patch per serial, so
+	// images can be checked against it once the device is in bootloader.
+	patchLevels map[string]string
+	patchMutex  sync.RWMutex
+
+	// lockTokens are the confirmations issued for bootloader lock changes.
+	lockTokens map[string]lockConfirmation
+	lockMutex  sync.Mutex
+
+	// tcpFastboot maps connected tcp: fastboot targets to their product,
+	// since `fastboot devices` only lists USB devices.
+	tcpFastboot      map[string]string
+	tcpFastbootMutex sync.Mutex
+
+	// headlessAdb connects wireless devices in-process and keeps the app
+	// from starting an adb server.
+	headlessAdb   bool
+	headlessMutex sync.Mutex
+
+	targetSerial string
+	targetMutex  sync.RWMutex
+
+	jobs   *jobManager
+	shells *shellManager
+	logcat *logcatManager
 }
 
 func NewApp() *App {
-	return &App{
+	app := &App{
+		adb:         newAdbClient(),
 		binaryCache: make(map[string]string),
+		patchLevels: make(map[string]string),
+		lockTokens:  make(map[string]lockConfirmation),
+		tcpFastboot: make(map[string]string),
 	}
+	app.jobs = newJobManager(app)
+	app.shells = newShellManager(app)
+	app.logcat = newLogcatManager(app)
+	return app
 }
 
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+
+	a.watcher = newDeviceWatcher(a)
+	a.watcher.run(ctx)
 }
 
 func (a *App) Greet(name string) string {
@@ -70,12 +112,7 @@ func (a *App) Greet(name string) string {
 }
 
 func (a *App) CancelOperation() string {
-	a.opMutex.Lock()
-	defer a.opMutex.Unlock()
-
-	if a.currentCancel != nil {
-		a.currentCancel()
-		a.currentCancel = nil
+	if a.CancelAll() > 0 {
 		return "Operation cancelled."
 	}
 	return "No active operation to cancel."
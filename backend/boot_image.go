@@ -0,0 +1,451 @@
+package backend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	bootMagic       = "ANDROID!"
+	vendorBootMagic = "VNDRBOOT"
+	// Boot image v3 and later use a fixed page size.
+	bootImagePageSize = 4096
+	// Only the start of the ramdisk is needed to spot Magisk's files.
+	maxRamdiskScan = 64 << 20
+)
+
+const (
+	BootImageKindBoot       = "boot"
+	BootImageKindInitBoot   = "init_boot"
+	BootImageKindVendorBoot = "vendor_boot"
+)
+
+var (
+	patchLevelPattern = regexp.MustCompile(`^\d{4}-\d{2}(-\d{2})?$`)
+	slotSuffixPattern = regexp.MustCompile(`_[ab]$`)
+
+	lz4LegacyMagic = []byte{0x02, 0x21, 0x4c, 0x18}
+	gzipMagic      = []byte{0x1f, 0x8b}
+	xzMagic        = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+
+	// Files Magisk adds when it patches a ramdisk.
+	magiskRamdiskMarkers = []string{".backup/.magisk", "overlay.d/sbin/magisk"}
+)
+
+type BootImageInfo struct {
+	Path string
+	// Kind is boot, init_boot (a v4 boot image without a kernel) or
+	// vendor_boot.
+	Kind          string
+	HeaderVersion int
+	PageSize      int
+	KernelSize    int64
+	RamdiskSize   int64
+	SecondSize    int64
+	DtbSize       int64
+	// OSVersion and OSPatchLevel are empty when the header leaves them unset.
+	OSVersion          string
+	OSPatchLevel       string
+	Name               string
+	Cmdline            string
+	RamdiskCompression string
+	MagiskPatched      bool
+	Warnings           []string
+}
+
+// InspectBootImage parses a boot, init_boot or vendor_boot image. When
+// partition is set, Warnings lists reasons it probably does not belong there.
+func (a *App) InspectBootImage(filePath string, partition string) (BootImageInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return BootImageInfo{}, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	info, err := parseBootImage(file)
+	if err != nil {
+		return BootImageInfo{}, err
+	}
+	info.Path = filePath
+
+	devicePatch := ""
+	if serial := a.GetTargetDevice(); serial != "" {
+		a.patchMutex.RLock()
+		devicePatch = a.patchLevels[serial]
+		a.patchMutex.RUnlock()
+	}
+	info.Warnings = bootImageWarnings(info, partition, devicePatch)
+	return info, nil
+}
+
+func parseBootImage(r io.ReaderAt) (BootImageInfo, error) {
+	header := make([]byte, bootImagePageSize)
+	n, err := r.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return BootImageInfo{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	header = header[:n]
+
+	var info BootImageInfo
+	var ramdiskOffset int64
+	switch {
+	case bytes.HasPrefix(header, []byte(bootMagic)):
+		info, ramdiskOffset, err = parseBootHeader(header)
+	case bytes.HasPrefix(header, []byte(vendorBootMagic)):
+		info, ramdiskOffset, err = parseVendorBootHeader(header)
+	default:
+		return BootImageInfo{}, fmt.Errorf("not an Android boot image")
+	}
+	if err != nil {
+		return BootImageInfo{}, err
+	}
+
+	if info.RamdiskSize > 0 {
+		ramdisk := io.NewSectionReader(r, ramdiskOffset, min(info.RamdiskSize, maxRamdiskScan))
+		info.RamdiskCompression, info.MagiskPatched = inspectRamdisk(ramdisk)
+	}
+	return info, nil
+}
+
+func parseBootHeader(h []byte) (BootImageInfo, int64, error) {
+	if len(h) < 1660 {
+		return BootImageInfo{}, 0, fmt.Errorf("boot image header truncated")
+	}
+	le := binary.LittleEndian
+	info := BootImageInfo{Kind: BootImageKindBoot, HeaderVersion: int(le.Uint32(h[40:]))}
+
+	var osVersion uint32
+	var kernelOffset int64
+	if info.HeaderVersion >= 3 {
+		info.KernelSize = int64(le.Uint32(h[8:]))
+		info.RamdiskSize = int64(le.Uint32(h[12:]))
+		osVersion = le.Uint32(h[16:])
+		info.PageSize = bootImagePageSize
+		info.Cmdline = cString(h[44 : 44+1536])
+		kernelOffset = bootImagePageSize
+		if info.KernelSize == 0 && info.RamdiskSize > 0 {
+			info.Kind = BootImageKindInitBoot
+		}
+	} else {
+		info.KernelSize = int64(le.Uint32(h[8:]))
+		info.RamdiskSize = int64(le.Uint32(h[16:]))
+		info.SecondSize = int64(le.Uint32(h[24:]))
+		info.PageSize = int(le.Uint32(h[36:]))
+		osVersion = le.Uint32(h[44:])
+		info.Name = cString(h[48:64])
+		// mkbootimg continues a full cmdline field in extra_cmdline.
+		info.Cmdline = cString(append(append([]byte(nil), h[64:576]...), h[608:1632]...))
+		if info.HeaderVersion == 2 {
+			info.DtbSize = int64(le.Uint32(h[1648:]))
+		}
+		if info.PageSize <= 0 || info.PageSize&(info.PageSize-1) != 0 {
+			return BootImageInfo{}, 0, fmt.Errorf("invalid page size %d", info.PageSize)
+		}
+		kernelOffset = int64(info.PageSize)
+	}
+
+	info.OSVersion, info.OSPatchLevel = decodeOSVersion(osVersion)
+	ramdiskOffset := kernelOffset + alignTo(info.KernelSize, int64(info.PageSize))
+	return info, ramdiskOffset, nil
+}
+
+func parseVendorBootHeader(h []byte) (BootImageInfo, int64, error) {
+	if len(h) < 2112 {
+		return BootImageInfo{}, 0, fmt.Errorf("vendor_boot header truncated")
+	}
+	le := binary.LittleEndian
+	info := BootImageInfo{
+		Kind:          BootImageKindVendorBoot,
+		HeaderVersion: int(le.Uint32(h[8:])),
+		PageSize:      int(le.Uint32(h[12:])),
+		RamdiskSize:   int64(le.Uint32(h[24:])),
+		Cmdline:       cString(h[28 : 28+2048]),
+		Name:          cString(h[2080:2096]),
+		DtbSize:       int64(le.Uint32(h[2100:])),
+	}
+	if info.PageSize <= 0 || info.PageSize&(info.PageSize-1) != 0 {
+		return BootImageInfo{}, 0, fmt.Errorf("invalid page size %d", info.PageSize)
+	}
+	headerSize := int64(le.Uint32(h[2096:]))
+	return info, alignTo(headerSize, int64(info.PageSize)), nil
+}
+
+// decodeOSVersion splits the packed os_version field: A.B.C in the top 21
+// bits and the patch level year/month in the low 11.
+func decodeOSVersion(value uint32) (string, string) {
+	if value == 0 {
+		return "", ""
+	}
+	version := value >> 11
+	patch := value & 0x7ff
+
+	osVersion := ""
+	if version != 0 {
+		osVersion = fmt.Sprintf("%d.%d.%d", version>>14&0x7f, version>>7&0x7f, version&0x7f)
+	}
+	patchLevel := ""
+	if patch != 0 {
+		patchLevel = fmt.Sprintf("%04d-%02d", 2000+(patch>>4), patch&0xf)
+	}
+	return osVersion, patchLevel
+}
+
+// inspectRamdisk reports the ramdisk compression and whether its cpio
+// archive holds Magisk's files.
+func inspectRamdisk(r io.Reader) (string, bool) {
+	buffered := make([]byte, 8)
+	n, _ := io.ReadFull(r, buffered)
+	buffered = buffered[:n]
+	stream := io.MultiReader(bytes.NewReader(buffered), r)
+
+	var archive io.Reader
+	compression := "unknown"
+	switch {
+	case bytes.HasPrefix(buffered, lz4LegacyMagic):
+		compression = "lz4"
+		reader := newLZ4LegacyReader(stream)
+		// Closing stops the decoder when the scan ends early.
+		defer reader.Close()
+		archive = reader
+	case bytes.HasPrefix(buffered, gzipMagic):
+		compression = "gzip"
+		if reader, err := gzip.NewReader(stream); err == nil {
+			archive = reader
+		}
+	case bytes.HasPrefix(buffered, xzMagic):
+		compression = "xz"
+		if reader, err := xz.NewReader(stream); err == nil {
+			archive = reader
+		}
+	case bytes.HasPrefix(buffered, []byte("070701")):
+		compression = "none"
+		archive = stream
+	}
+	if archive == nil {
+		return compression, false
+	}
+
+	names := cpioNames(io.LimitReader(archive, maxRamdiskScan))
+	for _, name := range names {
+		for _, marker := range magiskRamdiskMarkers {
+			if strings.HasPrefix(name, marker) {
+				return compression, true
+			}
+		}
+	}
+	return compression, false
+}
+
+// cpioNames lists the entries of a newc cpio archive, stopping quietly at
+// the trailer or the first malformed header.
+func cpioNames(r io.Reader) []string {
+	var names []string
+	var read int64
+	header := make([]byte, 110)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil || string(header[:6]) != "070701" {
+			return names
+		}
+		fileSize, err1 := strconv.ParseUint(string(header[54:62]), 16, 32)
+		nameSize, err2 := strconv.ParseUint(string(header[94:102]), 16, 32)
+		if err1 != nil || err2 != nil || nameSize == 0 || nameSize > 4096 {
+			return names
+		}
+		read += 110
+
+		name := make([]byte, nameSize)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return names
+		}
+		read += int64(nameSize)
+		entry := string(bytes.TrimRight(name, "\x00"))
+		if entry == "TRAILER!!!" {
+			return names
+		}
+		names = append(names, entry)
+
+		skip := alignTo(read, 4) - read + alignTo(int64(fileSize), 4)
+		if _, err := io.CopyN(io.Discard, r, skip); err != nil {
+			return names
+		}
+		read += skip
+	}
+}
+
+// newLZ4LegacyReader decodes the legacy lz4 frame format mkbootfs output is
+// compressed with: the magic, then blocks each prefixed by a little-endian
+// compressed size. Close the reader to stop decoding before the end.
+func newLZ4LegacyReader(r io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		magic := make([]byte, 4)
+		if _, err := io.ReadFull(r, magic); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		sizeBuf := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(r, sizeBuf); err != nil {
+				pw.Close()
+				return
+			}
+			size := binary.LittleEndian.Uint32(sizeBuf)
+			if bytes.Equal(sizeBuf, lz4LegacyMagic) {
+				// Concatenated frames repeat the magic.
+				continue
+			}
+			if size > 16<<20 {
+				pw.CloseWithError(fmt.Errorf("lz4 block too large"))
+				return
+			}
+			block := make([]byte, size)
+			if _, err := io.ReadFull(r, block); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			out, err := lz4DecodeBlock(block, 8<<20)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := pw.Write(out); err != nil {
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// lz4DecodeBlock decompresses a single lz4 block of at most maxSize bytes.
+func lz4DecodeBlock(src []byte, maxSize int) ([]byte, error) {
+	dst := make([]byte, 0, maxSize)
+	for i := 0; i < len(src); {
+		token := src[i]
+		i++
+
+		literals := int(token >> 4)
+		if literals == 15 {
+			for i < len(src) {
+				b := src[i]
+				i++
+				literals += int(b)
+				if b != 255 {
+					break
+				}
+			}
+		}
+		if i+literals > len(src) || len(dst)+literals > maxSize {
+			return nil, fmt.Errorf("corrupt lz4 block")
+		}
+		dst = append(dst, src[i:i+literals]...)
+		i += literals
+		if i == len(src) {
+			return dst, nil
+		}
+
+		if i+2 > len(src) {
+			return nil, fmt.Errorf("corrupt lz4 block")
+		}
+		offset := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		if offset == 0 || offset > len(dst) {
+			return nil, fmt.Errorf("corrupt lz4 block")
+		}
+
+		length := int(token&0xf) + 4
+		if token&0xf == 15 {
+			for i < len(src) {
+				b := src[i]
+				i++
+				length += int(b)
+				if b != 255 {
+					break
+				}
+			}
+		}
+		if len(dst)+length > maxSize {
+			return nil, fmt.Errorf("corrupt lz4 block")
+		}
+		// Matches may overlap their own output, so copy byte by byte.
+		start := len(dst) - offset
+		for k := 0; k < length; k++ {
+			dst = append(dst, dst[start+k])
+		}
+	}
+	return dst, nil
+}
+
+// bootImageWarnings explains why an image probably does not belong on
+// partition. devicePatch is the patch level last seen on the device, if any.
+func bootImageWarnings(info BootImageInfo, partition string, devicePatch string) []string {
+	warnings := []string{}
+	target := slotSuffixPattern.ReplaceAllString(strings.ToLower(strings.TrimSpace(partition)), "")
+
+	switch target {
+	case "":
+	case "boot":
+		switch info.Kind {
+		case BootImageKindInitBoot:
+			warnings = append(warnings, "This image has no kernel, so it looks like an init_boot image. Flash it to init_boot instead.")
+		case BootImageKindVendorBoot:
+			warnings = append(warnings, "This is a vendor_boot image, not a boot image.")
+		}
+	case "init_boot":
+		switch {
+		case info.Kind == BootImageKindVendorBoot:
+			warnings = append(warnings, "This is a vendor_boot image, not an init_boot image.")
+		case info.KernelSize > 0:
+			warnings = append(warnings, "This image contains a kernel, so it looks like a boot image. init_boot only holds the generic ramdisk.")
+		case info.HeaderVersion < 4:
+			warnings = append(warnings, fmt.Sprintf("init_boot needs a v4 header, this image is v%d.", info.HeaderVersion))
+		}
+	case "vendor_boot":
+		if info.Kind != BootImageKindVendorBoot {
+			warnings = append(warnings, fmt.Sprintf("This is a %s image, not a vendor_boot image.", info.Kind))
+		}
+	case "recovery":
+		if info.Kind != BootImageKindBoot {
+			warnings = append(warnings, fmt.Sprintf("This is a %s image, not a recovery image.", info.Kind))
+		} else if info.HeaderVersion >= 3 {
+			warnings = append(warnings, "Devices with v3+ boot images keep recovery in the boot ramdisk and usually have no recovery partition.")
+		}
+	default:
+		warnings = append(warnings, fmt.Sprintf("This is a %s image, but the target partition is %s.", info.Kind, partition))
+	}
+
+	if info.OSPatchLevel != "" && devicePatch != "" {
+		// Compare year and month only; the header has no day.
+		current := devicePatch[:7]
+		switch {
+		case info.OSPatchLevel < current:
+			warnings = append(warnings, fmt.Sprintf("Image patch level %s is older than the device's %s. Rollback protection may refuse to boot it.", info.OSPatchLevel, devicePatch))
+		case info.OSPatchLevel > current:
+			warnings = append(warnings, fmt.Sprintf("Image patch level %s does not match the device's %s. Patched images should come from the installed build.", info.OSPatchLevel, devicePatch))
+		}
+	}
+	return warnings
+}
+
+func alignTo(value int64, alignment int64) int64 {
+	if alignment <= 0 {
+		return value
+	}
+	return (value + alignment - 1) / alignment * alignment
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,234 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testOSVersion packs Android 14.0.0 with the 2024-03 patch level.
+const testOSVersion = (14<<14|0<<7|0)<<11 | (24<<4 | 3)
+
+func testBootHeader(version int, kernelSize, ramdiskSize uint32, pageSize uint32) []byte {
+	h := make([]byte, bootImagePageSize)
+	copy(h, bootMagic)
+	le := binary.LittleEndian
+	le.PutUint32(h[8:], kernelSize)
+	le.PutUint32(h[40:], uint32(version))
+	if version >= 3 {
+		le.PutUint32(h[12:], ramdiskSize)
+		le.PutUint32(h[16:], testOSVersion)
+		copy(h[44:], "console=ttyMSM0")
+		return h
+	}
+	le.PutUint32(h[16:], ramdiskSize)
+	le.PutUint32(h[24:], 0x800)
+	le.PutUint32(h[36:], pageSize)
+	le.PutUint32(h[44:], testOSVersion)
+	copy(h[48:], "sunfish")
+	// Fill the cmdline field so the rest goes to extra_cmdline.
+	copy(h[64:576], strings.Repeat("console=ttyMSM0 ", 32))
+	copy(h[608:], "androidboot.hardware=qcom")
+	le.PutUint32(h[1648:], 0x1000)
+	return h
+}
+
+func TestParseBootHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      []byte
+		want        BootImageInfo
+		wantRamdisk int64
+		wantErr     bool
+	}{
+		{
+			name:   "v2 boot",
+			header: testBootHeader(2, 5000, 100, 2048),
+			want: BootImageInfo{
+				Kind: BootImageKindBoot, HeaderVersion: 2, PageSize: 2048, KernelSize: 5000, RamdiskSize: 100,
+				SecondSize: 0x800, DtbSize: 0x1000, OSVersion: "14.0.0", OSPatchLevel: "2024-03",
+				Name: "sunfish", Cmdline: strings.Repeat("console=ttyMSM0 ", 32) + "androidboot.hardware=qcom",
+			},
+			// The kernel starts after the header page and is padded to a page.
+			wantRamdisk: 2048 + 6144,
+		},
+		{
+			name:   "v4 boot",
+			header: testBootHeader(4, 4097, 100, 0),
+			want: BootImageInfo{
+				Kind: BootImageKindBoot, HeaderVersion: 4, PageSize: bootImagePageSize, KernelSize: 4097, RamdiskSize: 100,
+				OSVersion: "14.0.0", OSPatchLevel: "2024-03", Cmdline: "console=ttyMSM0",
+			},
+			wantRamdisk: 3 * bootImagePageSize,
+		},
+		{
+			name:   "v4 init_boot",
+			header: testBootHeader(4, 0, 100, 0),
+			want: BootImageInfo{
+				Kind: BootImageKindInitBoot, HeaderVersion: 4, PageSize: bootImagePageSize, RamdiskSize: 100,
+				OSVersion: "14.0.0", OSPatchLevel: "2024-03", Cmdline: "console=ttyMSM0",
+			},
+			wantRamdisk: bootImagePageSize,
+		},
+		{name: "truncated", header: testBootHeader(2, 0, 0, 2048)[:1659], wantErr: true},
+		{name: "zero page size", header: testBootHeader(2, 0, 0, 0), wantErr: true},
+		{name: "odd page size", header: testBootHeader(2, 0, 0, 3000), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ramdisk, err := parseBootHeader(tt.header)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseBootHeader succeeded with %+v", info)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%+v", info) != fmt.Sprintf("%+v", tt.want) {
+				t.Errorf("info = %+v\nwant   %+v", info, tt.want)
+			}
+			if ramdisk != tt.wantRamdisk {
+				t.Errorf("ramdisk offset = %d, want %d", ramdisk, tt.wantRamdisk)
+			}
+		})
+	}
+}
+
+func TestDecodeOSVersion(t *testing.T) {
+	tests := []struct {
+		value       uint32
+		wantVersion string
+		wantPatch   string
+	}{
+		{0, "", ""},
+		{testOSVersion, "14.0.0", "2024-03"},
+		{(11<<14 | 2<<7 | 1) << 11, "11.2.1", ""},
+		{21<<4 | 12, "", "2021-12"},
+	}
+
+	for _, tt := range tests {
+		version, patch := decodeOSVersion(tt.value)
+		if version != tt.wantVersion || patch != tt.wantPatch {
+			t.Errorf("decodeOSVersion(%#x) = %q, %q; want %q, %q", tt.value, version, patch, tt.wantVersion, tt.wantPatch)
+		}
+	}
+}
+
+func TestLZ4DecodeBlock(t *testing.T) {
+	long := strings.Repeat("x", 20)
+	tests := []struct {
+		name    string
+		src     []byte
+		maxSize int
+		want    string
+		wantErr bool
+	}{
+		{name: "literals", src: append([]byte{0x50}, "hello"...), maxSize: 64, want: "hello"},
+		{name: "long literal run", src: append([]byte{0xf0, 5}, long...), maxSize: 64, want: long},
+		// "ab", then a match 2 back for 4+2 bytes that overlaps its own output.
+		{name: "overlapping match", src: []byte{0x22, 'a', 'b', 2, 0, 0x00}, maxSize: 64, want: "abababab"},
+		{name: "long match", src: []byte{0x1f, 'z', 1, 0, 3, 0x00}, maxSize: 64, want: strings.Repeat("z", 1+4+15+3)},
+		{name: "literals past the end", src: append([]byte{0x90}, "short"...), maxSize: 64, wantErr: true},
+		{name: "zero offset", src: []byte{0x10, 'a', 0, 0}, maxSize: 64, wantErr: true},
+		{name: "offset before start", src: []byte{0x10, 'a', 2, 0}, maxSize: 64, wantErr: true},
+		{name: "truncated offset", src: []byte{0x10, 'a', 1}, maxSize: 64, wantErr: true},
+		{name: "over max size", src: []byte{0x1f, 'z', 1, 0, 255, 0}, maxSize: 64, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lz4DecodeBlock(tt.src, tt.maxSize)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("lz4DecodeBlock = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("lz4DecodeBlock = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// testCpio builds a newc cpio archive of empty files.
+func testCpio(names ...string) []byte {
+	var out bytes.Buffer
+	for _, name := range append(names, "TRAILER!!!") {
+		fmt.Fprintf(&out, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x", 0, 0100644, 0, 0, 1, 0, 0, 0, 0, 0, 0, len(name)+1, 0)
+		out.WriteString(name + "\x00")
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+	return out.Bytes()
+}
+
+// testLZ4Legacy frames data as legacy lz4 blocks of literals only.
+func testLZ4Legacy(data []byte, blockSize int) []byte {
+	out := append([]byte(nil), lz4LegacyMagic...)
+	for len(data) > 0 {
+		n := min(blockSize, len(data))
+		block := []byte{0xf0}
+		for rest := n - 15; ; rest -= 255 {
+			if rest < 255 {
+				block = append(block, byte(rest))
+				break
+			}
+			block = append(block, 255)
+		}
+		block = append(block, data[:n]...)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(block)))
+		out = append(out, block...)
+		data = data[n:]
+	}
+	return out
+}
+
+func TestInspectRamdisk(t *testing.T) {
+	stock := testCpio("init", "system/bin/init")
+	patched := testCpio("init", ".backup/.magisk", "overlay.d/sbin/magisk64.xz")
+
+	tests := []struct {
+		name            string
+		ramdisk         []byte
+		wantCompression string
+		wantMagisk      bool
+	}{
+		{"plain cpio", stock, "none", false},
+		{"lz4 stock", testLZ4Legacy(stock, 64), "lz4", false},
+		{"lz4 patched", testLZ4Legacy(patched, 64), "lz4", true},
+		{"unknown", []byte("garbage"), "unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compression, magisk := inspectRamdisk(bytes.NewReader(tt.ramdisk))
+			if compression != tt.wantCompression || magisk != tt.wantMagisk {
+				t.Errorf("inspectRamdisk = %q, %v; want %q, %v", compression, magisk, tt.wantCompression, tt.wantMagisk)
+			}
+		})
+	}
+}
+
+func TestLZ4LegacyReaderClose(t *testing.T) {
+	// Stop after the first block of a long stream; Close must end the decoder
+	// so the read below returns instead of blocking forever.
+	reader := newLZ4LegacyReader(bytes.NewReader(testLZ4Legacy(bytes.Repeat([]byte("a"), 1<<16), 1024)))
+	if _, err := io.ReadFull(reader, make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	reader.Close()
+	if _, err := reader.Read(make([]byte, 16)); err != io.ErrClosedPipe {
+		t.Errorf("read after Close = %v, want io.ErrClosedPipe", err)
+	}
+}
@@ -0,0 +1,245 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	LockActionUnlock         = "unlock"
+	LockActionLock           = "lock"
+	LockActionUnlockCritical = "unlock_critical"
+	LockActionOemUnlock      = "oem_unlock"
+
+	UnlockAbilityAllowed    = "allowed"
+	UnlockAbilityDisallowed = "disallowed"
+	UnlockAbilityUnknown    = "unknown"
+
+	lockTokenLifetime = 2 * time.Minute
+	// Long enough for the user to confirm on the device and for it to wipe
+	// and come back.
+	lockChangeTimeout = 5 * time.Minute
+)
+
+var lockActionCommands = map[string][]string{
+	LockActionUnlock:         {"flashing", "unlock"},
+	LockActionLock:           {"flashing", "lock"},
+	LockActionUnlockCritical: {"flashing", "unlock_critical"},
+	LockActionOemUnlock:      {"oem", "unlock"},
+}
+
+type BootloaderLockState struct {
+	Serial   string
+	Unlocked bool
+	// UnlockAbility mirrors `fastboot flashing get_unlock_ability`, which is
+	// "disallowed" until OEM unlocking is enabled in Developer options.
+	UnlockAbility string
+}
+
+// LockChangePlan describes what a lock change will do. Token must be passed
+// back to ChangeBootloaderLock to run it.
+type LockChangePlan struct {
+	Serial   string
+	Action   string
+	Command  string
+	Warnings []string
+	Token    string
+}
+
+type lockConfirmation struct {
+	serial  string
+	action  string
+	expires time.Time
+}
+
+// GetBootloaderLockState reads the unlocked and get_unlock_ability state of
+// a device in fastboot.
+func (a *App) GetBootloaderLockState(serial string) (BootloaderLockState, error) {
+	serial, err := a.resolveFastbootSerial(serial)
+	if err != nil {
+		return BootloaderLockState{}, err
+	}
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+	return a.readLockState(ctx, serial)
+}
+
+// PrepareBootloaderLockChange checks that action makes sense for the device
+// and issues a short-lived confirmation token for it.
+func (a *App) PrepareBootloaderLockChange(serial string, action string) (LockChangePlan, error) {
+	command, ok := lockActionCommands[action]
+	if !ok {
+		return LockChangePlan{}, fmt.Errorf("unknown lock action %q", action)
+	}
+	state, err := a.GetBootloaderLockState(serial)
+	if err != nil {
+		return LockChangePlan{}, err
+	}
+
+	switch action {
+	case LockActionUnlock, LockActionOemUnlock, LockActionUnlockCritical:
+		// Critical partitions are unlocked separately, so unlock_critical is
+		// also valid on an unlocked bootloader.
+		if state.Unlocked && action != LockActionUnlockCritical {
+			return LockChangePlan{}, fmt.Errorf("the bootloader is already unlocked")
+		}
+		if state.UnlockAbility == UnlockAbilityDisallowed {
+			return LockChangePlan{}, fmt.Errorf("unlocking is not allowed; enable OEM unlocking in Developer options first")
+		}
+	case LockActionLock:
+		if !state.Unlocked {
+			return LockChangePlan{}, fmt.Errorf("the bootloader is already locked")
+		}
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return LockChangePlan{}, err
+	}
+	plan := LockChangePlan{
+		Serial:   state.Serial,
+		Action:   action,
+		Command:  "fastboot " + strings.Join(command, " "),
+		Warnings: lockChangeWarnings(action),
+		Token:    hex.EncodeToString(token),
+	}
+
+	a.lockMutex.Lock()
+	for key, confirmation := range a.lockTokens {
+		if time.Now().After(confirmation.expires) {
+			delete(a.lockTokens, key)
+		}
+	}
+	a.lockTokens[plan.Token] = lockConfirmation{serial: plan.Serial, action: action, expires: time.Now().Add(lockTokenLifetime)}
+	a.lockMutex.Unlock()
+	return plan, nil
+}
+
+// ChangeBootloaderLock runs a lock change prepared by
+// PrepareBootloaderLockChange as a job, then waits for the device to report
+// the new state in fastboot. getvar has no state for critical partitions, so
+// unlock_critical finishes once the command is sent. Each token works once.
+func (a *App) ChangeBootloaderLock(serial string, action string, token string) (string, error) {
+	a.lockMutex.Lock()
+	confirmation, ok := a.lockTokens[token]
+	delete(a.lockTokens, token)
+	a.lockMutex.Unlock()
+
+	if !ok || time.Now().After(confirmation.expires) {
+		return "", fmt.Errorf("confirmation expired; review the warnings and confirm again")
+	}
+	if confirmation.action != action || (serial != "" && confirmation.serial != serial) {
+		return "", fmt.Errorf("confirmation does not match this device and action")
+	}
+	serial = confirmation.serial
+
+	wantUnlocked := action != LockActionLock
+	job := a.jobs.start(nil, "bootloader-lock", fmt.Sprintf("%s %s", strings.Join(lockActionCommands[action], " "), serial), 0)
+	go func() {
+		if _, err := a.runFastboot(job.ctx, serial, lockActionCommands[action]...); err != nil {
+			job.finish("", fmt.Errorf("failed to run fastboot %s: %w", strings.Join(lockActionCommands[action], " "), err))
+			return
+		}
+		if action == LockActionUnlockCritical {
+			job.finish("Sent flashing unlock_critical. Confirm on the device; fastboot cannot report whether critical partitions are unlocked", nil)
+			return
+		}
+		state, err := a.waitForLockState(job.ctx, serial, wantUnlocked)
+		if err != nil {
+			job.finish("", err)
+			return
+		}
+		if state.Unlocked {
+			job.finish("Bootloader is now unlocked", nil)
+		} else {
+			job.finish("Bootloader is now locked", nil)
+		}
+	}()
+	return job.id, nil
+}
+
+func (a *App) readLockState(ctx context.Context, serial string) (BootloaderLockState, error) {
+	value, err := a.fastbootGetvar(ctx, serial, "unlocked")
+	if err != nil {
+		return BootloaderLockState{}, err
+	}
+	state := BootloaderLockState{Serial: serial, Unlocked: value == "yes", UnlockAbility: UnlockAbilityUnknown}
+
+	// Older bootloaders do not know get_unlock_ability.
+	if output, err := a.runFastboot(ctx, serial, "flashing", "get_unlock_ability"); err == nil {
+		state.UnlockAbility = parseUnlockAbility(output)
+	}
+	return state, nil
+}
+
+// parseUnlockAbility reads "(bootloader) get_unlock_ability: 1".
+func parseUnlockAbility(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		_, value, ok := strings.Cut(line, "get_unlock_ability:")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(value) {
+		case "1":
+			return UnlockAbilityAllowed
+		case "0":
+			return UnlockAbilityDisallowed
+		}
+	}
+	return UnlockAbilityUnknown
+}
+
+// waitForLockState polls until the device is back in fastboot and reports
+// the wanted state. The device may reboot or sit on its confirmation screen
+// in the meantime, so failed reads are retried.
+func (a *App) waitForLockState(ctx context.Context, serial string, wantUnlocked bool) (BootloaderLockState, error) {
+	waitCtx, cancel := withCommandTimeout(ctx, lockChangeTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		readCtx, readCancel := withCommandTimeout(waitCtx, 10*time.Second)
+		state, err := a.readLockState(readCtx, serial)
+		readCancel()
+		if err == nil && state.Unlocked == wantUnlocked {
+			return state, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return BootloaderLockState{}, contextError(ctx, "fastboot getvar unlocked")
+			}
+			return BootloaderLockState{}, newTimeoutError("fastboot getvar unlocked", fmt.Sprintf("device %s did not report the new lock state within %s; if it booted to Android, reboot to the bootloader and check again", serial, lockChangeTimeout))
+		}
+	}
+}
+
+func lockChangeWarnings(action string) []string {
+	switch action {
+	case LockActionLock:
+		return []string{
+			"Locking erases all user data on the device.",
+			"Only lock with fully stock firmware. A patched boot, a modified vbmeta or a custom ROM will stop the device from booting, and it may not be recoverable if OEM unlocking is turned off.",
+			"Confirm on the device with the volume and power keys.",
+		}
+	case LockActionUnlockCritical:
+		return []string{
+			"Unlocking critical partitions allows flashing the bootloader and radio, where a bad image can hard-brick the device.",
+			"This erases all user data on most devices.",
+			"Confirm on the device with the volume and power keys.",
+		}
+	default:
+		return []string{
+			"Unlocking erases all user data on the device (factory reset).",
+			"The device shows an unlocked bootloader warning on every boot, and some apps and services stop working.",
+			"Confirm on the device with the volume and power keys.",
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package backend
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -9,31 +11,88 @@ import (
 )
 
 func (a *App) GetDevices() ([]Device, error) {
-	output, err := a.runCommand("adb", "devices")
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+
+	devices, err := a.adb.Devices(ctx)
+	if err == nil {
+		return devices, nil
+	}
+	if !errors.Is(err, errAdbServerUnavailable) {
+		return nil, err
+	}
+
+	output, err := a.runCommandContext(ctx, "adb", "devices", "-l")
 	if err != nil {
 		return nil, err
 	}
 
-	var devices []Device
-	lines := strings.Split(output, "\n")
+	return parseDevicesLong(output), nil
+}
+
+// SetTargetDevice binds every subsequent adb/fastboot call to the given serial.
+// An empty serial clears the selection and falls back to adb's default device.
+func (a *App) SetTargetDevice(serial string) error {
+	serial = strings.TrimSpace(serial)
+	if serial != "" && !a.isDeviceConnected(serial) {
+		return fmt.Errorf("device %s not found in adb or fastboot mode", serial)
+	}
+
+	a.targetMutex.Lock()
+	a.targetSerial = serial
+	a.targetMutex.Unlock()
+	return nil
+}
+
+func (a *App) GetTargetDevice() string {
+	a.targetMutex.RLock()
+	defer a.targetMutex.RUnlock()
+	return a.targetSerial
+}
+
+// resolveAdbSerial picks serial, else the target device, else the only
+// device adb lists. Jobs resolve it once when they start.
+func (a *App) resolveAdbSerial(serial string) (string, error) {
+	if serial == "" {
+		serial = a.GetTargetDevice()
+	}
+	if serial != "" {
+		return serial, nil
+	}
+
+	devices, err := a.GetDevices()
+	if err != nil {
+		return "", err
+	}
+	switch len(devices) {
+	case 0:
+		return "", ErrNoDevice
+	case 1:
+		return devices[0].Serial, nil
+	}
+	return "", ErrMultipleDevices
+}
 
-	if len(lines) > 1 {
-		for _, line := range lines[1:] {
-			parts := strings.Fields(line)
-			if len(parts) == 2 {
-				devices = append(devices, Device{
-					Serial: parts[0],
-					Status: parts[1],
-				})
+func (a *App) isDeviceConnected(serial string) bool {
+	if devices, err := a.GetDevices(); err == nil {
+		for _, device := range devices {
+			if device.Serial == serial {
+				return true
 			}
 		}
 	}
-
-	return devices, nil
+	if devices, err := a.GetFastbootDevices(); err == nil {
+		for _, device := range devices {
+			if device.Serial == serial {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (a *App) getProp(prop string) string {
-	output, err := a.runCommand("adb", "shell", "getprop", prop)
+	output, err := a.runCommand("adb", "shell", shellJoin("getprop", prop))
 	if err != nil {
 		return "N/A"
 	}
@@ -41,7 +100,7 @@ func (a *App) getProp(prop string) string {
 }
 
 func (a *App) checkRootStatus() string {
-	output, err := a.runCommand("adb", "shell", "su", "-c", "id -u")
+	output, err := a.runCommand("adb", "shell", shellJoin("su", "-c", "id -u"))
 	cleanOutput := strings.TrimSpace(output)
 	if err == nil && cleanOutput == "0" {
 		return "Yes"
@@ -133,6 +192,7 @@ func (a *App) GetDeviceInfo() (DeviceInfo, error) {
 		{"ro.product.device", func(val string) { info.Codename = val }},
 		{"ro.product.brand", func(val string) { info.Brand = val }},
 		{"ro.product.name", func(val string) { info.DeviceName = val }},
+		{"ro.build.version.security_patch", func(val string) { info.SecurityPatch = val }},
 	}
 
 	for _, pk := range propKeys {
@@ -218,13 +278,24 @@ func (a *App) GetDeviceInfo() (DeviceInfo, error) {
 
 	wg.Wait()
 
+	if info.Serial != "" && patchLevelPattern.MatchString(info.SecurityPatch) {
+		a.patchMutex.Lock()
+		a.patchLevels[info.Serial] = info.SecurityPatch
+		a.patchMutex.Unlock()
+	}
+
 	return info, nil
 }
 
 func (a *App) detectDeviceMode() (DeviceMode, error) {
+	target := a.GetTargetDevice()
+
 	adbDevices, adbErr := a.GetDevices()
 	if adbErr == nil {
 		for _, device := range adbDevices {
+			if target != "" && device.Serial != target {
+				continue
+			}
 			status := strings.ToLower(strings.TrimSpace(device.Status))
 			switch status {
 			case "device", "recovery", "sideload":
@@ -234,8 +305,12 @@ func (a *App) detectDeviceMode() (DeviceMode, error) {
 	}
 
 	fastbootDevices, fastbootErr := a.GetFastbootDevices()
-	if fastbootErr == nil && len(fastbootDevices) > 0 {
-		return DeviceModeFastboot, nil
+	if fastbootErr == nil {
+		for _, device := range fastbootDevices {
+			if target == "" || device.Serial == target {
+				return DeviceModeFastboot, nil
+			}
+		}
 	}
 
 	if adbErr != nil && fastbootErr != nil {
@@ -0,0 +1,275 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	EventDeviceAttached     = "device:attached"
+	EventDeviceDetached     = "device:detached"
+	EventDeviceStateChanged = "device:state-changed"
+
+	fastbootScanInterval = 2 * time.Second
+	trackReconnectDelay  = 2 * time.Second
+	// An adb server restart drops the track-devices stream briefly. Devices
+	// are only treated as gone once it stays down this long.
+	trackLossGracePeriod = 6 * time.Second
+	// A device that comes back within this window (reboot to bootloader, adb
+	// server restart) is reported as a state change rather than a new attach.
+	deviceReturnWindow = 30 * time.Second
+)
+
+type TrackedDevice struct {
+	Device Device
+	Mode   string
+}
+
+type DeviceEvent struct {
+	Serial         string
+	Mode           string
+	Status         string
+	PreviousMode   string
+	PreviousStatus string
+	Device         Device
+}
+
+type departedDevice struct {
+	device TrackedDevice
+	at     time.Time
+}
+
+// deviceWatcher merges the adb track-devices stream with periodic fastboot
+// scans into one snapshot and emits Wails events for every difference.
+type deviceWatcher struct {
+	app *App
+
+	mu              sync.Mutex
+	serverDevices   []Device
+	adbDevices      []Device
+	fastbootDevices []Device
+	current         map[string]TrackedDevice
+	departed        map[string]departedDevice
+}
+
+func newDeviceWatcher(app *App) *deviceWatcher {
+	return &deviceWatcher{
+		app:      app,
+		current:  make(map[string]TrackedDevice),
+		departed: make(map[string]departedDevice),
+	}
+}
+
+func (w *deviceWatcher) run(ctx context.Context) {
+	go w.trackAdb(ctx)
+	go w.scanFastboot(ctx)
+}
+
+func (w *deviceWatcher) trackAdb(ctx context.Context) {
+	// lostAt starts past the grace period, so without a server from the start
+	// the in-process devices are listed right away.
+	lostAt := time.Now().Add(-trackLossGracePeriod)
+	for ctx.Err() == nil {
+		err := w.streamAdbDevices(ctx, func() { lostAt = time.Time{} })
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The stream ended: the server died or restarted. The first list of the
+		// new stream is reconciled against the old one, so a quick restart
+		// emits nothing; only a stream that stays down clears the server's
+		// devices. In-process connections do not depend on the server.
+		if lostAt.IsZero() {
+			lostAt = time.Now()
+		}
+		if time.Since(lostAt) >= trackLossGracePeriod {
+			w.updateAdb(nil)
+		}
+
+		// Headless mode never starts a server; the app would otherwise bring
+		// one up here and route new wireless connections through it.
+		if errors.Is(err, errAdbServerUnavailable) && !w.app.IsHeadlessAdb() {
+			_, _ = w.app.runCommandContext(ctx, "adb", "start-server")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(trackReconnectDelay):
+		}
+	}
+}
+
+// streamAdbDevices follows host:track-devices-l until it ends, calling
+// connected once the server has accepted the request.
+func (w *deviceWatcher) streamAdbDevices(ctx context.Context, connected func()) error {
+	conn, err := w.app.adb.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	defer watchContext(ctx, conn)()
+
+	if err := writeAdbRequest(conn, "host:track-devices-l"); err != nil {
+		return err
+	}
+	if err := readAdbStatus(conn); err != nil {
+		return err
+	}
+	connected()
+
+	for {
+		payload, err := readAdbString(conn)
+		if err != nil {
+			return err
+		}
+		w.updateAdb(parseDevicesLong(payload))
+	}
+}
+
+func (w *deviceWatcher) scanFastboot(ctx context.Context) {
+	ticker := time.NewTicker(fastbootScanInterval)
+	defer ticker.Stop()
+
+	for {
+		if devices, err := w.app.GetFastbootDevices(); err == nil {
+			w.updateFastboot(devices)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateAdb takes the adb server's device list; the in-process connections
+// are added to it.
+func (w *deviceWatcher) updateAdb(serverDevices []Device) {
+	w.mu.Lock()
+	w.serverDevices = serverDevices
+	w.adbDevices = append(append([]Device(nil), serverDevices...), w.app.adb.directDevices()...)
+	events := w.reconcileLocked()
+	w.mu.Unlock()
+	w.emit(events)
+}
+
+// refreshDirect relists the in-process connections after one was made or
+// dropped, which the server's stream does not report.
+func (w *deviceWatcher) refreshDirect() {
+	w.mu.Lock()
+	w.adbDevices = append(append([]Device(nil), w.serverDevices...), w.app.adb.directDevices()...)
+	events := w.reconcileLocked()
+	w.mu.Unlock()
+	w.emit(events)
+}
+
+func (w *deviceWatcher) updateFastboot(devices []Device) {
+	w.mu.Lock()
+	w.fastbootDevices = devices
+	events := w.reconcileLocked()
+	w.mu.Unlock()
+	w.emit(events)
+}
+
+type namedDeviceEvent struct {
+	name  string
+	event DeviceEvent
+}
+
+func (w *deviceWatcher) reconcileLocked() []namedDeviceEvent {
+	next := make(map[string]TrackedDevice)
+	for _, device := range w.fastbootDevices {
+		next[device.Serial] = TrackedDevice{Device: device, Mode: string(DeviceModeFastboot)}
+	}
+	for _, device := range w.adbDevices {
+		next[device.Serial] = TrackedDevice{Device: device, Mode: string(DeviceModeADB)}
+	}
+
+	now := time.Now()
+	var events []namedDeviceEvent
+
+	for serial, previous := range w.current {
+		if _, ok := next[serial]; !ok {
+			w.departed[serial] = departedDevice{device: previous, at: now}
+			events = append(events, namedDeviceEvent{EventDeviceDetached, newDeviceEvent(previous, previous)})
+		}
+	}
+
+	for serial, device := range next {
+		previous, known := w.current[serial]
+		if !known {
+			if departed, ok := w.departed[serial]; ok && now.Sub(departed.at) < deviceReturnWindow {
+				previous, known = departed.device, true
+			}
+			delete(w.departed, serial)
+
+			if !known || (previous.Mode == device.Mode && previous.Device.Status == device.Device.Status) {
+				events = append(events, namedDeviceEvent{EventDeviceAttached, newDeviceEvent(device, device)})
+				continue
+			}
+		}
+
+		if previous.Mode != device.Mode || previous.Device.Status != device.Device.Status {
+			events = append(events, namedDeviceEvent{EventDeviceStateChanged, newDeviceEvent(device, previous)})
+		}
+	}
+
+	for serial, departed := range w.departed {
+		if now.Sub(departed.at) >= deviceReturnWindow {
+			delete(w.departed, serial)
+		}
+	}
+
+	w.current = next
+	return events
+}
+
+func newDeviceEvent(device TrackedDevice, previous TrackedDevice) DeviceEvent {
+	return DeviceEvent{
+		Serial:         device.Device.Serial,
+		Mode:           device.Mode,
+		Status:         device.Device.Status,
+		PreviousMode:   previous.Mode,
+		PreviousStatus: previous.Device.Status,
+		Device:         device.Device,
+	}
+}
+
+func (w *deviceWatcher) emit(events []namedDeviceEvent) {
+	if w.app.ctx == nil {
+		return
+	}
+	for _, e := range events {
+		runtime.EventsEmit(w.app.ctx, e.name, e.event)
+	}
+}
+
+func (w *deviceWatcher) snapshot() []TrackedDevice {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	devices := make([]TrackedDevice, 0, len(w.current))
+	for _, device := range w.current {
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Device.Serial < devices[j].Device.Serial
+	})
+	return devices
+}
+
+// GetDeviceSnapshot returns the watcher's current view of adb and fastboot
+// devices, so views mounted after an event still start from a consistent list.
+func (a *App) GetDeviceSnapshot() []TrackedDevice {
+	if a.watcher == nil {
+		return []TrackedDevice{}
+	}
+	return a.watcher.snapshot()
+}
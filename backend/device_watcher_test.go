@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// eventSummary lists events as "name serial mode" so map order does not matter.
+func eventSummary(events []namedDeviceEvent) []string {
+	summary := []string{}
+	for _, e := range events {
+		summary = append(summary, e.name+" "+e.event.Serial+" "+e.event.Mode)
+	}
+	sort.Strings(summary)
+	return summary
+}
+
+func TestDeviceWatcherReconcile(t *testing.T) {
+	phone := Device{Serial: "A1", Status: "device"}
+	tablet := Device{Serial: "B2", Status: "device"}
+
+	steps := []struct {
+		name     string
+		adb      []Device
+		fastboot []Device
+		want     []string
+	}{
+		{"attach", []Device{phone, tablet}, nil, []string{"device:attached A1 adb", "device:attached B2 adb"}},
+		{"unchanged", []Device{phone, tablet}, nil, []string{}},
+		{"detach", []Device{phone}, nil, []string{"device:detached B2 adb"}},
+		{"reboot to fastboot", nil, []Device{{Serial: "A1", Status: "fastboot"}}, []string{"device:state-changed A1 fastboot"}},
+		{"still in fastboot", nil, []Device{{Serial: "A1", Status: "fastboot"}}, []string{}},
+		{"unauthorized", []Device{{Serial: "A1", Status: "unauthorized"}}, nil, []string{"device:state-changed A1 adb"}},
+		{"reconnect in same state", []Device{{Serial: "A1", Status: "unauthorized"}, tablet}, nil, []string{"device:attached B2 adb"}},
+	}
+
+	w := newDeviceWatcher(&App{})
+	for _, step := range steps {
+		w.adbDevices, w.fastbootDevices = step.adb, step.fastboot
+		if got := eventSummary(w.reconcileLocked()); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: events = %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestDeviceWatcherReturnWindow(t *testing.T) {
+	w := newDeviceWatcher(&App{})
+	w.adbDevices = []Device{{Serial: "A1", Status: "device"}}
+	w.reconcileLocked()
+
+	w.adbDevices = nil
+	w.reconcileLocked()
+	// A device that comes back in another mode after the window is a new
+	// attachment, not a state change.
+	w.departed["A1"] = departedDevice{device: w.departed["A1"].device, at: time.Now().Add(-deviceReturnWindow)}
+
+	w.fastbootDevices = []Device{{Serial: "A1", Status: "fastboot"}}
+	want := []string{"device:attached A1 fastboot"}
+	if got := eventSummary(w.reconcileLocked()); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if len(w.departed) != 0 {
+		t.Errorf("departed = %v, want empty", w.departed)
+	}
+}
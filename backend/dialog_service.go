@@ -153,3 +153,29 @@ func (a *App) SelectSaveFile(defaultFilename string) (string, error) {
 
 	return filePath, nil
 }
+
+func (a *App) SelectFactoryImageFolder() (string, error) {
+	selectedPath, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Extracted Factory Image",
+	})
+	if err != nil {
+		return "", err
+	}
+	return selectedPath, nil
+}
+
+func (a *App) SelectOtaPackage() (string, error) {
+	selectedPath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select OTA Package",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "OTA Packages (*.zip, payload.bin)",
+				Pattern:     "*.zip;*.bin",
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return selectedPath, nil
+}
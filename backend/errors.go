@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type ErrorCode string
+
+const (
+	CodeCommandFailed    ErrorCode = "COMMAND_FAILED"
+	CodeDeviceOffline    ErrorCode = "DEVICE_OFFLINE"
+	CodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	CodeNoDevice         ErrorCode = "NO_DEVICE"
+	CodeDeviceNotFound   ErrorCode = "DEVICE_NOT_FOUND"
+	CodeMultipleDevices  ErrorCode = "MULTIPLE_DEVICES"
+	CodeTimeout          ErrorCode = "TIMEOUT"
+	CodeCancelled        ErrorCode = "CANCELLED"
+	CodeBinaryMissing    ErrorCode = "BINARY_MISSING"
+	CodePermissionDenied ErrorCode = "PERMISSION_DENIED"
+	CodePackageNotFound  ErrorCode = "PACKAGE_NOT_FOUND"
+	CodeFileNotFound     ErrorCode = "FILE_NOT_FOUND"
+	CodeStorageFull      ErrorCode = "STORAGE_FULL"
+	CodeNotFastbootd     ErrorCode = "NOT_FASTBOOTD"
+)
+
+// CommandError is the structured error every adb/fastboot failure is reported
+// as. It reaches the frontend as an object (see FormatError) so the UI can
+// switch on Code instead of matching message text.
+type CommandError struct {
+	Code     ErrorCode
+	Message  string
+	Command  string
+	ExitCode int
+	Stderr   string
+}
+
+func (e *CommandError) Error() string {
+	return e.Message
+}
+
+// Is matches by code, so errors.Is(err, ErrDeviceOffline) works on any
+// CommandError regardless of its command or output.
+func (e *CommandError) Is(target error) bool {
+	t, ok := target.(*CommandError)
+	return ok && t.Code == e.Code
+}
+
+var (
+	ErrCommandFailed    = &CommandError{Code: CodeCommandFailed, Message: "command failed"}
+	ErrDeviceOffline    = &CommandError{Code: CodeDeviceOffline, Message: "device is offline"}
+	ErrUnauthorized     = &CommandError{Code: CodeUnauthorized, Message: "device unauthorized"}
+	ErrNoDevice         = &CommandError{Code: CodeNoDevice, Message: "no device connected"}
+	ErrDeviceNotFound   = &CommandError{Code: CodeDeviceNotFound, Message: "device not found"}
+	ErrMultipleDevices  = &CommandError{Code: CodeMultipleDevices, Message: "more than one device connected"}
+	ErrTimeout          = &CommandError{Code: CodeTimeout, Message: "command timed out"}
+	ErrCancelled        = &CommandError{Code: CodeCancelled, Message: "command cancelled by user"}
+	ErrBinaryMissing    = &CommandError{Code: CodeBinaryMissing, Message: "binary not found"}
+	ErrPermissionDenied = &CommandError{Code: CodePermissionDenied, Message: "permission denied"}
+	ErrPackageNotFound  = &CommandError{Code: CodePackageNotFound, Message: "package not found"}
+	ErrFileNotFound     = &CommandError{Code: CodeFileNotFound, Message: "file not found"}
+	ErrStorageFull      = &CommandError{Code: CodeStorageFull, Message: "not enough storage"}
+	ErrNotFastbootd     = &CommandError{Code: CodeNotFastbootd, Message: "device is in bootloader fastboot; reboot to fastbootd for logical partitions"}
+)
+
+// newCommandError classifies raw adb/fastboot output. serial is the targeted
+// device, if any, and is used to recognise "device '<serial>' not found".
+func newCommandError(serial string, command string, exitCode int, errOutput string) *CommandError {
+	e := &CommandError{
+		Code:     CodeCommandFailed,
+		Message:  errOutput,
+		Command:  command,
+		ExitCode: exitCode,
+		Stderr:   errOutput,
+	}
+
+	lower := strings.ToLower(errOutput)
+	switch {
+	case serial != "" && (strings.Contains(errOutput, "device '"+serial+"' not found") || strings.Contains(lower, "device not found")):
+		e.Code = CodeDeviceNotFound
+		e.Message = fmt.Sprintf("device %s is no longer connected. Select another device", serial)
+	case strings.Contains(lower, "more than one device"):
+		e.Code = CodeMultipleDevices
+		e.Message = "more than one device connected. Select a target device first"
+	case strings.Contains(lower, "no devices/emulators found") || strings.Contains(lower, "no devices found"):
+		e.Code = CodeNoDevice
+		e.Message = "no device connected. Check the USB cable and USB debugging"
+	case strings.Contains(lower, "device offline"):
+		e.Code = CodeDeviceOffline
+		e.Message = "device is offline. Try reconnecting USB"
+	case strings.Contains(lower, "unauthorized"):
+		e.Code = CodeUnauthorized
+		e.Message = "unauthorized. Check phone screen to allow USB debugging"
+	case strings.Contains(lower, "insufficient_storage") || strings.Contains(lower, "no space left"):
+		e.Code = CodeStorageFull
+	case strings.Contains(lower, "unknown package") || strings.Contains(lower, "not installed for") || strings.Contains(lower, "package not found"):
+		e.Code = CodePackageNotFound
+	case strings.Contains(lower, "permission denied") || strings.Contains(lower, "not allowed") || strings.Contains(lower, "securityexception"):
+		e.Code = CodePermissionDenied
+	case strings.Contains(lower, "no such file or directory") || strings.Contains(lower, "does not exist"):
+		e.Code = CodeFileNotFound
+	}
+	return e
+}
+
+func newTimeoutError(command string, message string) *CommandError {
+	return &CommandError{Code: CodeTimeout, Message: message, Command: command, ExitCode: -1}
+}
+
+func newCancelledError(command string, message string) *CommandError {
+	return &CommandError{Code: CodeCancelled, Message: message, Command: command, ExitCode: -1}
+}
+
+// FormatError is the Wails error formatter: errors carrying a CommandError are
+// sent to the frontend as an object, everything else stays a plain string.
+func FormatError(err error) any {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		formatted := *cmdErr
+		formatted.Message = err.Error()
+		return formatted
+	}
+	return err.Error()
+}
@@ -3,7 +3,9 @@ package backend
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,6 +16,74 @@ import (
 
 const DefaultCommandTimeout = 60 * time.Second
 
+// adbHostCommands are handled by the adb server itself and must not be scoped with -s.
+var adbHostCommands = map[string]bool{
+	"devices":      true,
+	"connect":      true,
+	"disconnect":   true,
+	"pair":         true,
+	"mdns":         true,
+	"version":      true,
+	"--version":    true,
+	"start-server": true,
+	"kill-server":  true,
+}
+
+// targetArgs prefixes adb/fastboot arguments with -s <serial> when a target device is selected.
+func (a *App) targetArgs(name string, args []string) (string, []string) {
+	serial := a.GetTargetDevice()
+	if serial == "" || len(args) == 0 || args[0] == "-s" {
+		return "", args
+	}
+
+	switch name {
+	case "adb":
+		if adbHostCommands[args[0]] {
+			return "", args
+		}
+	case "fastboot":
+		if args[0] == "devices" || args[0] == "--version" {
+			return "", args
+		}
+	default:
+		return "", args
+	}
+
+	return serial, append([]string{"-s", serial}, args...)
+}
+
+// serialArgs prefixes args with -s serial for a serial a job resolved itself.
+func serialArgs(serial string, args ...string) []string {
+	if serial == "" {
+		return args
+	}
+	return append([]string{"-s", serial}, args...)
+}
+
+// ensureFastbootTarget fails fast when the selected serial is not in bootloader,
+// since fastboot -s waits forever for a device that never shows up.
+func (a *App) ensureFastbootTarget(serial string) error {
+	if strings.HasPrefix(serial, "tcp:") || strings.HasPrefix(serial, "udp:") {
+		return nil
+	}
+
+	devices, err := a.GetFastbootDevices()
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if device.Serial == serial {
+			return nil
+		}
+	}
+	return &CommandError{
+		Code:     CodeDeviceNotFound,
+		Message:  fmt.Sprintf("device %s is not connected in fastboot mode", serial),
+		Command:  "fastboot -s " + serial,
+		ExitCode: -1,
+	}
+}
+
 func (a *App) getBinaryPath(name string) (string, error) {
 	a.cacheMutex.RLock()
 	if cached, ok := a.binaryCache[name]; ok {
@@ -67,15 +137,101 @@ func (a *App) getBinaryPath(name string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("binary '%s' not found. Please ensure 'bin/%s/%s%s' exists", name, platformDir, name, extension)
+	return "", &CommandError{
+		Code:     CodeBinaryMissing,
+		Message:  fmt.Sprintf("binary '%s' not found. Please ensure 'bin/%s/%s%s' exists", name, platformDir, name, extension),
+		Command:  name,
+		ExitCode: -1,
+	}
+}
+
+// exitCodeOf returns the process exit code, or -1 when the command never ran.
+func exitCodeOf(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+type commandTimeoutKey struct{}
+
+// withCommandTimeout is context.WithTimeout that remembers the timeout, so
+// contextError can report it. A parent deadline that comes first keeps its own.
+func withCommandTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if deadline, ok := parent.Deadline(); !ok || time.Until(deadline) > timeout {
+		parent = context.WithValue(parent, commandTimeoutKey{}, timeout)
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// contextError reports a timeout or cancellation of ctx as a CommandError.
+func contextError(ctx context.Context, command string) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		if timeout, ok := ctx.Value(commandTimeoutKey{}).(time.Duration); ok {
+			return newTimeoutError(command, fmt.Sprintf("command timed out after %s", timeout))
+		}
+		return newTimeoutError(command, "command timed out")
+	case context.Canceled:
+		return newCancelledError(command, "command cancelled by user")
+	}
+	return nil
+}
+
+// runNativeShell runs a device shell command on serial through the adb server protocol.
+// It returns errAdbServerUnavailable when the caller should fall back to the adb binary.
+func (a *App) runNativeShell(ctx context.Context, serial string, command string) (string, error) {
+	commandLine := "adb shell " + command
+
+	result, err := a.adb.Shell(ctx, serial, command)
+	if err != nil {
+		if errors.Is(err, errAdbServerUnavailable) {
+			return "", err
+		}
+		if ctxErr := contextError(ctx, commandLine); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", newCommandError(serial, commandLine, -1, err.Error())
+	}
+
+	if result.ExitCode != 0 {
+		errOutput := strings.TrimSpace(result.Stderr)
+		if errOutput == "" {
+			errOutput = strings.TrimSpace(result.Stdout)
+		}
+		if errOutput == "" {
+			errOutput = fmt.Sprintf("exit status %d", result.ExitCode)
+		}
+		return "", newCommandError(serial, commandLine, result.ExitCode, errOutput)
+	}
+
+	return strings.TrimSpace(result.Stdout), nil
 }
 
 func (a *App) runCommandContext(ctx context.Context, name string, args ...string) (string, error) {
+	if name == "adb" && len(args) > 1 && args[0] == "shell" {
+		output, err := a.runNativeShell(ctx, a.GetTargetDevice(), strings.Join(args[1:], " "))
+		if !errors.Is(err, errAdbServerUnavailable) {
+			return output, err
+		}
+	}
+	if serial, fastbootArgs, ok := a.fastbootTCPTarget(name, args); ok {
+		return a.runFastbootTCP(ctx, serial, fastbootArgs, nil)
+	}
+
 	binaryPath, err := a.getBinaryPath(name)
 	if err != nil {
 		return "", err
 	}
 
+	serial, args := a.targetArgs(name, args)
+	if serial != "" && name == "fastboot" {
+		if err := a.ensureFastbootTarget(serial); err != nil {
+			return "", err
+		}
+	}
+
 	cmd := exec.CommandContext(ctx, binaryPath, args...)
 	setCommandWindowMode(cmd)
 
@@ -86,39 +242,136 @@ func (a *App) runCommandContext(ctx context.Context, name string, args ...string
 
 	err = cmd.Run()
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("command timed out after %s", DefaultCommandTimeout)
-		}
-		if ctx.Err() == context.Canceled {
-			return "", fmt.Errorf("command cancelled by user")
+		commandLine := name + " " + strings.Join(args, " ")
+		if ctxErr := contextError(ctx, commandLine); ctxErr != nil {
+			return "", ctxErr
 		}
 
 		errOutput := strings.TrimSpace(stderr.String())
 		if errOutput == "" {
 			errOutput = err.Error()
 		}
-		
-		if strings.Contains(errOutput, "device offline") {
-			return "", fmt.Errorf("device is offline. Try reconnecting USB")
+
+		return "", newCommandError(serial, commandLine, exitCodeOf(err), errOutput)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// runFastboot runs fastboot for serial (the target device when empty) and
+// returns stdout and stderr together, since fastboot prints getvar values and
+// progress to stderr.
+func (a *App) runFastboot(ctx context.Context, serial string, args ...string) (string, error) {
+	return a.runFastbootStream(ctx, serial, nil, args...)
+}
+
+// runFastbootStream is runFastboot that also hands the output to onOutput as
+// it arrives, when onOutput is set.
+func (a *App) runFastbootStream(ctx context.Context, serial string, onOutput func([]byte), args ...string) (string, error) {
+	if serial == "" {
+		serial = a.GetTargetDevice()
+	}
+	if isFastbootTCPSerial(serial) {
+		return a.runFastbootTCP(ctx, serial, args, onOutput)
+	}
+
+	binaryPath, err := a.getBinaryPath("fastboot")
+	if err != nil {
+		return "", err
+	}
+
+	if serial != "" {
+		if err := a.ensureFastbootTarget(serial); err != nil {
+			return "", err
+		}
+		args = append([]string{"-s", serial}, args...)
+	}
+
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	setCommandWindowMode(cmd)
+
+	out := &streamWriter{onOutput: onOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := cmd.Run(); err != nil {
+		commandLine := "fastboot " + strings.Join(args, " ")
+		if ctxErr := contextError(ctx, commandLine); ctxErr != nil {
+			return "", ctxErr
+		}
+		errOutput := strings.TrimSpace(out.buf.String())
+		if errOutput == "" {
+			errOutput = err.Error()
 		}
-		if strings.Contains(errOutput, "unauthorized") {
-			return "", fmt.Errorf("unauthorized. Check phone screen to allow USB debugging")
+		return "", newCommandError(serial, commandLine, exitCodeOf(err), errOutput)
+	}
+
+	return strings.TrimSpace(out.buf.String()), nil
+}
+
+// runCommandStream runs an adb or fastboot command like runCommandContext and
+// also hands its combined output to onOutput as it arrives, for commands that
+// report progress while they run.
+func (a *App) runCommandStream(ctx context.Context, onOutput func([]byte), name string, args ...string) (string, error) {
+	if serial, fastbootArgs, ok := a.fastbootTCPTarget(name, args); ok {
+		return a.runFastbootTCP(ctx, serial, fastbootArgs, onOutput)
+	}
+
+	binaryPath, err := a.getBinaryPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	serial, args := a.targetArgs(name, args)
+	if serial != "" && name == "fastboot" {
+		if err := a.ensureFastbootTarget(serial); err != nil {
+			return "", err
 		}
+	}
 
-		return "", fmt.Errorf("%s", errOutput)
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	setCommandWindowMode(cmd)
+
+	// One writer for both streams, so exec serializes the writes.
+	out := &streamWriter{onOutput: onOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := cmd.Run(); err != nil {
+		commandLine := name + " " + strings.Join(args, " ")
+		if ctxErr := contextError(ctx, commandLine); ctxErr != nil {
+			return "", ctxErr
+		}
+		errOutput := strings.TrimSpace(out.buf.String())
+		if errOutput == "" {
+			errOutput = err.Error()
+		}
+		return "", newCommandError(serial, commandLine, exitCodeOf(err), errOutput)
 	}
+	return strings.TrimSpace(out.buf.String()), nil
+}
 
-	return strings.TrimSpace(out.String()), nil
+type streamWriter struct {
+	buf      bytes.Buffer
+	onOutput func([]byte)
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	if w.onOutput != nil {
+		w.onOutput(p)
+	}
+	return len(p), nil
 }
 
 func (a *App) runCommand(name string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
 	defer cancel()
 	return a.runCommandContext(ctx, name, args...)
 }
 
 func (a *App) runCommandWithTimeout(timeout time.Duration, name string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := withCommandTimeout(context.Background(), timeout)
 	defer cancel()
 	return a.runCommandContext(ctx, name, args...)
 }
@@ -128,11 +381,30 @@ func (a *App) runCommandRaw(name string, args ...string) (string, error) {
 	return a.runCommandContext(context.Background(), name, args...)
 }
 
+// runShellCommand runs shellCommand verbatim in the device shell. Commands built
+// from user-supplied paths or names must go through shellJoin so every argument
+// stays a single word; pipes and redirects are then only possible where the
+// caller wrote them on purpose.
 func (a *App) runShellCommand(shellCommand string) (string, error) {
-	// Security: simplistic check to prevent completely reckless command injection
-	// Ideally, shell commands should be avoided in favor of direct args, but adb shell requires it often.
-	if strings.ContainsAny(shellCommand, "&|;") {
-		return "", fmt.Errorf("illegal characters in command")
+	return a.runShellCommandOn(a.GetTargetDevice(), shellCommand)
+}
+
+// runShellCommandOn is runShellCommand for a serial resolved by the caller,
+// so every step of a job runs on the same device.
+func (a *App) runShellCommandOn(serial string, shellCommand string) (string, error) {
+	// Shell commands default to 60s timeout too
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+
+	output, err := a.runNativeShell(ctx, serial, shellCommand)
+	if err == nil {
+		return output, nil
+	}
+	if !errors.Is(err, errAdbServerUnavailable) {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("shell command timed out: %w", err)
+		}
+		return "", fmt.Errorf("shell error: %w", err)
 	}
 
 	binaryPath, err := a.getBinaryPath("adb")
@@ -140,11 +412,8 @@ func (a *App) runShellCommand(shellCommand string) (string, error) {
 		return "", err
 	}
 
-	// Shell commands default to 60s timeout too
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultCommandTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, binaryPath, "shell", shellCommand)
+	args := serialArgs(serial, "shell", shellCommand)
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
 	setCommandWindowMode(cmd)
 
 	var out bytes.Buffer
@@ -154,19 +423,99 @@ func (a *App) runShellCommand(shellCommand string) (string, error) {
 
 	err = cmd.Run()
 	if err != nil {
+		commandLine := "adb " + strings.Join(args, " ")
 		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("shell command timed out")
+			return "", fmt.Errorf("shell command timed out: %w", contextError(ctx, commandLine))
 		}
 		errOutput := strings.TrimSpace(stderr.String())
 		if errOutput == "" {
 			errOutput = err.Error()
 		}
-		return "", fmt.Errorf("shell error: %s", errOutput)
+		cmdErr := newCommandError(serial, commandLine, exitCodeOf(err), errOutput)
+		if cmdErr.Code == CodeDeviceNotFound || cmdErr.Code == CodeMultipleDevices {
+			return "", cmdErr
+		}
+		return "", fmt.Errorf("shell error: %w", cmdErr)
 	}
 
 	return strings.TrimSpace(out.String()), nil
 }
 
+// streamShellCommand runs a long-lived device shell command, copying stdout to
+// w as it arrives. It ends when the command exits or ctx is cancelled.
+func (a *App) streamShellCommand(ctx context.Context, serial string, shellCommand string, w io.Writer) error {
+	commandLine := "adb shell " + shellCommand
+
+	var stderr bytes.Buffer
+	exitCode, err := a.adb.ShellStream(ctx, serial, shellCommand, w, &stderr)
+	if errors.Is(err, errAdbServerUnavailable) {
+		binaryPath, pathErr := a.getBinaryPath("adb")
+		if pathErr != nil {
+			return pathErr
+		}
+
+		cmd := exec.CommandContext(ctx, binaryPath, serialArgs(serial, "shell", shellCommand)...)
+		setCommandWindowMode(cmd)
+		cmd.Stdout = w
+		cmd.Stderr = &stderr
+
+		err = cmd.Run()
+		exitCode = exitCodeOf(err)
+		if err != nil && exitCode > 0 {
+			err = nil
+		}
+	}
+
+	if ctxErr := contextError(ctx, commandLine); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return newCommandError(serial, commandLine, -1, err.Error())
+	}
+	if exitCode != 0 {
+		errOutput := strings.TrimSpace(stderr.String())
+		if errOutput == "" {
+			errOutput = fmt.Sprintf("exit status %d", exitCode)
+		}
+		return newCommandError(serial, commandLine, exitCode, errOutput)
+	}
+	return nil
+}
+
+// execOut runs a device command like `adb exec-out`, copying its raw output to w.
+func (a *App) execOut(ctx context.Context, command string, w io.Writer) error {
+	serial := a.GetTargetDevice()
+	commandLine := "adb exec-out " + command
+
+	err := a.adb.Exec(ctx, serial, command, w)
+	if errors.Is(err, errAdbServerUnavailable) {
+		binaryPath, pathErr := a.getBinaryPath("adb")
+		if pathErr != nil {
+			return pathErr
+		}
+
+		var args []string
+		var stderr bytes.Buffer
+		serial, args = a.targetArgs("adb", []string{"exec-out", command})
+		cmd := exec.CommandContext(ctx, binaryPath, args...)
+		setCommandWindowMode(cmd)
+		cmd.Stdout = w
+		cmd.Stderr = &stderr
+
+		if err = cmd.Run(); err != nil && stderr.Len() > 0 {
+			err = errors.New(strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	if ctxErr := contextError(ctx, commandLine); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return newCommandError(serial, commandLine, -1, err.Error())
+	}
+	return nil
+}
+
 func (a *App) CheckSystemRequirements() (string, error) {
 	adbPath, err := a.getBinaryPath("adb")
 	if err != nil {
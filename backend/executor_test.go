@@ -0,0 +1,33 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextErrorReportsTimeout(t *testing.T) {
+	outer, cancelOuter := withCommandTimeout(context.Background(), time.Millisecond)
+	defer cancelOuter()
+	// The inner timeout is longer, so the outer one is the one that fires.
+	inner, cancelInner := withCommandTimeout(outer, time.Hour)
+	defer cancelInner()
+	<-inner.Done()
+
+	err := contextError(inner, "adb shell sleep 10")
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != CodeTimeout {
+		t.Fatalf("contextError = %v, want a timeout", err)
+	}
+	if !strings.Contains(cmdErr.Message, "after 1ms") {
+		t.Errorf("message = %q, want the 1ms timeout", cmdErr.Message)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := contextError(cancelled, "adb shell sleep 10"); !errors.As(err, &cmdErr) || cmdErr.Code != CodeCancelled {
+		t.Errorf("contextError = %v, want a cancellation", err)
+	}
+}
@@ -0,0 +1,664 @@
+package backend
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	EventFactoryFlashProgress = "factoryflash:progress"
+
+	// How long a device gets to come back after reboot-bootloader.
+	fastbootRebootTimeout = 2 * time.Minute
+)
+
+const (
+	FactoryFlashRunning   = "running"
+	FactoryFlashDone      = "done"
+	FactoryFlashFailed    = "failed"
+	FactoryFlashCancelled = "cancelled"
+)
+
+// factoryRequirementVars maps android-info.txt requirements to the getvar
+// that has to satisfy them. Other requirements are left to `fastboot update`.
+var factoryRequirementVars = map[string]string{
+	"board":              "product",
+	"version-bootloader": "version-bootloader",
+	"version-baseband":   "version-baseband",
+}
+
+type FactoryRequirement struct {
+	Name   string
+	Values []string
+	// Product is set for require-for-product lines.
+	Product string
+}
+
+type FactoryImage struct {
+	Path         string
+	Bootloader   string
+	Radio        string
+	Image        string
+	Requirements []FactoryRequirement
+}
+
+type FactoryRequirementCheck struct {
+	Name        string
+	Expected    string
+	DeviceValue string
+	Satisfied   bool
+	// Flashed is true when the factory image brings its own bootloader or
+	// radio, so a mismatch now is expected and rechecked after flashing it.
+	Flashed bool
+}
+
+type FactoryFlashOptions struct {
+	Path   string
+	Serial string
+	Wipe   bool
+}
+
+type FactoryFlashProgress struct {
+	JobID   string
+	Steps   []string
+	Step    int
+	State   string
+	Message string
+}
+
+// factoryImage locates the pieces of a Pixel-style factory image, either a
+// factory zip or the folder it extracts to.
+type factoryImage struct {
+	FactoryImage
+	zip *zip.ReadCloser
+	// entries and paths map the Bootloader, Radio and Image names to zip
+	// entries or on-disk files.
+	entries map[string]*zip.File
+	paths   map[string]string
+	// androidInfo is a loose android-info.txt next to the images, if any.
+	androidInfo string
+}
+
+// InspectFactoryImage lists the images and android-info.txt requirements of
+// a factory zip or extracted folder.
+func (a *App) InspectFactoryImage(imagePath string) (FactoryImage, error) {
+	image, err := openFactoryImage(imagePath)
+	if err != nil {
+		return FactoryImage{}, err
+	}
+	defer image.Close()
+	return image.FactoryImage, nil
+}
+
+// CheckFactoryImage compares the factory image requirements with the device
+// in bootloader. An empty serial uses the target device.
+func (a *App) CheckFactoryImage(imagePath string, serial string) ([]FactoryRequirementCheck, error) {
+	image, err := openFactoryImage(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer image.Close()
+
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+
+	output, err := a.runFastboot(ctx, serial, "getvar", "all")
+	if err != nil {
+		return nil, err
+	}
+	vars := parseGetvarAll(output)
+
+	checks := []FactoryRequirementCheck{}
+	for _, name := range []string{"board", "version-bootloader", "version-baseband"} {
+		check, ok := checkFactoryRequirement(image.Requirements, name, vars)
+		if !ok {
+			continue
+		}
+		check.Flashed = (name == "version-bootloader" && image.Bootloader != "") || (name == "version-baseband" && image.Radio != "")
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// StartFactoryFlash runs the flash-all sequence as a job and returns its id:
+// bootloader, reboot-bootloader, radio, reboot-bootloader, then update. Each
+// android-info.txt requirement is checked before the step that depends on it
+// and the job aborts on the first mismatch.
+func (a *App) StartFactoryFlash(opts FactoryFlashOptions) (string, error) {
+	image, err := openFactoryImage(opts.Path)
+	if err != nil {
+		return "", err
+	}
+	if image.Image == "" {
+		image.Close()
+		return "", fmt.Errorf("no image-*.zip found in %s", opts.Path)
+	}
+
+	serial, err := a.resolveFastbootSerial(opts.Serial)
+	if err != nil {
+		image.Close()
+		return "", err
+	}
+
+	job := a.jobs.start(nil, "flash-all", "Flash factory image "+filepath.Base(opts.Path), 0)
+	go func() {
+		defer image.Close()
+		result, err := a.flashFactoryImage(job, image, serial, opts.Wipe)
+		job.finish(result, err)
+	}()
+	return job.id, nil
+}
+
+func (a *App) flashFactoryImage(job *jobHandle, image *factoryImage, serial string, wipe bool) (string, error) {
+	type step struct {
+		name string
+		run  func(ctx context.Context) error
+	}
+
+	tempDir, err := os.MkdirTemp("", "adbkit-factory-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tempDir)
+
+	check := func(requirement string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			return a.requireFactoryVar(ctx, serial, image.Requirements, requirement)
+		}
+	}
+	flash := func(partition string, name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			file, err := image.extract(name, tempDir)
+			if err != nil {
+				return err
+			}
+			if _, err := a.runFastboot(ctx, serial, "flash", partition, file); err != nil {
+				return fmt.Errorf("failed to flash %s: %w", partition, err)
+			}
+			return nil
+		}
+	}
+	reboot := func(ctx context.Context) error {
+		return a.rebootBootloaderAndWait(ctx, serial)
+	}
+
+	steps := []step{{"Check device", func(ctx context.Context) error {
+		if err := check("board")(ctx); err != nil {
+			return err
+		}
+		unlocked, err := a.fastbootGetvar(ctx, serial, "unlocked")
+		if err != nil && !isUnknownVariable(err) {
+			return fmt.Errorf("failed to read the lock state: %w", err)
+		}
+		if unlocked == "no" {
+			return fmt.Errorf("the bootloader is locked; unlock it before flashing a factory image")
+		}
+		return nil
+	}}}
+	if image.Bootloader != "" {
+		steps = append(steps,
+			step{"Flash bootloader", flash("bootloader", image.Bootloader)},
+			step{"Reboot to bootloader", reboot},
+		)
+	}
+	steps = append(steps, step{"Check bootloader version", check("version-bootloader")})
+	if image.Radio != "" {
+		steps = append(steps,
+			step{"Flash radio", flash("radio", image.Radio)},
+			step{"Reboot to bootloader", reboot},
+		)
+	}
+	steps = append(steps, step{"Check baseband version", check("version-baseband")})
+
+	updateName := "Update system images"
+	if wipe {
+		updateName += " and wipe data"
+	}
+	steps = append(steps, step{updateName, func(ctx context.Context) error {
+		file, err := image.extract(image.Image, tempDir)
+		if err != nil {
+			return err
+		}
+		args := []string{"update", file}
+		if wipe {
+			args = append([]string{"-w"}, args...)
+		}
+		if _, err := a.runFastboot(ctx, serial, args...); err != nil {
+			return fmt.Errorf("fastboot update failed: %w", err)
+		}
+		return nil
+	}})
+
+	progress := FactoryFlashProgress{JobID: job.id}
+	for _, s := range steps {
+		progress.Steps = append(progress.Steps, s.name)
+	}
+
+	for i, s := range steps {
+		progress.Step = i
+		progress.State = FactoryFlashRunning
+		progress.Message = s.name
+		a.emitFactoryFlashProgress(progress)
+		job.setProgress(float64(i) / float64(len(steps)) * 100)
+
+		if err := s.run(job.ctx); err != nil {
+			progress.State = FactoryFlashFailed
+			if job.ctx.Err() != nil {
+				progress.State = FactoryFlashCancelled
+			}
+			progress.Message = err.Error()
+			a.emitFactoryFlashProgress(progress)
+			return "", err
+		}
+	}
+
+	progress.Step = len(steps)
+	progress.State = FactoryFlashDone
+	progress.Message = "Factory image flashed"
+	a.emitFactoryFlashProgress(progress)
+	return fmt.Sprintf("Flashed %s to %s", filepath.Base(image.Path), serial), nil
+}
+
+// requireFactoryVar aborts when the device does not satisfy the named
+// android-info.txt requirement.
+func (a *App) requireFactoryVar(ctx context.Context, serial string, requirements []FactoryRequirement, name string) error {
+	if _, ok := checkFactoryRequirement(requirements, name, nil); !ok {
+		return nil
+	}
+
+	variable := factoryRequirementVars[name]
+	value, err := a.fastbootGetvar(ctx, serial, variable)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", variable, err)
+	}
+
+	vars := map[string]string{variable: value}
+	if variable != "product" {
+		// require-for-product lines only apply to their product.
+		vars["product"], _ = a.fastbootGetvar(ctx, serial, "product")
+	}
+
+	check, ok := checkFactoryRequirement(requirements, name, vars)
+	if ok && !check.Satisfied {
+		return fmt.Errorf("factory image requires %s %s but the device reports %q", name, check.Expected, value)
+	}
+	return nil
+}
+
+// checkFactoryRequirement evaluates the requirement called name against
+// getvar values. ok is false when the factory image does not require it.
+func checkFactoryRequirement(requirements []FactoryRequirement, name string, vars map[string]string) (FactoryRequirementCheck, bool) {
+	variable := factoryRequirementVars[name]
+	deviceValue := vars[variable]
+	product := vars["product"]
+
+	for _, requirement := range requirements {
+		if requirement.Name != name {
+			continue
+		}
+		if requirement.Product != "" && !strings.EqualFold(requirement.Product, product) {
+			continue
+		}
+
+		check := FactoryRequirementCheck{
+			Name:        name,
+			Expected:    strings.Join(requirement.Values, " or "),
+			DeviceValue: deviceValue,
+		}
+		for _, value := range requirement.Values {
+			if matchFactoryRequirement(value, deviceValue) {
+				check.Satisfied = true
+				break
+			}
+		}
+		return check, true
+	}
+	return FactoryRequirementCheck{}, false
+}
+
+// matchFactoryRequirement follows fastboot: case-insensitive, with a
+// trailing * matching any suffix.
+func matchFactoryRequirement(expected string, actual string) bool {
+	if prefix, ok := strings.CutSuffix(expected, "*"); ok {
+		return len(actual) >= len(prefix) && strings.EqualFold(actual[:len(prefix)], prefix)
+	}
+	return strings.EqualFold(expected, actual)
+}
+
+// parseAndroidInfo reads "require name=a|b" and
+// "require-for-product:product name=a|b" lines.
+func parseAndroidInfo(content string) []FactoryRequirement {
+	var requirements []FactoryRequirement
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		directive, rest, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+
+		requirement := FactoryRequirement{}
+		switch {
+		case directive == "require":
+		case strings.HasPrefix(directive, "require-for-product:"):
+			requirement.Product = strings.TrimPrefix(directive, "require-for-product:")
+		default:
+			continue
+		}
+
+		name, values, ok := strings.Cut(strings.TrimSpace(rest), "=")
+		if !ok {
+			continue
+		}
+		requirement.Name = strings.TrimSpace(name)
+		for _, value := range strings.Split(values, "|") {
+			if value = strings.TrimSpace(value); value != "" {
+				requirement.Values = append(requirement.Values, value)
+			}
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements
+}
+
+func openFactoryImage(imagePath string) (*factoryImage, error) {
+	if imagePath == "" {
+		return nil, fmt.Errorf("no factory image selected")
+	}
+	info, err := os.Stat(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open factory image: %w", err)
+	}
+
+	image := &factoryImage{
+		FactoryImage: FactoryImage{Path: imagePath},
+		entries:      map[string]*zip.File{},
+		paths:        map[string]string{},
+	}
+
+	if info.IsDir() {
+		err = image.scanFolder(imagePath)
+	} else {
+		err = image.scanZip(imagePath)
+	}
+	if err != nil {
+		image.Close()
+		return nil, err
+	}
+	if image.Bootloader == "" && image.Radio == "" && image.Image == "" {
+		image.Close()
+		return nil, fmt.Errorf("%s does not look like a factory image: no bootloader-*.img, radio-*.img or image-*.zip", filepath.Base(imagePath))
+	}
+
+	content := image.androidInfo
+	if content == "" && image.Image != "" {
+		if content, err = image.readAndroidInfo(); err != nil {
+			image.Close()
+			return nil, err
+		}
+	}
+	image.Requirements = parseAndroidInfo(content)
+	return image, nil
+}
+
+func (f *factoryImage) scanFolder(dir string) error {
+	// Factory zips hold a single <device>-<build> folder; accept either level.
+	candidates := []string{dir}
+	if children, err := os.ReadDir(dir); err == nil {
+		for _, child := range children {
+			if child.IsDir() {
+				candidates = append(candidates, filepath.Join(dir, child.Name()))
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		files, err := os.ReadDir(candidate)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			name := file.Name()
+			full := filepath.Join(candidate, name)
+			if name == "android-info.txt" && f.androidInfo == "" {
+				if data, err := os.ReadFile(full); err == nil {
+					f.androidInfo = string(data)
+				}
+				continue
+			}
+			if f.classify(name) {
+				f.paths[name] = full
+			}
+		}
+	}
+	return nil
+}
+
+func (f *factoryImage) scanZip(zipPath string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to open factory zip: %w", err)
+	}
+	f.zip = reader
+
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Base(entry.Name)
+		if f.classify(name) {
+			f.entries[name] = entry
+		}
+	}
+	return nil
+}
+
+// classify records name if it is one of the factory image pieces.
+func (f *factoryImage) classify(name string) bool {
+	switch {
+	case strings.HasPrefix(name, "bootloader-") && strings.HasSuffix(name, ".img") && f.Bootloader == "":
+		f.Bootloader = name
+	case strings.HasPrefix(name, "radio-") && strings.HasSuffix(name, ".img") && f.Radio == "":
+		f.Radio = name
+	case strings.HasPrefix(name, "image-") && strings.HasSuffix(name, ".zip") && f.Image == "":
+		f.Image = name
+	default:
+		return false
+	}
+	return true
+}
+
+// readAndroidInfo reads android-info.txt from the inner image zip. A stored
+// (uncompressed) inner zip is read in place; otherwise it is extracted first.
+func (f *factoryImage) readAndroidInfo() (string, error) {
+	var inner *zip.Reader
+	if entry, ok := f.entries[f.Image]; ok {
+		if entry.Method == zip.Store {
+			offset, err := entry.DataOffset()
+			if err != nil {
+				return "", err
+			}
+			file, err := os.Open(f.Path)
+			if err != nil {
+				return "", err
+			}
+			defer file.Close()
+			section := io.NewSectionReader(file, offset, int64(entry.UncompressedSize64))
+			if inner, err = zip.NewReader(section, section.Size()); err != nil {
+				return "", fmt.Errorf("failed to open %s: %w", f.Image, err)
+			}
+		} else {
+			tempDir, err := os.MkdirTemp("", "adbkit-factory-")
+			if err != nil {
+				return "", err
+			}
+			defer os.RemoveAll(tempDir)
+			extracted, err := f.extract(f.Image, tempDir)
+			if err != nil {
+				return "", err
+			}
+			reader, err := zip.OpenReader(extracted)
+			if err != nil {
+				return "", fmt.Errorf("failed to open %s: %w", f.Image, err)
+			}
+			defer reader.Close()
+			inner = &reader.Reader
+		}
+	} else {
+		reader, err := zip.OpenReader(f.paths[f.Image])
+		if err != nil {
+			return "", fmt.Errorf("failed to open %s: %w", f.Image, err)
+		}
+		defer reader.Close()
+		inner = &reader.Reader
+	}
+
+	for _, entry := range inner.File {
+		if entry.Name != "android-info.txt" {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(io.LimitReader(rc, 64*1024))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("android-info.txt not found in %s", f.Image)
+}
+
+// extract returns an on-disk path for name, copying it out of the zip into
+// dir when needed.
+func (f *factoryImage) extract(name string, dir string) (string, error) {
+	if p, ok := f.paths[name]; ok {
+		return p, nil
+	}
+	entry, ok := f.entries[name]
+	if !ok {
+		return "", fmt.Errorf("%s not found in factory image", name)
+	}
+
+	target := filepath.Join(dir, name)
+	if _, err := os.Stat(target); err == nil {
+		return target, nil
+	}
+
+	rc, err := entry.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		os.Remove(target)
+		return "", fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	f.paths[name] = target
+	return target, nil
+}
+
+func (f *factoryImage) Close() error {
+	if f.zip != nil {
+		return f.zip.Close()
+	}
+	return nil
+}
+
+// resolveFastbootSerial picks serial, else the target device, else the only
+// device in fastboot mode. Multi-step operations need a fixed serial so they
+// can wait for the same device across reboots.
+func (a *App) resolveFastbootSerial(serial string) (string, error) {
+	if serial == "" {
+		serial = a.GetTargetDevice()
+	}
+	if serial != "" {
+		return serial, nil
+	}
+
+	devices, err := a.GetFastbootDevices()
+	if err != nil {
+		return "", err
+	}
+	switch len(devices) {
+	case 0:
+		return "", ErrNoDevice
+	case 1:
+		return devices[0].Serial, nil
+	}
+	return "", ErrMultipleDevices
+}
+
+// rebootBootloaderAndWait reboots into the bootloader and returns once the
+// device is listed by fastboot again.
+func (a *App) rebootBootloaderAndWait(ctx context.Context, serial string) error {
+	if _, err := a.runFastboot(ctx, serial, "reboot-bootloader"); err != nil {
+		return fmt.Errorf("failed to reboot to bootloader: %w", err)
+	}
+	// The device stays listed for a moment while it goes down.
+	select {
+	case <-time.After(3 * time.Second):
+	case <-ctx.Done():
+		return contextError(ctx, "fastboot reboot-bootloader")
+	}
+	return a.waitForFastbootDevice(ctx, serial, fastbootRebootTimeout)
+}
+
+// waitForFastbootDevice polls until serial is listed in fastboot mode.
+func (a *App) waitForFastbootDevice(ctx context.Context, serial string, timeout time.Duration) error {
+	waitCtx, cancel := withCommandTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if devices, err := a.GetFastbootDevices(); err == nil {
+			for _, device := range devices {
+				if device.Serial == serial {
+					return nil
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return contextError(ctx, "fastboot devices")
+			}
+			return newTimeoutError("fastboot devices", fmt.Sprintf("device %s did not return to fastboot within %s", serial, timeout))
+		}
+	}
+}
+
+func (a *App) emitFactoryFlashProgress(progress FactoryFlashProgress) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, EventFactoryFlashProgress, progress)
+}
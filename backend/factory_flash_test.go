@@ -0,0 +1,116 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAndroidInfo(t *testing.T) {
+	content := "require board=oriole\n" +
+		"require version-bootloader=slider-1.3-11403664 | slider-1.3-11506101\r\n" +
+		"  require-for-product:raven version-baseband=g5123b-*\n" +
+		"require-partial-support=true\n" +
+		"require version-baseband\n" +
+		"# comment\n" +
+		"require board=|\n"
+
+	want := []FactoryRequirement{
+		{Name: "board", Values: []string{"oriole"}},
+		{Name: "version-bootloader", Values: []string{"slider-1.3-11403664", "slider-1.3-11506101"}},
+		{Name: "version-baseband", Values: []string{"g5123b-*"}, Product: "raven"},
+		{Name: "board"},
+	}
+	if got := parseAndroidInfo(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAndroidInfo = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestMatchFactoryRequirement(t *testing.T) {
+	tests := []struct {
+		expected, actual string
+		want             bool
+	}{
+		{"oriole", "oriole", true},
+		{"oriole", "ORIOLE", true},
+		{"oriole", "raven", false},
+		{"oriole", "oriole2", false},
+		{"g5123b-*", "g5123b-116954-230511-B-10112789", true},
+		{"G5123B-*", "g5123b-1", true},
+		{"g5123b-*", "g5123b-", true},
+		{"g5123b-*", "g5123b", false},
+		{"g5123b-*", "g5300b-1", false},
+		{"*", "", true},
+		{"oriole", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchFactoryRequirement(tt.expected, tt.actual); got != tt.want {
+			t.Errorf("matchFactoryRequirement(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.want)
+		}
+	}
+}
+
+func TestCheckFactoryRequirement(t *testing.T) {
+	requirements := parseAndroidInfo("require board=oriole|raven\n" +
+		"require-for-product:raven version-baseband=g5123b-*\n" +
+		"require-for-product:oriole version-baseband=g5300b-*\n" +
+		"require version-bootloader=slider-1.3-11403664\n")
+
+	tests := []struct {
+		name   string
+		check  string
+		vars   map[string]string
+		want   FactoryRequirementCheck
+		wantOK bool
+	}{
+		{
+			name:   "board",
+			check:  "board",
+			vars:   map[string]string{"product": "Raven"},
+			want:   FactoryRequirementCheck{Name: "board", Expected: "oriole or raven", DeviceValue: "Raven", Satisfied: true},
+			wantOK: true,
+		},
+		{
+			name:   "wrong board",
+			check:  "board",
+			vars:   map[string]string{"product": "cheetah"},
+			want:   FactoryRequirementCheck{Name: "board", Expected: "oriole or raven", DeviceValue: "cheetah"},
+			wantOK: true,
+		},
+		{
+			name:   "baseband for this product",
+			check:  "version-baseband",
+			vars:   map[string]string{"product": "oriole", "version-baseband": "g5300b-231107-B-11072891"},
+			want:   FactoryRequirementCheck{Name: "version-baseband", Expected: "g5300b-*", DeviceValue: "g5300b-231107-B-11072891", Satisfied: true},
+			wantOK: true,
+		},
+		{
+			name:   "baseband for another product",
+			check:  "version-baseband",
+			vars:   map[string]string{"product": "ORIOLE", "version-baseband": "g5123b-1"},
+			want:   FactoryRequirementCheck{Name: "version-baseband", Expected: "g5300b-*", DeviceValue: "g5123b-1"},
+			wantOK: true,
+		},
+		{
+			name:  "no baseband requirement for the product",
+			check: "version-baseband",
+			vars:  map[string]string{"product": "cheetah", "version-baseband": "g5300b-1"},
+		},
+		{
+			name:   "bootloader",
+			check:  "version-bootloader",
+			vars:   map[string]string{"product": "oriole", "version-bootloader": "SLIDER-1.3-11403664"},
+			want:   FactoryRequirementCheck{Name: "version-bootloader", Expected: "slider-1.3-11403664", DeviceValue: "SLIDER-1.3-11403664", Satisfied: true},
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := checkFactoryRequirement(requirements, tt.check, tt.vars)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkFactoryRequirement = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formatTimeout covers building the filesystem image and flashing it.
+const formatTimeout = 10 * time.Minute
+
+// criticalPartitions hold the bootloader chain, modem firmware and
+// per-device calibration or anti-theft data. Erasing them can hard-brick a
+// device or lose data no factory image restores.
+var criticalPartitions = map[string]bool{
+	"bootloader": true, "aboot": true, "abl": true, "xbl": true, "xbl_config": true,
+	"sbl1": true, "tz": true, "hyp": true, "rpm": true, "keymaster": true,
+	"devcfg": true, "devinfo": true, "preloader": true, "lk": true, "seccfg": true,
+	"radio": true, "modem": true, "modemst1": true, "modemst2": true, "fsg": true,
+	"fsc": true, "efs": true, "nvdata": true, "nvram": true, "protect1": true,
+	"protect2": true, "persist": true, "persistent": true, "frp": true, "config": true,
+}
+
+// partitionNamePattern matches GPT partition names, which are at most 36
+// characters. A name must not look like a fastboot flag or a path.
+var partitionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_\-]{0,35}$`)
+
+func checkPartitionName(partition string) error {
+	if partition == "" {
+		return fmt.Errorf("partition cannot be empty")
+	}
+	if !partitionNamePattern.MatchString(partition) {
+		return fmt.Errorf("invalid partition name %q", partition)
+	}
+	return nil
+}
+
+// checkCriticalPartition refuses destructive operations on critical
+// partitions unless allowCritical is set. Slot suffixes are ignored.
+func checkCriticalPartition(partition string, allowCritical bool) error {
+	name := slotSuffixPattern.ReplaceAllString(strings.ToLower(partition), "")
+	if criticalPartitions[name] && !allowCritical {
+		return fmt.Errorf("%s is a critical partition; erasing or formatting it can brick the device, so it needs the override", partition)
+	}
+	return nil
+}
+
+// FormatPartition creates an empty ext4 or f2fs filesystem the size of
+// partition with the bundled mke2fs/make_f2fs and flashes it. An empty
+// fsType uses the partition-type the device reports.
+func (a *App) FormatPartition(partition string, fsType string, allowCritical bool) error {
+	if err := checkPartitionName(partition); err != nil {
+		return err
+	}
+	if err := checkCriticalPartition(partition, allowCritical); err != nil {
+		return err
+	}
+
+	ctx, cancel := withCommandTimeout(context.Background(), formatTimeout)
+	defer cancel()
+
+	serial, err := a.resolveFastbootSerial("")
+	if err != nil {
+		return err
+	}
+	sizeValue, err := a.fastbootGetvar(ctx, serial, "partition-size:"+partition)
+	if err != nil {
+		return fmt.Errorf("failed to read the size of %s: %w", partition, err)
+	}
+	size := parseGetvarInt(sizeValue)
+	if size <= 0 {
+		return fmt.Errorf("device reports an invalid size for %s", partition)
+	}
+
+	if fsType == "" {
+		if fsType, err = a.fastbootGetvar(ctx, serial, "partition-type:"+partition); err != nil {
+			return fmt.Errorf("failed to read the filesystem type of %s: %w", partition, err)
+		}
+	}
+	fsType = strings.ToLower(strings.TrimSpace(fsType))
+	if fsType != "ext4" && fsType != "f2fs" {
+		return fmt.Errorf("cannot format %s as %q; only ext4 and f2fs are supported", partition, fsType)
+	}
+
+	dir, err := os.MkdirTemp("", "adbkit-format-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	image := filepath.Join(dir, partition+".img")
+	if err := a.makeFilesystem(ctx, fsType, image, size); err != nil {
+		return err
+	}
+
+	pieces, cleanup, err := a.splitForDownload(ctx, serial, image)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	for _, piece := range pieces {
+		if _, err := a.runFastboot(ctx, serial, "flash", partition, piece); err != nil {
+			return fmt.Errorf("failed to flash the new %s filesystem: %w", fsType, err)
+		}
+	}
+	return nil
+}
+
+// makeFilesystem writes an empty sparse filesystem image of size bytes the
+// way fastboot format does.
+func (a *App) makeFilesystem(ctx context.Context, fsType string, image string, size int64) error {
+	var tool string
+	var args []string
+	switch fsType {
+	case "ext4":
+		tool = "mke2fs"
+		args = []string{"-t", "ext4", "-b", "4096", "-E", "android_sparse", "-F", "-q", image, strconv.FormatInt(size/4096, 10)}
+	case "f2fs":
+		tool = "make_f2fs"
+		// make_f2fs only writes into an existing file.
+		if err := os.WriteFile(image, nil, 0644); err != nil {
+			return err
+		}
+		args = []string{"-f", "-g", "android", "-S", strconv.FormatInt(size, 10), image}
+	}
+
+	binaryPath, err := a.getBinaryPath(tool)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	setCommandWindowMode(cmd)
+	// mke2fs looks for the Android defaults next to itself, as fastboot sets up.
+	cmd.Env = append(os.Environ(), "MKE2FS_CONFIG="+filepath.Join(filepath.Dir(binaryPath), "mke2fs.conf"))
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		commandLine := tool + " " + strings.Join(args, " ")
+		if ctxErr := contextError(ctx, commandLine); ctxErr != nil {
+			return ctxErr
+		}
+		errOutput := strings.TrimSpace(out.String())
+		if errOutput == "" {
+			errOutput = err.Error()
+		}
+		return newCommandError("", commandLine, exitCodeOf(err), errOutput)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// getvarQualifiedKeys are getvar names followed by a partition or slot, as in
+// "partition-size:boot_a: 0x4000000".
+var getvarQualifiedKeys = map[string]bool{
+	"partition-size":   true,
+	"partition-type":   true,
+	"is-logical":       true,
+	"has-slot":         true,
+	"slot-successful":  true,
+	"slot-unbootable":  true,
+	"slot-retry-count": true,
+}
+
+type FastbootPartition struct {
+	Name      string
+	Size      int64
+	Type      string
+	IsLogical bool
+}
+
+type FastbootInfo struct {
+	Serial      string
+	Product     string
+	Unlocked    bool
+	Secure      bool
+	CurrentSlot string
+	SlotCount   int
+	// IsUserspace is true in fastbootd, false in the bootloader.
+	IsUserspace     bool
+	MaxDownloadSize int64
+	// BatteryVoltage in millivolts, 0 when the bootloader does not report it.
+	BatteryVoltage int
+	Partitions     []FastbootPartition
+	// Vars holds every reported variable; qualified ones are keyed like
+	// "partition-size:boot_a".
+	Vars map[string]string
+}
+
+// GetFastbootInfo runs `fastboot getvar all` and parses what the bootloader
+// or fastbootd reports. An empty serial uses the target device.
+func (a *App) GetFastbootInfo(serial string) (FastbootInfo, error) {
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+
+	output, err := a.runFastboot(ctx, serial, "getvar", "all")
+	if err != nil {
+		return FastbootInfo{}, err
+	}
+
+	info := parseFastbootInfo(parseGetvarAll(output))
+	info.Serial = serial
+	if info.Serial == "" {
+		info.Serial = a.GetTargetDevice()
+	}
+	return info, nil
+}
+
+// parseGetvarAll collects the "(bootloader) key: value" lines of getvar all.
+// Bootloaders differ on whether a space follows the colon, so values are
+// trimmed.
+func parseGetvarAll(output string) map[string]string {
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "(bootloader)")
+		if !ok {
+			continue
+		}
+		key, value, ok := splitGetvarLine(strings.TrimSpace(line))
+		if ok {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+func splitGetvarLine(line string) (string, string, bool) {
+	key, rest, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	if getvarQualifiedKeys[key] {
+		qualifier, value, ok := strings.Cut(rest, ":")
+		if !ok {
+			return "", "", false
+		}
+		key += ":" + strings.TrimSpace(qualifier)
+		rest = value
+	}
+	if key == "" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(rest), true
+}
+
+func parseFastbootInfo(vars map[string]string) FastbootInfo {
+	info := FastbootInfo{
+		Product:         vars["product"],
+		Unlocked:        vars["unlocked"] == "yes",
+		Secure:          vars["secure"] == "yes",
+		CurrentSlot:     strings.TrimPrefix(vars["current-slot"], "_"),
+		IsUserspace:     vars["is-userspace"] == "yes",
+		MaxDownloadSize: parseGetvarInt(vars["max-download-size"]),
+		Vars:            vars,
+	}
+	info.SlotCount = int(parseGetvarInt(vars["slot-count"]))
+
+	voltage := vars["battery-voltage"]
+	if voltage == "" {
+		voltage = vars["voltage"]
+	}
+	info.BatteryVoltage = int(parseGetvarInt(strings.TrimSuffix(strings.ToLower(voltage), "mv")))
+
+	partitions := map[string]*FastbootPartition{}
+	partition := func(name string) *FastbootPartition {
+		p, ok := partitions[name]
+		if !ok {
+			p = &FastbootPartition{Name: name}
+			partitions[name] = p
+		}
+		return p
+	}
+	for key, value := range vars {
+		prefix, name, ok := strings.Cut(key, ":")
+		if !ok {
+			continue
+		}
+		switch prefix {
+		case "partition-size":
+			partition(name).Size = parseGetvarInt(value)
+		case "partition-type":
+			partition(name).Type = value
+		case "is-logical":
+			partition(name).IsLogical = value == "yes"
+		}
+	}
+
+	info.Partitions = make([]FastbootPartition, 0, len(partitions))
+	for _, p := range partitions {
+		info.Partitions = append(info.Partitions, *p)
+	}
+	sort.Slice(info.Partitions, func(i, j int) bool {
+		return info.Partitions[i].Name < info.Partitions[j].Name
+	})
+	return info
+}
+
+// parseGetvarInt accepts the decimal and 0x-prefixed hex values bootloaders
+// report, returning 0 for anything else.
+func parseGetvarInt(value string) int64 {
+	value = strings.TrimSpace(value)
+	if hex, ok := strings.CutPrefix(strings.ToLower(value), "0x"); ok {
+		n, _ := strconv.ParseInt(hex, 16, 64)
+		return n
+	}
+	n, _ := strconv.ParseInt(value, 10, 64)
+	return n
+}
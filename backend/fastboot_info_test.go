@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pixelGetvarAll is getvar all from a Pixel bootloader, trimmed.
+const pixelGetvarAll = `(bootloader) max-download-size:0x10000000
+(bootloader) variant:SM8150 UFS
+(bootloader) partition-type:vbmeta_a:raw
+(bootloader) partition-size:vbmeta_a: 0x10000
+(bootloader) partition-type:super:raw
+(bootloader) partition-size:super:0x2600000000
+(bootloader) has-slot:boot:yes
+(bootloader) has-slot:super:no
+(bootloader) current-slot:_b
+(bootloader) slot-count:2
+(bootloader) slot-successful:a:no
+(bootloader) slot-successful:b:yes
+(bootloader) slot-unbootable:a:yes
+(bootloader) slot-unbootable:b:no
+(bootloader) slot-retry-count:a:0
+(bootloader) slot-retry-count:b:7
+(bootloader) battery-voltage:4134mV
+(bootloader) secure:yes
+(bootloader) unlocked:no
+(bootloader) product:coral
+(bootloader) version-bootloader:c2f2-0.3-8437432
+all: listed above
+Finished. Total time: 0.112s`
+
+func TestParseGetvarAll(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]string
+	}{
+		{"space after colon", "(bootloader) product: oriole", map[string]string{"product": "oriole"}},
+		{"no space", "(bootloader) product:oriole", map[string]string{"product": "oriole"}},
+		{"qualified", "(bootloader) partition-size:boot_a: 0x4000000", map[string]string{"partition-size:boot_a": "0x4000000"}},
+		{"value with colons", "(bootloader) version-baseband:g7250-00188-220211:B", map[string]string{"version-baseband": "g7250-00188-220211:B"}},
+		{"qualified without value", "(bootloader) has-slot:boot", map[string]string{}},
+		{"fastboot's own lines", "all: listed above\nFinished. Total time: 0.1s", map[string]string{}},
+		{"indented", "  (bootloader)   slot-count:2  ", map[string]string{"slot-count": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseGetvarAll(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGetvarAll(%q) = %v, want %v", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFastbootInfo(t *testing.T) {
+	vars := parseGetvarAll(pixelGetvarAll)
+	if len(vars) != 21 {
+		t.Errorf("parsed %d variables, want 21: %v", len(vars), vars)
+	}
+	info := parseFastbootInfo(vars)
+
+	if info.Product != "coral" || info.Unlocked || !info.Secure || info.IsUserspace {
+		t.Errorf("info = %+v", info)
+	}
+	if info.CurrentSlot != "b" || info.SlotCount != 2 {
+		t.Errorf("slots: current %q, count %d; want b, 2", info.CurrentSlot, info.SlotCount)
+	}
+	if info.MaxDownloadSize != 0x10000000 || info.BatteryVoltage != 4134 {
+		t.Errorf("max-download-size %d, voltage %d", info.MaxDownloadSize, info.BatteryVoltage)
+	}
+	want := []FastbootPartition{
+		{Name: "super", Size: 0x2600000000, Type: "raw"},
+		{Name: "vbmeta_a", Size: 0x10000, Type: "raw"},
+	}
+	if !reflect.DeepEqual(info.Partitions, want) {
+		t.Errorf("partitions = %+v, want %+v", info.Partitions, want)
+	}
+}
+
+func TestParseGetvarInt(t *testing.T) {
+	for value, want := range map[string]int64{"0x10000000": 0x10000000, "0X1f": 31, "4096": 4096, " 7 ": 7, "": 0, "yes": 0} {
+		if got := parseGetvarInt(value); got != want {
+			t.Errorf("parseGetvarInt(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
@@ -1,34 +1,186 @@
 package backend
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 )
+
+// WipeData runs fastboot -w on a single, explicitly resolved device so it
+// never lands on whichever device fastboot happens to pick. Locked
+// bootloaders refuse the erase, so that is reported up front.
 func (a *App) WipeData() error {
-	output, err := a.runCommand("fastboot", "-w")
+	serial, err := a.resolveFastbootSerial("")
 	if err != nil {
-		return fmt.Errorf("failed to run fastboot -w: %w. Output: %s", err, output)
+		return err
+	}
+
+	ctx, cancel := withCommandTimeout(context.Background(), formatTimeout)
+	defer cancel()
+
+	if unlocked, err := a.fastbootGetvar(ctx, serial, "unlocked"); err == nil && unlocked == "no" {
+		return fmt.Errorf("the bootloader is locked; factory reset from recovery or Settings instead")
+	}
+
+	if _, err := a.runFastboot(ctx, serial, "-w"); err != nil {
+		return fmt.Errorf("failed to run fastboot -w: %w", err)
 	}
 	return nil
 }
 
-func (a *App) FlashPartition(partition string, filePath string) error {
+// BootImage boots filePath once with fastboot boot, without flashing it, to
+// test a patched boot or recovery image.
+func (a *App) BootImage(filePath string) error {
+	if filePath == "" {
+		return fmt.Errorf("file path cannot be empty")
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open image: %w", err)
+	}
+	info, err := parseBootImage(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	if info.Kind != BootImageKindBoot {
+		return fmt.Errorf("%s images cannot be booted directly; fastboot boot needs a boot or recovery image with a kernel", info.Kind)
+	}
+
+	serial, err := a.resolveFastbootSerial("")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withCommandTimeout(context.Background(), formatTimeout)
+	defer cancel()
+
+	if _, err := a.runFastboot(ctx, serial, "boot", filePath); err != nil {
+		return fmt.Errorf("failed to run fastboot boot: %w", err)
+	}
+	return nil
+}
+
+// ErasePartition erases partition. Critical partitions are refused unless
+// allowCritical is set.
+func (a *App) ErasePartition(partition string, allowCritical bool) error {
+	if err := checkPartitionName(partition); err != nil {
+		return err
+	}
+	if err := checkCriticalPartition(partition, allowCritical); err != nil {
+		return err
+	}
+
+	serial, err := a.resolveFastbootSerial("")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withCommandTimeout(context.Background(), formatTimeout)
+	defer cancel()
+
+	if _, err := a.runFastboot(ctx, serial, "erase", partition); err != nil {
+		return fmt.Errorf("failed to run fastboot erase: %w", err)
+	}
+	return nil
+}
+
+// FlashPartition flashes filePath to partition as a job and returns its id.
+// slot is empty for the bootloader's default, "a"/"b" for a specific slot or
+// "all" for both. Progress is parsed from fastboot's output and emitted as
+// FlashProgress events.
+func (a *App) FlashPartition(partition string, filePath string, slot string) (string, error) {
 	if partition == "" || filePath == "" {
-		return fmt.Errorf("partition and file path cannot be empty")
+		return "", fmt.Errorf("partition and file path cannot be empty")
+	}
+	slot, err := normalizeSlot(slot, true)
+	if err != nil {
+		return "", err
+	}
+
+	serial, err := a.resolveFastbootSerial("")
+	if err != nil {
+		return "", err
 	}
 
-	output, err := a.runCommand("fastboot", "flash", partition, filePath)
+	job := a.jobs.startOn(nil, serial, "fastboot-flash", "Flash "+filepath.Base(filePath)+" to "+partition, 0)
+	go func() {
+		job.finish("", a.flashPartition(job, partition, filePath, slot, nil))
+	}()
+	return job.id, nil
+}
+
+// flashPartition flashes filePath on the job's device. flags go before the
+// flash command, as fastboot expects for options like --disable-verity.
+func (a *App) flashPartition(job *jobHandle, partition string, filePath string, slot string, flags []string) error {
+	pieces, cleanup, err := a.splitForDownload(job.ctx, job.serial, filePath)
 	if err != nil {
-		return fmt.Errorf("failed to run fastboot flash: %w. Output: %s", err, output)
+		return err
+	}
+	defer cleanup()
+
+	progress := &fastbootProgress{}
+	for _, piece := range pieces {
+		if stat, err := os.Stat(piece); err == nil {
+			progress.totalKB += float64(stat.Size()) / 1024
+		}
+	}
+	if slot == "all" {
+		progress.totalKB *= 2
+	}
+	reporter := &flashReporter{app: a, job: job}
+
+	for _, piece := range pieces {
+		args := append(append([]string{}, flags...), "flash", partition, piece)
+		if slot != "" {
+			args = append([]string{"--slot=" + slot}, args...)
+		}
+
+		_, err := a.runFastbootStream(job.ctx, job.serial, func(chunk []byte) {
+			reporter.report(progress.feed(chunk))
+		}, args...)
+		if err != nil {
+			return fmt.Errorf("failed to run fastboot flash: %w", err)
+		}
 	}
 	return nil
 }
 
+// FlashVbmeta flashes filePath to the vbmeta partition as a job and returns
+// its id, optionally with the flags that turn off dm-verity and AVB
+// verification. Custom ROMs and patched images usually need both, which in
+// turn need an unlocked bootloader.
+func (a *App) FlashVbmeta(filePath string, disableVerity bool, disableVerification bool) (string, error) {
+	if filePath == "" {
+		return "", fmt.Errorf("file path cannot be empty")
+	}
+
+	var flags []string
+	if disableVerity {
+		flags = append(flags, "--disable-verity")
+	}
+	if disableVerification {
+		flags = append(flags, "--disable-verification")
+	}
+
+	serial, err := a.resolveFastbootSerial("")
+	if err != nil {
+		return "", err
+	}
+
+	job := a.jobs.startOn(nil, serial, "vbmeta-flash", "Flash "+filepath.Base(filePath)+" to vbmeta", 0)
+	go func() {
+		job.finish("", a.flashPartition(job, "vbmeta", filePath, "", flags))
+	}()
+	return job.id, nil
+}
+
 func (a *App) GetFastbootDevices() ([]Device, error) {
 	output, err := a.runCommand("fastboot", "devices")
 	if err != nil {
 		if output == "" {
-			return []Device{}, nil
+			return a.tcpFastbootDevices(), nil
 		}
 		return nil, err
 	}
@@ -46,7 +198,7 @@ func (a *App) GetFastbootDevices() ([]Device, error) {
 		}
 	}
 
-	return devices, nil
+	return append(devices, a.tcpFastbootDevices()...), nil
 }
 
 func (a *App) RunFastbootHostCommand(args string) (string, error) {
@@ -0,0 +1,143 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type SlotInfo struct {
+	Name       string
+	Active     bool
+	Successful bool
+	Unbootable bool
+	// RetryCount is -1 when the bootloader does not report it.
+	RetryCount int
+}
+
+var slotVariables = []string{"slot-successful", "slot-unbootable", "slot-retry-count"}
+
+// GetSlots reports the state of every A/B slot. Devices without A/B slots
+// return an empty list.
+func (a *App) GetSlots(serial string) ([]SlotInfo, error) {
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+
+	output, err := a.runFastboot(ctx, serial, "getvar", "all")
+	if err != nil {
+		return nil, err
+	}
+	vars := parseGetvarAll(output)
+	info := parseFastbootInfo(vars)
+
+	// Not every bootloader lists per-slot variables in getvar all. Those it
+	// leaves out entirely are read one at a time, and a variable the
+	// bootloader rejects for one slot is not asked for again.
+	query := map[string]bool{}
+	for _, key := range slotVariables {
+		query[key] = true
+		for qualified := range vars {
+			if strings.HasPrefix(qualified, key+":") {
+				query[key] = false
+				break
+			}
+		}
+	}
+
+	slots := []SlotInfo{}
+	for i := 0; i < info.SlotCount && i < 26; i++ {
+		name := string(rune('a' + i))
+		slot := SlotInfo{Name: name, Active: info.CurrentSlot == name, RetryCount: -1}
+
+		for _, key := range slotVariables {
+			if !query[key] {
+				continue
+			}
+			value, err := a.fastbootGetvar(ctx, serial, key+":"+name)
+			if isUnknownVariable(err) {
+				query[key] = false
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s:%s: %w", key, name, err)
+			}
+			vars[key+":"+name] = value
+		}
+
+		slot.Successful = vars["slot-successful:"+name] == "yes"
+		slot.Unbootable = vars["slot-unbootable:"+name] == "yes"
+		if retry, ok := vars["slot-retry-count:"+name]; ok && retry != "" {
+			slot.RetryCount = int(parseGetvarInt(retry))
+		}
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+// SetActiveSlot marks slot ("a" or "b") as the one to boot next.
+func (a *App) SetActiveSlot(serial string, slot string) error {
+	slot, err := normalizeSlot(slot, false)
+	if err != nil {
+		return err
+	}
+	if slot == "" {
+		return fmt.Errorf("slot cannot be empty")
+	}
+
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+
+	if _, err := a.runFastboot(ctx, serial, "--set-active="+slot); err != nil {
+		return fmt.Errorf("failed to set active slot: %w", err)
+	}
+	return nil
+}
+
+// fastbootGetvar reads a single variable. fastboot prints "name: value" on
+// stderr, or an error when the bootloader does not know the variable.
+func (a *App) fastbootGetvar(ctx context.Context, serial string, name string) (string, error) {
+	output, err := a.runFastboot(ctx, serial, "getvar", name)
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimSpace(strings.TrimPrefix(line, "(bootloader)"))
+		if value, ok := strings.CutPrefix(line, name+":"); ok {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("variable %s %w", name, errGetvarNotReported)
+}
+
+var errGetvarNotReported = errors.New("not reported by the device")
+
+// isUnknownVariable reports whether a getvar failed only because the
+// bootloader does not know the variable, as opposed to a timeout or a lost
+// device.
+func isUnknownVariable(err error) bool {
+	if errors.Is(err, errGetvarNotReported) {
+		return true
+	}
+	var cmdErr *CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == CodeCommandFailed && strings.Contains(cmdErr.Stderr, "FAILED (remote:")
+}
+
+// normalizeSlot accepts "a", "_a" or "A" style slot names, and "all" when
+// allowAll is set. An empty slot is returned unchanged.
+func normalizeSlot(slot string, allowAll bool) (string, error) {
+	slot = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(slot), "_"))
+	switch {
+	case slot == "":
+		return "", nil
+	case slot == "all" && allowAll:
+		return slot, nil
+	case len(slot) == 1 && slot[0] >= 'a' && slot[0] <= 'z':
+		return slot, nil
+	}
+	return "", fmt.Errorf("invalid slot %q", slot)
+}
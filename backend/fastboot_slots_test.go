@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetSlots(t *testing.T) {
+	full := parseGetvarAll(pixelGetvarAll)
+	// Bootloaders that leave the per-slot variables out of getvar all.
+	partial := map[string]string{"slot-count": "2", "current-slot": "a"}
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		// single are variables only answered when asked for one at a time.
+		single      map[string]string
+		hangUp      string
+		want        []SlotInfo
+		wantQueries []string
+		wantErr     bool
+	}{
+		{
+			name: "all listed",
+			vars: full,
+			want: []SlotInfo{
+				{Name: "a", Unbootable: true, RetryCount: 0},
+				{Name: "b", Active: true, Successful: true, RetryCount: 7},
+			},
+		},
+		{
+			name:   "queried one at a time",
+			vars:   partial,
+			single: map[string]string{"slot-successful:a": "yes", "slot-successful:b": "no", "slot-retry-count:a": "3", "slot-retry-count:b": "0"},
+			want: []SlotInfo{
+				{Name: "a", Active: true, Successful: true, RetryCount: 3},
+				{Name: "b", RetryCount: 0},
+			},
+			// slot-unbootable is unknown, so it is not asked for slot b.
+			wantQueries: []string{"slot-successful:a", "slot-unbootable:a", "slot-retry-count:a", "slot-successful:b", "slot-retry-count:b"},
+		},
+		{
+			name:    "device lost",
+			vars:    partial,
+			single:  map[string]string{"slot-successful:a": "yes"},
+			hangUp:  "getvar:slot-unbootable:a",
+			wantErr: true,
+		},
+		{
+			name: "no A/B slots",
+			vars: map[string]string{"product": "walleye"},
+			want: []SlotInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := startFakeFastboot(t, tt.vars)
+			device.single = tt.single
+			if tt.hangUp != "" {
+				device.hangUp[tt.hangUp] = true
+			}
+
+			slots, err := (&App{}).GetSlots(device.serial)
+			if tt.wantErr {
+				if err == nil || isUnknownVariable(err) {
+					t.Fatalf("err = %v, want the lost device reported", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(slots, tt.want) {
+				t.Errorf("slots = %+v, want %+v", slots, tt.want)
+			}
+
+			var queries []string
+			for _, command := range device.received() {
+				if name, ok := strings.CutPrefix(command, "getvar:"); ok && name != "all" {
+					queries = append(queries, name)
+				}
+			}
+			if !reflect.DeepEqual(queries, tt.wantQueries) {
+				t.Errorf("queried %q, want %q", queries, tt.wantQueries)
+			}
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	fastbootTCPPrefix      = "tcp:"
+	fastbootTCPDefaultPort = "5554"
+	fastbootTCPVersion     = 1
+	// Responses are at most 256 bytes; larger packets only flow host to device.
+	fastbootMaxResponse = 64 * 1024
+	fastbootChunkSize   = 1024 * 1024
+
+	// Offset of the flags in a vbmeta header, which fastboot sets for
+	// --disable-verity and --disable-verification.
+	avbFlagsOffset = 120
+)
+
+// isFastbootTCPSerial reports whether serial names a network fastboot target
+// such as "tcp:192.168.1.20" or "tcp:emulator:5554".
+func isFastbootTCPSerial(serial string) bool {
+	return strings.HasPrefix(serial, fastbootTCPPrefix)
+}
+
+// fastbootTCPAddress turns "tcp:host[:port]" into host:port.
+func fastbootTCPAddress(serial string) string {
+	host := strings.TrimPrefix(serial, fastbootTCPPrefix)
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), fastbootTCPDefaultPort)
+}
+
+// fastbootTCPTarget reports whether a fastboot command line is aimed at a
+// tcp: target, either with -s or through the selected target device, and
+// returns the serial and the arguments without -s.
+func (a *App) fastbootTCPTarget(name string, args []string) (string, []string, bool) {
+	if name != "fastboot" {
+		return "", nil, false
+	}
+	if len(args) >= 2 && args[0] == "-s" {
+		return args[1], args[2:], isFastbootTCPSerial(args[1])
+	}
+	serial, targeted := a.targetArgs(name, args)
+	if !isFastbootTCPSerial(serial) {
+		return "", nil, false
+	}
+	return serial, targeted[2:], true
+}
+
+// ConnectFastbootTcp checks that a fastboot target answers at address
+// ("host" or "host:port", port 5554 by default) and lists it with the USB
+// fastboot devices until DisconnectFastbootTcp.
+func (a *App) ConnectFastbootTcp(address string) (Device, error) {
+	address = strings.TrimPrefix(strings.TrimSpace(address), fastbootTCPPrefix)
+	if address == "" {
+		return Device{}, fmt.Errorf("address cannot be empty")
+	}
+	serial := fastbootTCPPrefix + address
+
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+	product, err := a.fastbootGetvar(ctx, serial, "product")
+	if err != nil {
+		return Device{}, err
+	}
+
+	a.tcpFastbootMutex.Lock()
+	a.tcpFastboot[serial] = product
+	a.tcpFastbootMutex.Unlock()
+	return Device{Serial: serial, Status: "fastboot", Product: product}, nil
+}
+
+func (a *App) DisconnectFastbootTcp(serial string) {
+	a.tcpFastbootMutex.Lock()
+	delete(a.tcpFastboot, serial)
+	a.tcpFastbootMutex.Unlock()
+}
+
+func (a *App) tcpFastbootDevices() []Device {
+	a.tcpFastbootMutex.Lock()
+	defer a.tcpFastbootMutex.Unlock()
+
+	devices := make([]Device, 0, len(a.tcpFastboot))
+	for serial, product := range a.tcpFastboot {
+		devices = append(devices, Device{Serial: serial, Status: "fastboot", Product: product})
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Serial < devices[j].Serial })
+	return devices
+}
+
+// fastbootRemoteError is a FAIL response from the device.
+type fastbootRemoteError struct {
+	message string
+}
+
+func (e *fastbootRemoteError) Error() string {
+	return fmt.Sprintf("FAILED (remote: '%s')", e.message)
+}
+
+// fastbootTCPConn is one fastboot session over TCP: an "FBxx" version
+// handshake, then messages framed with an 8-byte big-endian length.
+type fastbootTCPConn struct {
+	conn net.Conn
+}
+
+func dialFastbootTCP(ctx context.Context, serial string) (*fastbootTCPConn, error) {
+	address := fastbootTCPAddress(serial)
+	dialer := net.Dialer{Timeout: adbDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+
+	c := &fastbootTCPConn{conn: conn}
+	if err := c.handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *fastbootTCPConn) handshake() error {
+	if _, err := fmt.Fprintf(c.conn, "FB%02d", fastbootTCPVersion); err != nil {
+		return err
+	}
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(c.conn, reply); err != nil {
+		return fmt.Errorf("fastboot handshake failed: %w", err)
+	}
+	version, err := strconv.Atoi(string(reply[2:]))
+	if string(reply[:2]) != "FB" || err != nil || version < fastbootTCPVersion {
+		return fmt.Errorf("unexpected fastboot handshake %q", reply)
+	}
+	return nil
+}
+
+func (c *fastbootTCPConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *fastbootTCPConn) writePacket(data []byte) error {
+	packet := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(packet, uint64(len(data)))
+	copy(packet[8:], data)
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+func (c *fastbootTCPConn) readPacket() ([]byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint64(header)
+	if length > fastbootMaxResponse {
+		return nil, fmt.Errorf("fastboot response of %d bytes is too large", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readResponse reads until OKAY, FAIL or DATA, passing INFO and TEXT
+// messages to info. It returns the final status and its payload.
+func (c *fastbootTCPConn) readResponse(info func(string)) (string, string, error) {
+	for {
+		packet, err := c.readPacket()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read fastboot response: %w", err)
+		}
+		if len(packet) < 4 {
+			return "", "", fmt.Errorf("short fastboot response %q", packet)
+		}
+
+		status, payload := string(packet[:4]), string(packet[4:])
+		switch status {
+		case "INFO", "TEXT":
+			info(payload)
+		case "OKAY", "DATA":
+			return status, payload, nil
+		case "FAIL":
+			return "", "", &fastbootRemoteError{message: payload}
+		default:
+			return "", "", fmt.Errorf("unexpected fastboot response %q", packet)
+		}
+	}
+}
+
+// command sends a fastboot command such as "getvar:product" and returns the
+// OKAY payload.
+func (c *fastbootTCPConn) command(command string, info func(string)) (string, error) {
+	if err := c.writePacket([]byte(command)); err != nil {
+		return "", err
+	}
+	status, payload, err := c.readResponse(info)
+	if err != nil {
+		return "", err
+	}
+	if status != "OKAY" {
+		return "", fmt.Errorf("unexpected %s response to %s", status, command)
+	}
+	return payload, nil
+}
+
+// download sends size bytes from r to the device's download buffer.
+func (c *fastbootTCPConn) download(r io.Reader, size int64, info func(string)) error {
+	if err := c.writePacket([]byte(fmt.Sprintf("download:%08x", size))); err != nil {
+		return err
+	}
+	status, payload, err := c.readResponse(info)
+	if err != nil {
+		return err
+	}
+	if accepted, _ := strconv.ParseInt(payload, 16, 64); status != "DATA" || accepted != size {
+		return fmt.Errorf("device accepted %s%s for a %d byte download", status, payload, size)
+	}
+
+	buf := make([]byte, fastbootChunkSize)
+	for sent := int64(0); sent < size; {
+		n, err := io.ReadFull(r, buf[:min(int64(len(buf)), size-sent)])
+		if err != nil {
+			return err
+		}
+		if err := c.writePacket(buf[:n]); err != nil {
+			return err
+		}
+		sent += int64(n)
+	}
+
+	_, _, err = c.readResponse(info)
+	return err
+}
+
+// runFastbootTCP runs the fastboot command line args against a tcp: target
+// in-process. Output follows the fastboot tool's, so the getvar and progress
+// parsers work on it, and is also streamed to onOutput when set.
+func (a *App) runFastbootTCP(ctx context.Context, serial string, args []string, onOutput func([]byte)) (string, error) {
+	commandLine := "fastboot -s " + serial + " " + strings.Join(args, " ")
+
+	var out bytes.Buffer
+	session := &fastbootTCPSession{emit: func(text string) {
+		out.WriteString(text)
+		if onOutput != nil {
+			onOutput([]byte(text))
+		}
+	}}
+
+	err := func() error {
+		conn, err := dialFastbootTCP(ctx, serial)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		defer watchContext(ctx, conn.conn)()
+
+		session.conn = conn
+		return session.run(args)
+	}()
+	if err != nil {
+		if ctxErr := contextError(ctx, commandLine); ctxErr != nil {
+			return "", ctxErr
+		}
+		errOutput := err.Error()
+		if output := strings.TrimSpace(out.String()); output != "" {
+			errOutput = output + "\n" + errOutput
+		}
+		return "", newCommandError(serial, commandLine, 1, errOutput)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// fastbootTCPSession maps fastboot tool arguments onto protocol commands.
+type fastbootTCPSession struct {
+	conn *fastbootTCPConn
+	emit func(string)
+
+	// label is the step in progress, printed without a newline until its
+	// OKAY. interrupted is set when INFO lines broke it up.
+	label       string
+	started     time.Time
+	interrupted bool
+}
+
+func (s *fastbootTCPSession) info(message string) {
+	if s.label != "" && !s.interrupted {
+		s.emit("\n")
+		s.interrupted = true
+	}
+	s.emit("(bootloader) " + message + "\n")
+}
+
+func (s *fastbootTCPSession) begin(label string) {
+	s.label, s.started, s.interrupted = label, time.Now(), false
+	s.emit(label)
+}
+
+// end prints the OKAY, repeating the label if INFO lines came in between so
+// each step still reads as one "<label> OKAY" line.
+func (s *fastbootTCPSession) end(err error) error {
+	if s.label != "" {
+		switch {
+		case err != nil && !s.interrupted:
+			s.emit("\n")
+		case err == nil && s.interrupted:
+			s.emit(s.label + fmt.Sprintf(" OKAY [%7.3fs]\n", time.Since(s.started).Seconds()))
+		case err == nil:
+			s.emit(fmt.Sprintf(" OKAY [%7.3fs]\n", time.Since(s.started).Seconds()))
+		}
+	}
+	s.label = ""
+	return err
+}
+
+func (s *fastbootTCPSession) run(args []string) error {
+	var slot string
+	var vbmetaFlags uint32
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		flag := args[0]
+		args = args[1:]
+		switch {
+		case strings.HasPrefix(flag, "--slot="):
+			slot = strings.TrimPrefix(flag, "--slot=")
+		case strings.HasPrefix(flag, "--set-active="):
+			return s.step("Setting current slot to '"+strings.TrimPrefix(flag, "--set-active=")+"'", "set_active:"+strings.TrimPrefix(flag, "--set-active="))
+		case flag == "--disable-verity":
+			vbmetaFlags |= avbFlagHashtreeDisabled
+		case flag == "--disable-verification":
+			vbmetaFlags |= avbFlagVerificationDisabled
+		default:
+			return fmt.Errorf("%s is not supported over TCP", flag)
+		}
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no fastboot command given")
+	}
+
+	command, params := args[0], args[1:]
+	switch {
+	case command == "getvar" && len(params) == 1:
+		return s.getvar(params[0])
+
+	case command == "flash" && len(params) == 2:
+		partitions, err := s.slotPartitions(params[0], slot)
+		if err != nil {
+			return err
+		}
+		for _, partition := range partitions {
+			if err := s.flash(partition, params[1], vbmetaFlags); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case command == "erase" && len(params) == 1:
+		partitions, err := s.slotPartitions(params[0], slot)
+		if err != nil {
+			return err
+		}
+		for _, partition := range partitions {
+			if err := s.step("Erasing '"+partition+"'", "erase:"+partition); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case command == "boot" && len(params) == 1:
+		if err := s.send("boot.img", params[0], 0); err != nil {
+			return err
+		}
+		return s.step("Booting", "boot")
+
+	case command == "set_active" && len(params) == 1:
+		return s.step("Setting current slot to '"+params[0]+"'", "set_active:"+params[0])
+
+	case command == "reboot" && len(params) <= 1:
+		if len(params) == 0 {
+			return s.step("Rebooting", "reboot")
+		}
+		return s.step("Rebooting into "+params[0], "reboot-"+params[0])
+
+	case command == "reboot-bootloader" && len(params) == 0:
+		return s.step("Rebooting into bootloader", "reboot-bootloader")
+
+	case command == "continue" && len(params) == 0:
+		return s.step("Resuming boot", "continue")
+
+	case command == "oem" || command == "flashing":
+		return s.step("", strings.Join(args, " "))
+
+	case (command == "create-logical-partition" || command == "resize-logical-partition") && len(params) == 2:
+		return s.step(command+" '"+params[0]+"'", command+":"+params[0]+":"+params[1])
+
+	case command == "delete-logical-partition" && len(params) == 1:
+		return s.step("Deleting '"+params[0]+"'", command+":"+params[0])
+	}
+	return fmt.Errorf("fastboot %s is not supported over TCP", strings.Join(args, " "))
+}
+
+// step runs one command, printing label and its OKAY the way fastboot does.
+func (s *fastbootTCPSession) step(label string, command string) error {
+	s.begin(label)
+	_, err := s.conn.command(command, s.info)
+	return s.end(err)
+}
+
+func (s *fastbootTCPSession) getvar(name string) error {
+	value, err := s.conn.command("getvar:"+name, s.info)
+	if err != nil {
+		return err
+	}
+	// "getvar all" answers with INFO lines only.
+	if name != "all" {
+		s.emit(name + ": " + value + "\n")
+	}
+	return nil
+}
+
+// slotPartitions expands partition for --slot the way fastboot does: slotted
+// partitions get the suffix, "all" means every slot.
+func (s *fastbootTCPSession) slotPartitions(partition string, slot string) ([]string, error) {
+	if slot == "" {
+		return []string{partition}, nil
+	}
+	hasSlot, err := s.conn.command("getvar:has-slot:"+partition, func(string) {})
+	if err != nil || hasSlot != "yes" {
+		return []string{partition}, nil
+	}
+	if slot != "all" {
+		return []string{partition + "_" + strings.TrimPrefix(slot, "_")}, nil
+	}
+
+	count := int64(2)
+	if value, err := s.conn.command("getvar:slot-count", func(string) {}); err == nil {
+		if parsed := parseGetvarInt(value); parsed > 0 {
+			count = parsed
+		}
+	}
+	var partitions []string
+	for i := int64(0); i < count; i++ {
+		partitions = append(partitions, partition+"_"+string(rune('a'+i)))
+	}
+	return partitions, nil
+}
+
+func (s *fastbootTCPSession) flash(partition string, filePath string, vbmetaFlags uint32) error {
+	if err := s.send(partition, filePath, vbmetaFlags); err != nil {
+		return err
+	}
+	return s.step("Writing '"+partition+"'", "flash:"+partition)
+}
+
+// send downloads filePath, setting vbmetaFlags in its vbmeta header first
+// when given.
+func (s *fastbootTCPSession) send(label string, filePath string, vbmetaFlags uint32) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = file
+	if vbmetaFlags != 0 {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		if len(data) < avbFlagsOffset+4 || string(data[:4]) != "AVB0" {
+			return errors.New("--disable-verity needs a vbmeta image")
+		}
+		flags := binary.BigEndian.Uint32(data[avbFlagsOffset:])
+		binary.BigEndian.PutUint32(data[avbFlagsOffset:], flags|vbmetaFlags)
+		r = bytes.NewReader(data)
+	}
+
+	s.begin(fmt.Sprintf("Sending '%s' (%d KB)", label, stat.Size()/1024))
+	return s.end(s.conn.download(r, stat.Size(), s.info))
+}
@@ -0,0 +1,358 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFastboot is a fastboot device on a local TCP port: the FB01 handshake,
+// then 8-byte length framed commands and responses.
+type fakeFastboot struct {
+	t      *testing.T
+	serial string
+	vars   map[string]string
+	// single holds variables left out of getvar all but answered when
+	// asked for by name.
+	single map[string]string
+	// info lists INFO messages sent before the reply to a command.
+	info map[string][]string
+	// fail lists commands answered with FAIL and the message.
+	fail map[string]string
+	// hangUp lists commands the device drops the connection on, as an
+	// unplugged device would.
+	hangUp map[string]bool
+	// dataSize overrides the size the device accepts for a download.
+	dataSize int64
+
+	mu         sync.Mutex
+	commands   []string
+	downloaded []byte
+	flashed    map[string][]byte
+}
+
+func startFakeFastboot(t *testing.T, vars map[string]string) *fakeFastboot {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeFastboot{
+		t:       t,
+		serial:  fastbootTCPPrefix + listener.Addr().String(),
+		vars:    vars,
+		info:    map[string][]string{},
+		fail:    map[string]string{},
+		hangUp:  map[string]bool{},
+		flashed: map[string][]byte{},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeFastboot) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	hello := make([]byte, 4)
+	if _, err := io.ReadFull(conn, hello); err != nil || string(hello) != "FB01" {
+		f.t.Errorf("fastboot: handshake %q, %v", hello, err)
+		return
+	}
+	conn.Write([]byte("FB01"))
+
+	for {
+		packet, err := readFastbootPacket(conn)
+		if err != nil {
+			return
+		}
+		command := string(packet)
+		f.mu.Lock()
+		f.commands = append(f.commands, command)
+		f.mu.Unlock()
+
+		if f.hangUp[command] {
+			return
+		}
+		for _, message := range f.info[command] {
+			writeFastbootPacket(conn, "INFO"+message)
+		}
+		if message, ok := f.fail[command]; ok {
+			writeFastbootPacket(conn, "FAIL"+message)
+			continue
+		}
+		if err := f.handle(conn, command); err != nil {
+			f.t.Errorf("fastboot: %s: %v", command, err)
+			return
+		}
+	}
+}
+
+func (f *fakeFastboot) handle(conn net.Conn, command string) error {
+	name, arg, _ := strings.Cut(command, ":")
+	switch name {
+	case "getvar":
+		if arg == "all" {
+			keys := make([]string, 0, len(f.vars))
+			for key := range f.vars {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				writeFastbootPacket(conn, "INFO"+key+": "+f.vars[key])
+			}
+			return writeFastbootPacket(conn, "OKAY")
+		}
+		if value, ok := f.vars[arg]; ok {
+			return writeFastbootPacket(conn, "OKAY"+value)
+		}
+		if value, ok := f.single[arg]; ok {
+			return writeFastbootPacket(conn, "OKAY"+value)
+		}
+		return writeFastbootPacket(conn, "FAILunknown variable")
+
+	case "download":
+		size, err := strconv.ParseInt(arg, 16, 64)
+		if err != nil {
+			return err
+		}
+		accepted := size
+		if f.dataSize != 0 {
+			accepted = f.dataSize
+		}
+		if err := writeFastbootPacket(conn, fmt.Sprintf("DATA%08x", accepted)); err != nil {
+			return err
+		}
+		if accepted != size {
+			return nil
+		}
+		var data []byte
+		for int64(len(data)) < size {
+			packet, err := readFastbootPacket(conn)
+			if err != nil {
+				return err
+			}
+			data = append(data, packet...)
+		}
+		f.mu.Lock()
+		f.downloaded = data
+		f.mu.Unlock()
+		return writeFastbootPacket(conn, "OKAY")
+
+	case "flash":
+		f.mu.Lock()
+		f.flashed[arg] = f.downloaded
+		f.mu.Unlock()
+	}
+	return writeFastbootPacket(conn, "OKAY")
+}
+
+func (f *fakeFastboot) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func (f *fakeFastboot) image(partition string) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.flashed[partition]
+}
+
+func readFastbootPacket(r io.Reader) ([]byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	packet := make([]byte, binary.BigEndian.Uint64(header))
+	_, err := io.ReadFull(r, packet)
+	return packet, err
+}
+
+func writeFastbootPacket(w io.Writer, message string) error {
+	packet := make([]byte, 8+len(message))
+	binary.BigEndian.PutUint64(packet, uint64(len(message)))
+	copy(packet[8:], message)
+	_, err := w.Write(packet)
+	return err
+}
+
+func writeTempImage(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFastbootTCPGetvar(t *testing.T) {
+	device := startFakeFastboot(t, map[string]string{"product": "oriole", "slot-count": "2", "current-slot": "a"})
+	a := &App{}
+	ctx := testContext(t)
+
+	output, err := a.runFastbootTCP(ctx, device.serial, []string{"getvar", "product"}, nil)
+	if err != nil || output != "product: oriole" {
+		t.Fatalf("getvar product = %q, %v", output, err)
+	}
+	if value, err := a.fastbootGetvar(ctx, device.serial, "product"); err != nil || value != "oriole" {
+		t.Errorf("fastbootGetvar = %q, %v", value, err)
+	}
+
+	output, err = a.runFastbootTCP(ctx, device.serial, []string{"getvar", "all"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars := parseGetvarAll(output); vars["slot-count"] != "2" || vars["current-slot"] != "a" {
+		t.Errorf("getvar all parsed to %v from %q", vars, output)
+	}
+
+	_, err = a.runFastbootTCP(ctx, device.serial, []string{"getvar", "max-download-size"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "FAILED (remote: 'unknown variable')") {
+		t.Fatalf("unknown variable error = %v", err)
+	}
+	if !isUnknownVariable(err) {
+		t.Errorf("isUnknownVariable(%v) = false, want true", err)
+	}
+}
+
+func TestFastbootTCPFlash(t *testing.T) {
+	device := startFakeFastboot(t, map[string]string{"has-slot:boot": "yes", "slot-count": "2"})
+	device.info["flash:boot_a"] = []string{"Flashing boot_a"}
+	a := &App{}
+	ctx := testContext(t)
+
+	// Larger than one chunk, so the download spans several packets.
+	image := bytes.Repeat([]byte("0123456789abcdef"), fastbootChunkSize/8)
+	path := writeTempImage(t, "boot.img", image)
+
+	var streamed strings.Builder
+	output, err := a.runFastbootTCP(ctx, device.serial, []string{"--slot=all", "flash", "boot", path}, func(p []byte) { streamed.Write(p) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed.String() == "" || strings.TrimSpace(streamed.String()) != output {
+		t.Errorf("streamed output %q differs from %q", streamed.String(), output)
+	}
+
+	for _, partition := range []string{"boot_a", "boot_b"} {
+		if !bytes.Equal(device.image(partition), image) {
+			t.Errorf("%s got %d bytes, want the %d byte image", partition, len(device.image(partition)), len(image))
+		}
+		if !strings.Contains(output, "Writing '"+partition+"' OKAY") {
+			t.Errorf("output has no OKAY line for %s:\n%s", partition, output)
+		}
+	}
+	// The INFO line interrupts the step, which is then repeated with its OKAY.
+	if !strings.Contains(output, "(bootloader) Flashing boot_a\nWriting 'boot_a' OKAY") {
+		t.Errorf("INFO not framed as fastboot prints it:\n%s", output)
+	}
+	if !strings.Contains(output, fmt.Sprintf("Sending 'boot_b' (%d KB)", len(image)/1024)) {
+		t.Errorf("output has no Sending line:\n%s", output)
+	}
+}
+
+func TestFastbootTCPDownloadSizeMismatch(t *testing.T) {
+	device := startFakeFastboot(t, map[string]string{})
+	device.dataSize = 16
+	path := writeTempImage(t, "boot.img", make([]byte, 32))
+
+	_, err := (&App{}).runFastbootTCP(testContext(t), device.serial, []string{"flash", "boot", path}, nil)
+	if err == nil || !strings.Contains(err.Error(), "device accepted DATA00000010 for a 32 byte download") {
+		t.Fatalf("flash error = %v, want the size mismatch", err)
+	}
+	for _, command := range device.received() {
+		if strings.HasPrefix(command, "flash:") {
+			t.Errorf("sent %s after the download was refused", command)
+		}
+	}
+}
+
+func TestFastbootTCPFail(t *testing.T) {
+	device := startFakeFastboot(t, map[string]string{})
+	device.info["flash:abl"] = []string{"Checking image"}
+	device.fail["flash:abl"] = "Partition should be flashed in bootloader"
+	path := writeTempImage(t, "abl.img", []byte("abl"))
+
+	_, err := (&App{}).runFastbootTCP(testContext(t), device.serial, []string{"flash", "abl", path}, nil)
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("flash error = %v, want a CommandError", err)
+	}
+	for _, want := range []string{"(bootloader) Checking image", "FAILED (remote: 'Partition should be flashed in bootloader')"} {
+		if !strings.Contains(cmdErr.Stderr, want) {
+			t.Errorf("error output %q lacks %q", cmdErr.Stderr, want)
+		}
+	}
+}
+
+func TestFastbootTCPReboot(t *testing.T) {
+	device := startFakeFastboot(t, map[string]string{})
+	a := &App{}
+	ctx := testContext(t)
+
+	for _, args := range [][]string{{"reboot"}, {"reboot", "fastboot"}, {"reboot-bootloader"}, {"--set-active=b"}} {
+		if _, err := a.runFastbootTCP(ctx, device.serial, args, nil); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	want := []string{"reboot", "reboot-fastboot", "reboot-bootloader", "set_active:b"}
+	if got := device.received(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+
+	if _, err := a.runFastbootTCP(ctx, device.serial, []string{"-w"}, nil); err == nil || !strings.Contains(err.Error(), "not supported over TCP") {
+		t.Errorf("-w error = %v, want unsupported", err)
+	}
+}
+
+func TestFastbootTCPDisableVerity(t *testing.T) {
+	device := startFakeFastboot(t, map[string]string{})
+	a := &App{}
+	ctx := testContext(t)
+
+	vbmeta := make([]byte, 256)
+	copy(vbmeta, "AVB0")
+	binary.BigEndian.PutUint32(vbmeta[avbFlagsOffset:], 0)
+	path := writeTempImage(t, "vbmeta.img", vbmeta)
+
+	if _, err := a.runFastbootTCP(ctx, device.serial, []string{"--disable-verity", "--disable-verification", "flash", "vbmeta", path}, nil); err != nil {
+		t.Fatal(err)
+	}
+	flashed := device.image("vbmeta")
+	if len(flashed) != len(vbmeta) {
+		t.Fatalf("flashed %d bytes, want %d", len(flashed), len(vbmeta))
+	}
+	if flags := binary.BigEndian.Uint32(flashed[avbFlagsOffset:]); flags != avbFlagHashtreeDisabled|avbFlagVerificationDisabled {
+		t.Errorf("flags = %d, want %d", flags, avbFlagHashtreeDisabled|avbFlagVerificationDisabled)
+	}
+	if original, _ := os.ReadFile(path); !bytes.Equal(original, vbmeta) {
+		t.Error("the image on disk was modified")
+	}
+
+	notVbmeta := writeTempImage(t, "boot.img", make([]byte, 256))
+	if _, err := a.runFastbootTCP(ctx, device.serial, []string{"--disable-verity", "flash", "vbmeta", notVbmeta}, nil); err == nil || !strings.Contains(err.Error(), "needs a vbmeta image") {
+		t.Errorf("non-vbmeta error = %v", err)
+	}
+}
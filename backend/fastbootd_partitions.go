@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Logical partition names as liblp accepts them.
+var logicalPartitionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,35}$`)
+
+type PartitionLayout struct {
+	// IsUserspace is true in fastbootd, the only mode that can change
+	// logical partitions.
+	IsUserspace bool
+	Logical     []FastbootPartition
+	Physical    []FastbootPartition
+}
+
+// GetPartitionLayout splits the partitions reported by getvar all into
+// logical ones (inside super) and physical ones. The bootloader reports
+// only physical partitions; fastbootd reports both.
+func (a *App) GetPartitionLayout(serial string) (PartitionLayout, error) {
+	info, err := a.GetFastbootInfo(serial)
+	if err != nil {
+		return PartitionLayout{}, err
+	}
+
+	layout := PartitionLayout{
+		IsUserspace: info.IsUserspace,
+		Logical:     []FastbootPartition{},
+		Physical:    []FastbootPartition{},
+	}
+	for _, partition := range info.Partitions {
+		if partition.IsLogical {
+			layout.Logical = append(layout.Logical, partition)
+		} else {
+			layout.Physical = append(layout.Physical, partition)
+		}
+	}
+	return layout, nil
+}
+
+// CreateLogicalPartition adds an empty logical partition of size bytes to
+// super. Only fastbootd can do this.
+func (a *App) CreateLogicalPartition(serial string, name string, size int64) error {
+	if size <= 0 {
+		return fmt.Errorf("partition size must be greater than zero")
+	}
+	return a.runLogicalPartitionCommand(serial, "create-logical-partition", name, strconv.FormatInt(size, 10))
+}
+
+// DeleteLogicalPartition removes a logical partition from super.
+func (a *App) DeleteLogicalPartition(serial string, name string) error {
+	return a.runLogicalPartitionCommand(serial, "delete-logical-partition", name)
+}
+
+// ResizeLogicalPartition grows or shrinks a logical partition to size
+// bytes. Shrinking discards whatever lies past the new end.
+func (a *App) ResizeLogicalPartition(serial string, name string, size int64) error {
+	if size <= 0 {
+		return fmt.Errorf("partition size must be greater than zero")
+	}
+	return a.runLogicalPartitionCommand(serial, "resize-logical-partition", name, strconv.FormatInt(size, 10))
+}
+
+// RebootToFastbootd reboots into userspace fastboot and waits for the device
+// to come back.
+func (a *App) RebootToFastbootd(serial string) error {
+	ctx, cancel := withCommandTimeout(context.Background(), fastbootRebootTimeout)
+	defer cancel()
+
+	serial, err := a.resolveFastbootSerial(serial)
+	if err != nil {
+		return err
+	}
+	if _, err := a.runFastboot(ctx, serial, "reboot", "fastboot"); err != nil {
+		return fmt.Errorf("failed to reboot to fastbootd: %w", err)
+	}
+	select {
+	case <-time.After(3 * time.Second):
+	case <-ctx.Done():
+		return contextError(ctx, "fastboot reboot fastboot")
+	}
+	return a.waitForFastbootDevice(ctx, serial, fastbootRebootTimeout)
+}
+
+// runLogicalPartitionCommand runs command for name, followed by args, after
+// checking the device is in fastbootd.
+func (a *App) runLogicalPartitionCommand(serial string, command string, name string, args ...string) error {
+	if !logicalPartitionNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid partition name %q", name)
+	}
+
+	ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+
+	if err := a.requireFastbootd(ctx, serial); err != nil {
+		return err
+	}
+
+	if _, err := a.runFastboot(ctx, serial, append([]string{command, name}, args...)...); err != nil {
+		return fmt.Errorf("failed to run fastboot %s: %w", command, err)
+	}
+	return nil
+}
+
+// requireFastbootd refuses logical partition changes in bootloader fastboot,
+// which does not understand super and would reject or misreport them.
+func (a *App) requireFastbootd(ctx context.Context, serial string) error {
+	value, err := a.fastbootGetvar(ctx, serial, "is-userspace")
+	if err != nil {
+		// Bootloaders that predate fastbootd reject the variable outright.
+		if isUnknownVariable(err) {
+			return ErrNotFastbootd
+		}
+		return err
+	}
+	if value != "yes" {
+		return ErrNotFastbootd
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLogicalPartitionCommands(t *testing.T) {
+	fastbootd := map[string]string{"is-userspace": "yes"}
+
+	tests := []struct {
+		name   string
+		vars   map[string]string
+		hangUp string
+		run    func(a *App, serial string) error
+		// want is the command sent after the is-userspace check, if any.
+		want    string
+		wantErr error
+	}{
+		{
+			name: "create",
+			vars: fastbootd,
+			run:  func(a *App, serial string) error { return a.CreateLogicalPartition(serial, "product_a", 4096) },
+			want: "create-logical-partition:product_a:4096",
+		},
+		{
+			name: "resize",
+			vars: fastbootd,
+			run:  func(a *App, serial string) error { return a.ResizeLogicalPartition(serial, "product_a", 8192) },
+			want: "resize-logical-partition:product_a:8192",
+		},
+		{
+			name: "delete",
+			vars: fastbootd,
+			run:  func(a *App, serial string) error { return a.DeleteLogicalPartition(serial, "product_a") },
+			want: "delete-logical-partition:product_a",
+		},
+		{
+			name: "create without a size",
+			vars: fastbootd,
+			run:  func(a *App, serial string) error { return a.CreateLogicalPartition(serial, "product_a", 0) },
+		},
+		{
+			name: "resize to the delete marker",
+			vars: fastbootd,
+			run:  func(a *App, serial string) error { return a.ResizeLogicalPartition(serial, "product_a", -1) },
+		},
+		{
+			name: "invalid name",
+			vars: fastbootd,
+			run:  func(a *App, serial string) error { return a.DeleteLogicalPartition(serial, "product a") },
+		},
+		{
+			name:    "bootloader",
+			vars:    map[string]string{"is-userspace": "no"},
+			run:     func(a *App, serial string) error { return a.DeleteLogicalPartition(serial, "product_a") },
+			wantErr: ErrNotFastbootd,
+		},
+		{
+			name:    "bootloader without is-userspace",
+			vars:    map[string]string{},
+			run:     func(a *App, serial string) error { return a.DeleteLogicalPartition(serial, "product_a") },
+			wantErr: ErrNotFastbootd,
+		},
+		{
+			name:   "device lost",
+			vars:   fastbootd,
+			hangUp: "getvar:is-userspace",
+			run:    func(a *App, serial string) error { return a.DeleteLogicalPartition(serial, "product_a") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := startFakeFastboot(t, tt.vars)
+			if tt.hangUp != "" {
+				device.hangUp[tt.hangUp] = true
+			}
+
+			err := tt.run(&App{}, device.serial)
+			var sent []string
+			for _, command := range device.received() {
+				if command != "getvar:is-userspace" {
+					sent = append(sent, command)
+				}
+			}
+			if tt.want == "" {
+				if err == nil {
+					t.Fatal("succeeded, want an error")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr == nil && errors.Is(err, ErrNotFastbootd) {
+					t.Errorf("err = %v, want the real failure rather than ErrNotFastbootd", err)
+				}
+				if len(sent) != 0 {
+					t.Errorf("sent %q, want nothing", sent)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sent, []string{tt.want}) {
+				t.Errorf("sent %q, want %q", sent, tt.want)
+			}
+		})
+	}
+}
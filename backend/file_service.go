@@ -2,17 +2,19 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
-	"time"
 )
 
 func (a *App) ListFiles(path string) ([]FileEntry, error) {
 
 	
 	// List files uses default timeout (60s) which is sufficient
-	output, err := a.runCommand("adb", "shell", "ls", "-lA", path)
+	output, err := a.runCommand("adb", "shell", shellJoin("ls", "-lA", path))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list files: %w. Output: %s", err, output)
 	}
@@ -100,26 +102,24 @@ func (a *App) ListFiles(path string) ([]FileEntry, error) {
 }
 
 func (a *App) PushFile(localPath string, remotePath string) (string, error) {
+	serial, err := a.resolveAdbSerial("")
+	if err != nil {
+		return "", err
+	}
+	job := a.jobs.startOn(nil, serial, "push", fmt.Sprintf("Push %s", filepath.Base(localPath)), 0)
+	output, err := a.pushFile(job.ctx, job.serial, localPath, remotePath)
+	job.finish(output, err)
+	return output, err
+}
 
-	
-	a.opMutex.Lock()
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
-	a.currentCancel = cancel
-	a.opMutex.Unlock()
-
-	defer func() {
-		cancel()
-		a.opMutex.Lock()
-		if a.currentCancel != nil {
-			a.currentCancel = nil
-		}
-		a.opMutex.Unlock()
-	}()
-
-	output, err := a.runCommandContext(ctx, "adb", "push", localPath, remotePath)
+func (a *App) pushFile(ctx context.Context, serial string, localPath string, remotePath string) (string, error) {
+	output, err := a.syncPush(ctx, serial, localPath, remotePath)
+	if errors.Is(err, errAdbServerUnavailable) {
+		output, err = a.runCommandContext(ctx, "adb", serialArgs(serial, "push", localPath, remotePath)...)
+	}
 	if err != nil {
 		if ctx.Err() == context.Canceled {
-			return "", fmt.Errorf("push cancelled by user")
+			return "", newCancelledError("push", "push cancelled by user")
 		}
 		return "", fmt.Errorf("failed to push file: %w. Output: %s", err, output)
 	}
@@ -127,27 +127,25 @@ func (a *App) PushFile(localPath string, remotePath string) (string, error) {
 }
 
 func (a *App) PullFile(remotePath string, localPath string) (string, error) {
-
-	
-	a.opMutex.Lock()
+	serial, err := a.resolveAdbSerial("")
+	if err != nil {
+		return "", err
+	}
 	// No timeout for file transfers, only user cancellation
-	ctx, cancel := context.WithCancel(context.Background())
-	a.currentCancel = cancel
-	a.opMutex.Unlock()
-	
-	defer func() {
-		cancel()
-		a.opMutex.Lock()
-		if a.currentCancel != nil {
-			a.currentCancel = nil
-		}
-		a.opMutex.Unlock()
-	}()
+	job := a.jobs.startOn(nil, serial, "pull", fmt.Sprintf("Pull %s", path.Base(remotePath)), 0)
+	output, err := a.pullFile(job.ctx, job.serial, remotePath, localPath)
+	job.finish(output, err)
+	return output, err
+}
 
-	output, err := a.runCommandContext(ctx, "adb", "pull", "-a", remotePath, localPath)
+func (a *App) pullFile(ctx context.Context, serial string, remotePath string, localPath string) (string, error) {
+	output, err := a.syncPull(ctx, serial, remotePath, localPath)
+	if errors.Is(err, errAdbServerUnavailable) {
+		output, err = a.runCommandContext(ctx, "adb", serialArgs(serial, "pull", "-a", remotePath, localPath)...)
+	}
 	if err != nil {
 		if ctx.Err() == context.Canceled {
-			return "", fmt.Errorf("pull cancelled by user")
+			return "", newCancelledError("pull", "pull cancelled by user")
 		}
 		return "", fmt.Errorf("failed to pull file: %w. Output: %s", err, output)
 	}
@@ -155,7 +153,7 @@ func (a *App) PullFile(remotePath string, localPath string) (string, error) {
 }
 
 func (a *App) CreateFolder(fullPath string) (string, error) {
-	command := fmt.Sprintf("mkdir -p '%s'", fullPath)
+	command := shellJoin("mkdir", "-p", fullPath)
 
 	output, err := a.runShellCommand(command)
 	if err != nil {
@@ -166,7 +164,7 @@ func (a *App) CreateFolder(fullPath string) (string, error) {
 }
 
 func (a *App) DeleteFile(fullPath string) (string, error) {
-	command := fmt.Sprintf("rm -rf '%s'", fullPath)
+	command := shellJoin("rm", "-rf", fullPath)
 
 	output, err := a.runShellCommand(command)
 	if err != nil {
@@ -177,7 +175,7 @@ func (a *App) DeleteFile(fullPath string) (string, error) {
 }
 
 func (a *App) RenameFile(oldPath string, newPath string) (string, error) {
-	command := fmt.Sprintf("mv '%s' '%s'", oldPath, newPath)
+	command := shellJoin("mv", oldPath, newPath)
 
 	output, err := a.runShellCommand(command)
 	if err != nil {
@@ -219,6 +217,11 @@ func (a *App) PullMultipleFiles(remotePaths []string) (string, error) {
 		return "", fmt.Errorf("no files selected to export")
 	}
 
+	serial, err := a.resolveAdbSerial("")
+	if err != nil {
+		return "", err
+	}
+
 	localSaveFolder, err := a.SelectDirectoryForPull()
 	if err != nil {
 		return "", fmt.Errorf("failed to open folder dialog: %w", err)
@@ -232,32 +235,27 @@ func (a *App) PullMultipleFiles(remotePaths []string) (string, error) {
 	var failCount int
 	var errorMessages strings.Builder
 
-	// Batch pull: each file pull is discrete. We could potentially make the whole batch cancellable,
-	// but currently only individual calls to PullFile are cancellable via the App.currentCancel mechanism.
-	// Since PullFile implements the lock/cancel mechanism internally, cancelling *during* one file
-	// will stop that file. 
-	// To cancel the whole batch, the user would hit "Cancel" which triggers App.CancelOperation().
-	// That would kill the *current* PullFile command.
-	// The loop here continues. Ideally we should check if cancellation happened.
-	// But `a.currentCancel` is reset after each PullFile.
-	// So repeatedly clicking cancel would be needed. 
-	// For now, this is acceptable for basic batch support.
-
-	for _, remotePath := range remotePaths {
-		_, err := a.PullFile(remotePath, localSaveFolder)
+	// The batch owns one child job per file, so cancelling the batch job
+	// stops the running pull and skips the rest.
+	batch := a.jobs.startOn(nil, serial, "batch-pull", fmt.Sprintf("Export %d items", len(remotePaths)), 0)
+
+	for i, remotePath := range remotePaths {
+		if batch.ctx.Err() != nil {
+			failCount += len(remotePaths) - i
+			errorMessages.WriteString(fmt.Sprintf("Cancelled %d remaining items\n", len(remotePaths)-i))
+			break
+		}
+
+		job := a.jobs.start(batch, "pull", fmt.Sprintf("Pull %s", path.Base(remotePath)), 0)
+		output, err := a.pullFile(job.ctx, job.serial, remotePath, localSaveFolder)
+		job.finish(output, err)
 		if err != nil {
-			if strings.Contains(err.Error(), "cancelled by user") {
-				// If one is cancelled, we might stop the batch?
-				// Yes, assuming user wants to stop everything.
-				failCount++
-				errorMessages.WriteString(fmt.Sprintf("Cancelled %s\n", remotePath))
-				break 
-			}
 			failCount++
 			errorMessages.WriteString(fmt.Sprintf("Failed %s: %v\n", remotePath, err))
 		} else {
 			successCount++
 		}
+		batch.setProgress(float64(i+1) / float64(len(remotePaths)) * 100)
 	}
 
 	summary := fmt.Sprintf("Successfully exported %d items to %s.", successCount, localSaveFolder)
@@ -265,5 +263,10 @@ func (a *App) PullMultipleFiles(remotePaths []string) (string, error) {
 		summary += fmt.Sprintf(" Failed to export %d items.\nDetails:\n%s", failCount, errorMessages.String())
 	}
 
+	if batch.ctx.Err() == context.Canceled {
+		batch.finish(summary, newCancelledError("pull", "export cancelled by user"))
+	} else {
+		batch.finish(summary, nil)
+	}
 	return summary, nil
 }
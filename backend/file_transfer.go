@@ -0,0 +1,430 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	EventTransferProgress      = "transfer:progress"
+	EventTransferFileStarted   = "transfer:file-started"
+	EventTransferFileCompleted = "transfer:file-completed"
+
+	transferProgressInterval = 250 * time.Millisecond
+)
+
+type TransferProgress struct {
+	Operation      string
+	CurrentFile    string
+	FileBytesDone  int64
+	FileBytesTotal int64
+	BytesDone      int64
+	BytesTotal     int64
+	FilesDone      int
+	FilesTotal     int
+	BytesPerSecond float64
+	ETASeconds     float64
+}
+
+type TransferFileEvent struct {
+	Operation   string
+	Source      string
+	Destination string
+	Size        int64
+	Error       string
+}
+
+// transferFile is one regular file of a push or pull plan.
+type transferFile struct {
+	source      string
+	destination string
+	size        int64
+	mode        uint32
+	mtime       int64
+}
+
+type transferPlan struct {
+	files []transferFile
+	dirs  []string
+}
+
+func (p transferPlan) totalBytes() int64 {
+	var total int64
+	for _, file := range p.files {
+		total += file.size
+	}
+	return total
+}
+
+// transferTracker turns chunk callbacks into throttled progress events.
+type transferTracker struct {
+	app       *App
+	job       *jobHandle
+	operation string
+	started   time.Time
+
+	mu       sync.Mutex
+	progress TransferProgress
+	lastEmit time.Time
+}
+
+func newTransferTracker(ctx context.Context, app *App, operation string, plan transferPlan) *transferTracker {
+	return &transferTracker{
+		app:       app,
+		job:       jobFromContext(ctx),
+		operation: operation,
+		started:   time.Now(),
+		progress: TransferProgress{
+			Operation:  operation,
+			BytesTotal: plan.totalBytes(),
+			FilesTotal: len(plan.files),
+		},
+	}
+}
+
+func (t *transferTracker) startFile(file transferFile) {
+	t.mu.Lock()
+	t.progress.CurrentFile = file.source
+	t.progress.FileBytesDone = 0
+	t.progress.FileBytesTotal = file.size
+	t.mu.Unlock()
+
+	t.emit(EventTransferFileStarted, TransferFileEvent{
+		Operation:   t.operation,
+		Source:      file.source,
+		Destination: file.destination,
+		Size:        file.size,
+	})
+	t.emitProgress(true)
+}
+
+func (t *transferTracker) finishFile(file transferFile, err error) {
+	event := TransferFileEvent{
+		Operation:   t.operation,
+		Source:      file.source,
+		Destination: file.destination,
+		Size:        file.size,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	} else {
+		t.mu.Lock()
+		t.progress.FilesDone++
+		t.mu.Unlock()
+	}
+
+	t.emit(EventTransferFileCompleted, event)
+	t.emitProgress(true)
+}
+
+func (t *transferTracker) add(n int) {
+	t.mu.Lock()
+	t.progress.FileBytesDone += int64(n)
+	t.progress.BytesDone += int64(n)
+	t.mu.Unlock()
+	t.emitProgress(false)
+}
+
+func (t *transferTracker) emitProgress(force bool) {
+	t.mu.Lock()
+	now := time.Now()
+	if !force && now.Sub(t.lastEmit) < transferProgressInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastEmit = now
+
+	elapsed := now.Sub(t.started).Seconds()
+	if elapsed > 0 {
+		t.progress.BytesPerSecond = float64(t.progress.BytesDone) / elapsed
+	}
+	t.progress.ETASeconds = 0
+	if t.progress.BytesPerSecond > 0 {
+		t.progress.ETASeconds = float64(t.progress.BytesTotal-t.progress.BytesDone) / t.progress.BytesPerSecond
+	}
+	progress := t.progress
+	t.mu.Unlock()
+
+	t.emit(EventTransferProgress, progress)
+	if t.job != nil && progress.BytesTotal > 0 {
+		t.job.setProgress(float64(progress.BytesDone) / float64(progress.BytesTotal) * 100)
+	}
+}
+
+func (t *transferTracker) emit(name string, payload interface{}) {
+	if t.app.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(t.app.ctx, name, payload)
+}
+
+// planPush mirrors `adb push`: pushing into an existing remote directory
+// places the source inside it, directories are copied recursively.
+func planPush(sc *syncConn, localPath string, remotePath string) (transferPlan, error) {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return transferPlan{}, err
+	}
+
+	remoteStat, err := sc.Stat(remotePath)
+	if err != nil {
+		return transferPlan{}, err
+	}
+	if remoteStat.IsDir() {
+		remotePath = path.Join(remotePath, filepath.Base(localPath))
+	}
+
+	var plan transferPlan
+	if !info.IsDir() {
+		plan.files = append(plan.files, newPushFile(localPath, remotePath, info))
+		return plan, nil
+	}
+
+	err = filepath.WalkDir(localPath, func(current string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		rel, err := filepath.Rel(localPath, current)
+		if err != nil {
+			return err
+		}
+		destination := path.Join(remotePath, filepath.ToSlash(rel))
+
+		if entry.IsDir() {
+			plan.dirs = append(plan.dirs, destination)
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		plan.files = append(plan.files, newPushFile(current, destination, info))
+		return nil
+	})
+	if err != nil {
+		return transferPlan{}, err
+	}
+
+	// SEND creates parent directories itself, only empty ones need a mkdir.
+	populated := make(map[string]bool)
+	for _, file := range plan.files {
+		for dir := path.Dir(file.destination); !populated[dir] && dir != "/" && dir != "."; dir = path.Dir(dir) {
+			populated[dir] = true
+		}
+	}
+	var emptyDirs []string
+	for _, dir := range plan.dirs {
+		if !populated[dir] {
+			emptyDirs = append(emptyDirs, dir)
+		}
+	}
+	plan.dirs = emptyDirs
+	return plan, nil
+}
+
+func newPushFile(localPath string, remotePath string, info fs.FileInfo) transferFile {
+	return transferFile{
+		source:      localPath,
+		destination: remotePath,
+		size:        info.Size(),
+		mode:        uint32(info.Mode().Perm()),
+		mtime:       info.ModTime().Unix(),
+	}
+}
+
+// planPull mirrors `adb pull`: pulling into an existing local directory
+// places the source inside it, remote directories are copied recursively.
+func planPull(sc *syncConn, remotePath string, localPath string) (transferPlan, error) {
+	remoteStat, err := sc.Stat(remotePath)
+	if err != nil {
+		return transferPlan{}, err
+	}
+	if !remoteStat.Exists() {
+		return transferPlan{}, fmt.Errorf("remote object '%s' does not exist", remotePath)
+	}
+
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		localPath = filepath.Join(localPath, path.Base(remotePath))
+	}
+
+	var plan transferPlan
+	if !remoteStat.IsDir() {
+		plan.files = append(plan.files, newPullFile(remotePath, localPath, remoteStat))
+		return plan, nil
+	}
+
+	err = walkRemote(sc, remotePath, localPath, &plan)
+	return plan, err
+}
+
+func walkRemote(sc *syncConn, remoteDir string, localDir string, plan *transferPlan) error {
+	plan.dirs = append(plan.dirs, localDir)
+
+	entries, err := sc.List(remoteDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		remote := path.Join(remoteDir, entry.Name)
+		local := filepath.Join(localDir, entry.Name)
+
+		st := entry.syncStat
+		if st.IsSymlink() {
+			// Follow links the way adb pull does; broken links are skipped.
+			if st, err = sc.Stat(remote); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case st.IsDir():
+			if err := walkRemote(sc, remote, local, plan); err != nil {
+				return err
+			}
+		case st.IsRegular():
+			plan.files = append(plan.files, newPullFile(remote, local, st))
+		}
+	}
+	return nil
+}
+
+func newPullFile(remotePath string, localPath string, st syncStat) transferFile {
+	return transferFile{
+		source:      remotePath,
+		destination: localPath,
+		size:        int64(st.Size),
+		mode:        st.Mode & 0777,
+		mtime:       st.Mtime,
+	}
+}
+
+// syncPush uploads localPath with the sync protocol, emitting progress events.
+// On failure or cancellation the partially written remote file is removed.
+func (a *App) syncPush(ctx context.Context, serial string, localPath string, remotePath string) (string, error) {
+	sc, err := a.adb.openSync(ctx, serial)
+	if err != nil {
+		if errors.Is(err, errAdbServerUnavailable) || ctx.Err() != nil {
+			return "", err
+		}
+		return "", newCommandError(serial, "adb sync", -1, err.Error())
+	}
+	defer sc.Close()
+	defer watchContext(ctx, sc.conn)()
+
+	plan, err := planPush(sc, localPath, remotePath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range plan.dirs {
+		if _, err := a.runShellCommandOn(serial, shellJoin("mkdir", "-p", dir)); err != nil {
+			return "", err
+		}
+	}
+
+	tracker := newTransferTracker(ctx, a, "push", plan)
+	for _, file := range plan.files {
+		tracker.startFile(file)
+		err := pushOne(sc, file, tracker)
+		tracker.finishFile(file, err)
+		if err != nil {
+			a.removeRemotePartial(serial, file.destination)
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%d files pushed, %d bytes", len(plan.files), plan.totalBytes()), nil
+}
+
+func pushOne(sc *syncConn, file transferFile, tracker *transferTracker) error {
+	f, err := os.Open(file.source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return sc.Send(file.destination, file.mode, file.mtime, f, tracker.add)
+}
+
+func (a *App) removeRemotePartial(serial string, remotePath string) {
+	_, _ = a.runShellCommandOn(serial, shellJoin("rm", "-f", remotePath))
+}
+
+// syncPull downloads remotePath with the sync protocol, emitting progress events.
+// Timestamps and permissions are preserved like `adb pull -a`. On failure or
+// cancellation the partially written local file is removed.
+func (a *App) syncPull(ctx context.Context, serial string, remotePath string, localPath string) (string, error) {
+	sc, err := a.adb.openSync(ctx, serial)
+	if err != nil {
+		if errors.Is(err, errAdbServerUnavailable) || ctx.Err() != nil {
+			return "", err
+		}
+		return "", newCommandError(serial, "adb sync", -1, err.Error())
+	}
+	defer sc.Close()
+	defer watchContext(ctx, sc.conn)()
+
+	plan, err := planPull(sc, remotePath, localPath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range plan.dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	tracker := newTransferTracker(ctx, a, "pull", plan)
+	for _, file := range plan.files {
+		tracker.startFile(file)
+		err := pullOne(sc, file, tracker)
+		tracker.finishFile(file, err)
+		if err != nil {
+			os.Remove(file.destination)
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%d files pulled, %d bytes", len(plan.files), plan.totalBytes()), nil
+}
+
+func pullOne(sc *syncConn, file transferFile, tracker *transferTracker) error {
+	if err := os.MkdirAll(filepath.Dir(file.destination), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file.destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := sc.Recv(file.source, f, tracker.add); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if file.mode != 0 {
+		_ = os.Chmod(file.destination, fs.FileMode(file.mode))
+	}
+	mtime := time.Unix(file.mtime, 0)
+	_ = os.Chtimes(file.destination, mtime, mtime)
+	return nil
+}
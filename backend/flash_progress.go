@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const EventFlashProgress = "flash:progress"
+
+// Sending is followed by OKAY on the same line once the data is on the
+// device; Writing likewise once it is flashed.
+var (
+	fastbootSendingPattern  = regexp.MustCompile(`^Sending(?: sparse)? '([^']+)'(?: (\d+)/(\d+))? \((\d+) KB\)`)
+	fastbootWritingPattern  = regexp.MustCompile(`^Writing '([^']+)'`)
+	sideloadProgressPattern = regexp.MustCompile(`\(~(\d+)%\)`)
+)
+
+// Share of a piece's weight counted when its upload finishes; the rest is
+// counted once the device has written it.
+const fastbootSendWeight = 0.7
+
+// FlashProgress is emitted while a flash or sideload job runs. Stage is
+// the step the tool is on, such as "Sending 'super' 2/5 (786428 KB)".
+type FlashProgress struct {
+	JobID   string
+	Percent float64
+	Stage   string
+}
+
+// lineSplitter splits streamed output on \n and \r, the latter being how adb
+// redraws its progress line. fn sees finished lines and, after each chunk,
+// the unfinished tail, since fastboot prints a step and only ends the line
+// when the step completes.
+type lineSplitter struct {
+	partial []byte
+}
+
+func (s *lineSplitter) feed(chunk []byte, fn func(line string, complete bool)) {
+	for _, b := range chunk {
+		if b == '\n' || b == '\r' {
+			if len(s.partial) > 0 {
+				fn(strings.TrimSpace(string(s.partial)), true)
+			}
+			s.partial = s.partial[:0]
+			continue
+		}
+		s.partial = append(s.partial, b)
+	}
+	if len(s.partial) > 0 {
+		fn(strings.TrimSpace(string(s.partial)), false)
+	}
+}
+
+// fastbootProgress estimates flash progress from fastboot's step lines,
+// weighting each piece by its size against totalKB.
+type fastbootProgress struct {
+	lines   lineSplitter
+	totalKB float64
+	doneKB  float64
+	pieceKB float64
+}
+
+// feed consumes output and returns the current percentage and stage.
+func (p *fastbootProgress) feed(chunk []byte) (float64, string) {
+	stage := ""
+	p.lines.feed(chunk, func(line string, complete bool) {
+		step, _, _ := strings.Cut(line, "OKAY")
+		step = strings.TrimSpace(step)
+
+		if match := fastbootSendingPattern.FindStringSubmatch(line); match != nil {
+			kb, _ := strconv.ParseFloat(match[4], 64)
+			p.pieceKB = kb
+			stage = step
+			if complete && strings.Contains(line, "OKAY") {
+				p.doneKB += kb * fastbootSendWeight
+			}
+			return
+		}
+		if fastbootWritingPattern.MatchString(line) {
+			stage = step
+			if complete && strings.Contains(line, "OKAY") {
+				p.doneKB += p.pieceKB * (1 - fastbootSendWeight)
+			}
+		}
+	})
+
+	if p.totalKB <= 0 {
+		return 0, stage
+	}
+	// Kept under 100 until the command exits, as the estimate can overshoot.
+	return math.Min(99, p.doneKB/p.totalKB*100), stage
+}
+
+// parseSideloadProgress reads the last "serving: 'x'  (~NN%)" percentage in
+// line, or -1 when there is none.
+func parseSideloadProgress(line string) float64 {
+	matches := sideloadProgressPattern.FindAllStringSubmatch(line, -1)
+	if len(matches) == 0 {
+		return -1
+	}
+	percent, _ := strconv.ParseFloat(matches[len(matches)-1][1], 64)
+	return percent
+}
+
+// flashReporter forwards progress to the job and the flash event,
+// skipping updates that change neither the whole percentage nor the stage.
+type flashReporter struct {
+	app     *App
+	job     *jobHandle
+	percent float64
+	stage   string
+}
+
+func (r *flashReporter) report(percent float64, stage string) {
+	if stage == "" {
+		stage = r.stage
+	}
+	if math.Floor(percent) == math.Floor(r.percent) && stage == r.stage {
+		return
+	}
+	if percent > r.percent {
+		r.percent = percent
+		r.job.setProgress(percent)
+	}
+	r.stage = stage
+
+	if r.app.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(r.app.ctx, EventFlashProgress, FlashProgress{JobID: r.job.id, Percent: r.percent, Stage: stage})
+}
@@ -0,0 +1,271 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type JobStatus string
+
+const (
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+const (
+	EventJobStarted  = "job:started"
+	EventJobUpdated  = "job:updated"
+	EventJobFinished = "job:finished"
+
+	// Finished jobs beyond this count are dropped, oldest first.
+	maxFinishedJobs = 200
+)
+
+type Job struct {
+	ID          string
+	ParentID    string
+	Kind        string
+	Description string
+	Status      JobStatus
+	Progress    float64
+	StartedAt   time.Time
+	EndedAt     time.Time
+	Result      string
+	Error       string
+	Children    []string
+}
+
+type jobEntry struct {
+	job    Job
+	cancel context.CancelFunc
+}
+
+// jobManager tracks every long-running operation so each can be listed and
+// cancelled on its own. Child jobs derive their context from the parent, so
+// cancelling a batch stops whatever child is running.
+type jobManager struct {
+	app *App
+
+	mu     sync.Mutex
+	nextID int
+	jobs   map[string]*jobEntry
+	order  []string
+}
+
+func newJobManager(app *App) *jobManager {
+	return &jobManager{
+		app:  app,
+		jobs: make(map[string]*jobEntry),
+	}
+}
+
+// jobHandle is what an operation uses to report on its own job.
+type jobHandle struct {
+	id  string
+	ctx context.Context
+	// serial is the device the job runs on, resolved once when it starts so
+	// changing the target device mid-job cannot split it across devices.
+	serial  string
+	manager *jobManager
+}
+
+type jobContextKey struct{}
+
+// jobFromContext returns the job running under ctx, if any.
+func jobFromContext(ctx context.Context) *jobHandle {
+	job, _ := ctx.Value(jobContextKey{}).(*jobHandle)
+	return job
+}
+
+// start registers a running job. A zero timeout means the job only ends by
+// completing or being cancelled. Child jobs run on their parent's device.
+func (m *jobManager) start(parent *jobHandle, kind string, description string, timeout time.Duration) *jobHandle {
+	serial := ""
+	if parent != nil {
+		serial = parent.serial
+	}
+	return m.startOn(parent, serial, kind, description, timeout)
+}
+
+// startOn is start for a job that talks to the device serial.
+func (m *jobManager) startOn(parent *jobHandle, serial string, kind string, description string, timeout time.Duration) *jobHandle {
+	base := context.Background()
+	parentID := ""
+	if parent != nil {
+		base = parent.ctx
+		parentID = parent.id
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = withCommandTimeout(base, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(base)
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	id := fmt.Sprintf("job-%d", m.nextID)
+	entry := &jobEntry{
+		job: Job{
+			ID:          id,
+			ParentID:    parentID,
+			Kind:        kind,
+			Description: description,
+			Status:      JobStatusRunning,
+			StartedAt:   time.Now(),
+		},
+		cancel: cancel,
+	}
+	m.jobs[id] = entry
+	m.order = append(m.order, id)
+	if parentEntry, ok := m.jobs[parentID]; ok {
+		parentEntry.job.Children = append(parentEntry.job.Children, id)
+	}
+	job := entry.job
+	m.mu.Unlock()
+
+	handle := &jobHandle{id: id, serial: serial, manager: m}
+	handle.ctx = context.WithValue(ctx, jobContextKey{}, handle)
+
+	m.emit(EventJobStarted, job)
+	return handle
+}
+
+func (h *jobHandle) setProgress(percent float64) {
+	m := h.manager
+	m.mu.Lock()
+	entry, ok := m.jobs[h.id]
+	if !ok || entry.job.Status != JobStatusRunning {
+		m.mu.Unlock()
+		return
+	}
+	entry.job.Progress = percent
+	job := entry.job
+	m.mu.Unlock()
+
+	m.emit(EventJobUpdated, job)
+}
+
+// finish records the outcome and releases the job's context.
+func (h *jobHandle) finish(result string, err error) {
+	m := h.manager
+	m.mu.Lock()
+	entry, ok := m.jobs[h.id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+
+	// The status is decided before the context is released, since releasing
+	// it cancels the context too.
+	entry.job.EndedAt = time.Now()
+	entry.job.Result = result
+	switch {
+	case err == nil:
+		entry.job.Status = JobStatusSucceeded
+		entry.job.Progress = 100
+	case h.ctx.Err() == context.Canceled:
+		entry.job.Status = JobStatusCancelled
+		entry.job.Error = err.Error()
+	default:
+		entry.job.Status = JobStatusFailed
+		entry.job.Error = err.Error()
+	}
+	entry.cancel()
+	job := entry.job
+	m.pruneLocked()
+	m.mu.Unlock()
+
+	m.emit(EventJobFinished, job)
+}
+
+func (m *jobManager) pruneLocked() {
+	finished := 0
+	for _, id := range m.order {
+		if m.jobs[id].job.Status != JobStatusRunning {
+			finished++
+		}
+	}
+
+	kept := m.order[:0]
+	for _, id := range m.order {
+		if finished > maxFinishedJobs && m.jobs[id].job.Status != JobStatusRunning {
+			delete(m.jobs, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	m.order = kept
+}
+
+func (m *jobManager) list() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]Job, 0, len(m.order))
+	for _, id := range m.order {
+		job := m.jobs[id].job
+		job.Children = append([]string(nil), job.Children...)
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func (m *jobManager) cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.jobs[id]
+	if !ok {
+		return fmt.Errorf("job %s not found", id)
+	}
+	if entry.job.Status != JobStatusRunning {
+		return fmt.Errorf("job %s already %s", id, entry.job.Status)
+	}
+	entry.cancel()
+	return nil
+}
+
+func (m *jobManager) cancelAll() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cancelled := 0
+	for _, entry := range m.jobs {
+		if entry.job.Status == JobStatusRunning && entry.job.ParentID == "" {
+			entry.cancel()
+			cancelled++
+		}
+	}
+	return cancelled
+}
+
+func (m *jobManager) emit(name string, job Job) {
+	if m.app.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(m.app.ctx, name, job)
+}
+
+func (a *App) ListJobs() []Job {
+	return a.jobs.list()
+}
+
+func (a *App) CancelJob(id string) error {
+	return a.jobs.cancel(id)
+}
+
+// CancelAll cancels every running top-level job (and with it, their children)
+// and returns how many were cancelled.
+func (a *App) CancelAll() int {
+	return a.jobs.cancelAll()
+}
@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobFinishStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		run     func(m *jobManager, job *jobHandle) error
+		want    JobStatus
+	}{
+		{
+			name: "succeeded",
+			run:  func(m *jobManager, job *jobHandle) error { return nil },
+			want: JobStatusSucceeded,
+		},
+		{
+			name: "failed",
+			run:  func(m *jobManager, job *jobHandle) error { return errors.New("boom") },
+			want: JobStatusFailed,
+		},
+		{
+			name: "cancelled",
+			run: func(m *jobManager, job *jobHandle) error {
+				if err := m.cancel(job.id); err != nil {
+					return err
+				}
+				<-job.ctx.Done()
+				return job.ctx.Err()
+			},
+			want: JobStatusCancelled,
+		},
+		{
+			name:    "timed out",
+			timeout: time.Millisecond,
+			run: func(m *jobManager, job *jobHandle) error {
+				<-job.ctx.Done()
+				return job.ctx.Err()
+			},
+			want: JobStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newJobManager(&App{})
+			job := m.start(nil, "test", tt.name, tt.timeout)
+			job.finish("done", tt.run(m, job))
+
+			jobs := m.list()
+			if len(jobs) != 1 {
+				t.Fatalf("got %d jobs, want 1", len(jobs))
+			}
+			if jobs[0].Status != tt.want {
+				t.Errorf("status = %s, want %s (error %q)", jobs[0].Status, tt.want, jobs[0].Error)
+			}
+			if job.ctx.Err() == nil {
+				t.Error("job context was not released")
+			}
+		})
+	}
+}
+
+func TestCancelAllCancelsChildren(t *testing.T) {
+	m := newJobManager(&App{})
+	parent := m.start(nil, "batch", "batch", 0)
+	child := m.start(parent, "step", "step", 0)
+
+	if got := m.cancelAll(); got != 1 {
+		t.Fatalf("cancelAll = %d, want 1", got)
+	}
+	<-child.ctx.Done()
+	child.finish("", context.Cause(child.ctx))
+	parent.finish("", parent.ctx.Err())
+
+	for _, job := range m.list() {
+		if job.Status != JobStatusCancelled {
+			t.Errorf("%s status = %s, want cancelled", job.Kind, job.Status)
+		}
+	}
+}
+
+func TestJobSerial(t *testing.T) {
+	app := &App{targetSerial: "A1"}
+	m := newJobManager(app)
+	batch := m.startOn(nil, "A1", "batch-pull", "batch", 0)
+	defer batch.finish("", nil)
+
+	// Selecting another device mid-batch must not move the rest of it.
+	app.SetTargetDevice("")
+	child := m.start(batch, "pull", "child", 0)
+	defer child.finish("", nil)
+	if child.serial != "A1" {
+		t.Errorf("child serial = %q, want the batch's A1", child.serial)
+	}
+	local := m.start(nil, "payload-extract", "local", 0)
+	defer local.finish("", nil)
+	if local.serial != "" {
+		t.Errorf("top-level job serial = %q, want none", local.serial)
+	}
+}
@@ -0,0 +1,448 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	EventLogcatEntries = "logcat:entries"
+	EventLogcatStopped = "logcat:stopped"
+
+	logcatBatchInterval = 200 * time.Millisecond
+	logcatBatchSize     = 500
+	// Entries kept per session for SaveLogcat, oldest dropped first.
+	logcatHistorySize = 50000
+	// Stopped sessions kept for SaveLogcat; older ones are discarded.
+	logcatStoppedLimit = 5
+)
+
+var logcatBuffers = map[string]bool{
+	"main":   true,
+	"system": true,
+	"crash":  true,
+	"events": true,
+	"radio":  true,
+	"kernel": true,
+	"all":    true,
+}
+
+var defaultLogcatBuffers = []string{"main", "system", "crash"}
+
+// Levels in increasing priority, as printed in the threadtime format.
+var logcatLevels = map[string]int{"V": 0, "D": 1, "I": 2, "W": 3, "E": 4, "F": 5, "A": 6}
+
+var (
+	threadtimePattern = regexp.MustCompile(`^(\d\d-\d\d \d\d:\d\d:\d\d\.\d+)\s+(\d+)\s+(\d+)\s+([VDIWEFA])\s+(.*?)\s*: ?(.*)$`)
+	bufferSizePattern = regexp.MustCompile(`^\d+[KM]?$`)
+)
+
+type LogcatFilter struct {
+	Buffers []string
+	// Package is resolved to the pid of its running process when the session starts.
+	Package  string
+	Pid      int
+	Tag      string
+	MinLevel string
+	Regex    string
+}
+
+type LogEntry struct {
+	Time    string
+	Pid     int
+	Tid     int
+	Level   string
+	Tag     string
+	Message string
+}
+
+type LogcatBatch struct {
+	SessionID string
+	Entries   []LogEntry
+}
+
+type LogcatStopped struct {
+	SessionID string
+	Error     string
+}
+
+type logcatSession struct {
+	id     string
+	cancel context.CancelFunc
+
+	tag      string
+	minLevel int
+	regex    *regexp.Regexp
+
+	mu      sync.Mutex
+	pending []LogEntry
+	history []LogEntry
+	partial []byte
+}
+
+type logcatManager struct {
+	app *App
+
+	mu       sync.Mutex
+	nextID   int
+	sessions map[string]*logcatSession
+	// stopped holds the ids of ended sessions, oldest first.
+	stopped []string
+}
+
+func newLogcatManager(app *App) *logcatManager {
+	return &logcatManager{
+		app:      app,
+		sessions: make(map[string]*logcatSession),
+	}
+}
+
+// StartLogcat streams `logcat -v threadtime` from the target device and
+// returns a session id. Parsed entries arrive in batches as logcat:entries
+// events; logcat:stopped follows when the stream ends.
+func (a *App) StartLogcat(filter LogcatFilter) (string, error) {
+	args, err := a.logcatArgs(filter)
+	if err != nil {
+		return "", err
+	}
+
+	session := &logcatSession{
+		tag:      strings.ToLower(strings.TrimSpace(filter.Tag)),
+		minLevel: logcatLevels[strings.ToUpper(filter.MinLevel)],
+	}
+	if filter.Regex != "" {
+		if session.regex, err = regexp.Compile(filter.Regex); err != nil {
+			return "", fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session.cancel = cancel
+
+	m := a.logcat
+	m.mu.Lock()
+	m.nextID++
+	session.id = fmt.Sprintf("logcat-%d", m.nextID)
+	m.sessions[session.id] = session
+	m.mu.Unlock()
+
+	go m.run(ctx, session, shellJoin(args...))
+	return session.id, nil
+}
+
+func (a *App) logcatArgs(filter LogcatFilter) ([]string, error) {
+	bufferArgs, err := logcatBufferArgs(filter.Buffers)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"logcat", "-v", "threadtime"}, bufferArgs...)
+
+	if filter.MinLevel != "" {
+		if _, ok := logcatLevels[strings.ToUpper(filter.MinLevel)]; !ok {
+			return nil, fmt.Errorf("unknown log level %q", filter.MinLevel)
+		}
+	}
+
+	pid := filter.Pid
+	if filter.Package != "" {
+		output, err := a.runShellCommand(shellJoin("pidof", "-s", filter.Package))
+		if err != nil || strings.TrimSpace(output) == "" {
+			return nil, fmt.Errorf("package %s is not running", filter.Package)
+		}
+		if pid, err = strconv.Atoi(strings.TrimSpace(output)); err != nil {
+			return nil, fmt.Errorf("unexpected pidof output: %s", output)
+		}
+	}
+	if pid > 0 {
+		args = append(args, fmt.Sprintf("--pid=%d", pid))
+	}
+
+	return args, nil
+}
+
+func (m *logcatManager) run(ctx context.Context, session *logcatSession, command string) {
+	done := make(chan struct{})
+	var flusher sync.WaitGroup
+	flusher.Add(1)
+	go func() {
+		defer flusher.Done()
+		ticker := time.NewTicker(logcatBatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				m.flush(session)
+			}
+		}
+	}()
+
+	err := m.app.streamShellCommand(ctx, m.app.GetTargetDevice(), command, session)
+	close(done)
+	flusher.Wait()
+	session.endPartial()
+	m.flush(session)
+	m.retire(session.id)
+
+	stopped := LogcatStopped{SessionID: session.id}
+	if err != nil && ctx.Err() == nil {
+		stopped.Error = err.Error()
+	}
+	session.cancel()
+	m.emit(EventLogcatStopped, stopped)
+}
+
+// Write receives raw logcat output and queues every complete, matching line.
+func (s *logcatSession) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(data[:i]), "\r")
+		data = data[i+1:]
+
+		entry, ok := parseLogLine(line)
+		if ok && s.matches(entry) {
+			s.pending = append(s.pending, entry)
+		}
+	}
+	s.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// endPartial queues the last line when the stream ended without a newline.
+func (s *logcatSession) endPartial() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := parseLogLine(strings.TrimRight(string(s.partial), "\r"))
+	if ok && s.matches(entry) {
+		s.pending = append(s.pending, entry)
+	}
+	s.partial = nil
+}
+
+func (s *logcatSession) matches(entry LogEntry) bool {
+	if level, ok := logcatLevels[entry.Level]; ok && level < s.minLevel {
+		return false
+	}
+	if s.tag != "" && !strings.Contains(strings.ToLower(entry.Tag), s.tag) {
+		return false
+	}
+	if s.regex != nil && !s.regex.MatchString(entry.Tag) && !s.regex.MatchString(entry.Message) {
+		return false
+	}
+	return true
+}
+
+// parseLogLine parses one `logcat -v threadtime` line. "--------- beginning
+// of main" markers are skipped; other unparseable lines are kept as messages.
+func parseLogLine(line string) (LogEntry, bool) {
+	if line == "" || strings.HasPrefix(line, "--------- ") {
+		return LogEntry{}, false
+	}
+
+	match := threadtimePattern.FindStringSubmatch(line)
+	if match == nil {
+		return LogEntry{Message: line}, true
+	}
+
+	pid, _ := strconv.Atoi(match[2])
+	tid, _ := strconv.Atoi(match[3])
+	return LogEntry{
+		Time:    match[1],
+		Pid:     pid,
+		Tid:     tid,
+		Level:   match[4],
+		Tag:     match[5],
+		Message: match[6],
+	}, true
+}
+
+func (m *logcatManager) flush(session *logcatSession) {
+	session.mu.Lock()
+	pending := session.pending
+	session.pending = nil
+	session.history = append(session.history, pending...)
+	if excess := len(session.history) - logcatHistorySize; excess > 0 {
+		session.history = append([]LogEntry(nil), session.history[excess:]...)
+	}
+	session.mu.Unlock()
+
+	for len(pending) > 0 {
+		n := min(len(pending), logcatBatchSize)
+		m.emit(EventLogcatEntries, LogcatBatch{SessionID: session.id, Entries: pending[:n]})
+		pending = pending[n:]
+	}
+}
+
+func (m *logcatManager) get(id string) (*logcatSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("logcat session %s not found", id)
+	}
+	return session, nil
+}
+
+// retire records that a session ended and discards the oldest stopped
+// sessions beyond logcatStoppedLimit.
+func (m *logcatManager) retire(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[id]; !ok {
+		return
+	}
+	m.stopped = append(m.stopped, id)
+	for len(m.stopped) > logcatStoppedLimit {
+		delete(m.sessions, m.stopped[0])
+		m.stopped = m.stopped[1:]
+	}
+}
+
+func (m *logcatManager) emit(name string, payload interface{}) {
+	if m.app.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(m.app.ctx, name, payload)
+}
+
+// StopLogcat ends the stream. The session's entries stay available to
+// SaveLogcat until DiscardLogcat is called or logcatStoppedLimit newer
+// sessions have stopped.
+func (a *App) StopLogcat(id string) error {
+	session, err := a.logcat.get(id)
+	if err != nil {
+		return err
+	}
+	session.cancel()
+	return nil
+}
+
+func (a *App) DiscardLogcat(id string) error {
+	session, err := a.logcat.get(id)
+	if err != nil {
+		return err
+	}
+	session.cancel()
+
+	a.logcat.mu.Lock()
+	delete(a.logcat.sessions, id)
+	a.logcat.stopped = slices.DeleteFunc(a.logcat.stopped, func(stopped string) bool { return stopped == id })
+	a.logcat.mu.Unlock()
+	return nil
+}
+
+// SaveLogcat writes the entries captured by a session to filePath in
+// threadtime format.
+func (a *App) SaveLogcat(id string, filePath string) (string, error) {
+	session, err := a.logcat.get(id)
+	if err != nil {
+		return "", err
+	}
+	a.logcat.flush(session)
+
+	session.mu.Lock()
+	entries := append([]LogEntry(nil), session.history...)
+	session.mu.Unlock()
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create log file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, entry := range entries {
+		fmt.Fprintln(w, formatLogEntry(entry))
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("failed to write log file: %w", err)
+	}
+
+	return fmt.Sprintf("Saved %d log entries to %s", len(entries), filePath), nil
+}
+
+func formatLogEntry(entry LogEntry) string {
+	if entry.Time == "" {
+		return entry.Message
+	}
+	return fmt.Sprintf("%s %5d %5d %s %s: %s", entry.Time, entry.Pid, entry.Tid, entry.Level, entry.Tag, entry.Message)
+}
+
+// ClearLogcat clears the given buffers (main, system and crash when empty).
+func (a *App) ClearLogcat(buffers []string) (string, error) {
+	args, err := logcatBufferArgs(buffers)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := a.runShellCommand(shellJoin(append([]string{"logcat", "-c"}, args...)...))
+	if err != nil {
+		return "", fmt.Errorf("failed to clear logcat: %w. Output: %s", err, output)
+	}
+	return "Log buffers cleared.", nil
+}
+
+// SetLogcatBufferSize changes the ring buffer size, e.g. "256K" or "16M".
+func (a *App) SetLogcatBufferSize(size string, buffers []string) (string, error) {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	if !bufferSizePattern.MatchString(size) {
+		return "", fmt.Errorf("invalid buffer size %q, expected e.g. 256K or 16M", size)
+	}
+
+	args, err := logcatBufferArgs(buffers)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := a.runShellCommand(shellJoin(append([]string{"logcat", "-G", size}, args...)...))
+	if err != nil {
+		return "", fmt.Errorf("failed to set logcat buffer size: %w. Output: %s", err, output)
+	}
+	return fmt.Sprintf("Log buffer size set to %s.", size), nil
+}
+
+// GetLogcatBufferSizes returns `logcat -g` output, one line per buffer.
+func (a *App) GetLogcatBufferSizes() (string, error) {
+	output, err := a.runShellCommand(shellJoin("logcat", "-g"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read logcat buffer sizes: %w. Output: %s", err, output)
+	}
+	return output, nil
+}
+
+func logcatBufferArgs(buffers []string) ([]string, error) {
+	if len(buffers) == 0 {
+		buffers = defaultLogcatBuffers
+	}
+
+	var args []string
+	for _, buffer := range buffers {
+		if !logcatBuffers[buffer] {
+			return nil, fmt.Errorf("unknown logcat buffer %q", buffer)
+		}
+		args = append(args, "-b", buffer)
+	}
+	return args, nil
+}
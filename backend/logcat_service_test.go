@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestLogcatSessionEndPartial(t *testing.T) {
+	session := &logcatSession{}
+	session.Write([]byte("10-16 12:00:00.000  100  101 I Tag: first\n10-16 12:00:00.001  100  101 W Ta"))
+	session.Write([]byte("g: second"))
+	if len(session.pending) != 1 {
+		t.Fatalf("pending = %d entries before the stream ended, want 1", len(session.pending))
+	}
+
+	session.endPartial()
+	if len(session.pending) != 2 || session.pending[1].Message != "second" || session.pending[1].Level != "W" {
+		t.Fatalf("pending = %+v, want the unterminated line as the second entry", session.pending)
+	}
+	if session.partial != nil {
+		t.Errorf("partial = %q, want nil", session.partial)
+	}
+}
+
+func TestLogcatRetireEvictsOldest(t *testing.T) {
+	m := newLogcatManager(&App{})
+	for i := 1; i <= logcatStoppedLimit+2; i++ {
+		id := fmt.Sprintf("logcat-%d", i)
+		_, cancel := context.WithCancel(context.Background())
+		m.sessions[id] = &logcatSession{id: id, cancel: cancel}
+		m.retire(id)
+	}
+
+	for _, id := range []string{"logcat-1", "logcat-2"} {
+		if _, err := m.get(id); err == nil {
+			t.Errorf("%s is still kept after %d newer sessions stopped", id, logcatStoppedLimit)
+		}
+	}
+	if _, err := m.get(fmt.Sprintf("logcat-%d", logcatStoppedLimit+2)); err != nil {
+		t.Errorf("newest session: %v", err)
+	}
+	if len(m.stopped) != logcatStoppedLimit {
+		t.Errorf("stopped = %v, want %d ids", m.stopped, logcatStoppedLimit)
+	}
+}
@@ -0,0 +1,740 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// This file joins screenrecord segments into one MP4 without re-encoding.
+// Segments must share one sample description (codec, frame size and
+// parameter sets); it is reused and only the sample tables are rebuilt.
+// Output is laid out as ftyp, moov, mdat so it can be previewed while
+// streaming.
+
+type mp4Box struct {
+	Type     string
+	Payload  []byte
+	Children []*mp4Box
+}
+
+var mp4Containers = map[string]bool{
+	"moov": true,
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+}
+
+func (b *mp4Box) child(boxType string) *mp4Box {
+	for _, c := range b.Children {
+		if c.Type == boxType {
+			return c
+		}
+	}
+	return nil
+}
+
+func (b *mp4Box) path(types ...string) *mp4Box {
+	box := b
+	for _, t := range types {
+		if box = box.child(t); box == nil {
+			return nil
+		}
+	}
+	return box
+}
+
+func (b *mp4Box) encode() []byte {
+	payload := b.Payload
+	if mp4Containers[b.Type] {
+		var buf bytes.Buffer
+		for _, c := range b.Children {
+			buf.Write(c.encode())
+		}
+		payload = buf.Bytes()
+	}
+
+	out := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(out, uint32(8+len(payload)))
+	copy(out[4:], b.Type)
+	return append(out, payload...)
+}
+
+func parseMP4Boxes(data []byte) ([]*mp4Box, error) {
+	var boxes []*mp4Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("truncated mp4 box header")
+		}
+		size := uint64(binary.BigEndian.Uint32(data))
+		boxType := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("truncated mp4 box header")
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid size for mp4 box %q", boxType)
+		}
+
+		box := &mp4Box{Type: boxType, Payload: data[header:size]}
+		if mp4Containers[boxType] {
+			children, err := parseMP4Boxes(box.Payload)
+			if err != nil {
+				return nil, err
+			}
+			box.Children = children
+		}
+		boxes = append(boxes, box)
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// mp4File is the part of a segment needed to copy its video samples.
+type mp4File struct {
+	path      string
+	ftyp      *mp4Box
+	moov      *mp4Box
+	trak      *mp4Box
+	timescale uint32
+	samples   []mp4Sample
+}
+
+type mp4Sample struct {
+	offset   int64
+	size     uint32
+	duration uint32
+	ctsShift int32
+	sync     bool
+}
+
+func readMP4File(path string) (*mp4File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file := &mp4File{path: path}
+	header := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(f, header[:8]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		size := uint64(binary.BigEndian.Uint32(header))
+		boxType := string(header[4:8])
+		headerSize := uint64(8)
+		if size == 1 {
+			if _, err := io.ReadFull(f, header[8:16]); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			size = binary.BigEndian.Uint64(header[8:])
+			headerSize = 16
+		}
+		if size == 0 {
+			// Box runs to end of file; only mdat does that, and it is never loaded.
+			break
+		}
+		if size < headerSize {
+			return nil, fmt.Errorf("%s: invalid size for mp4 box %q", path, boxType)
+		}
+
+		if boxType != "ftyp" && boxType != "moov" {
+			if _, err := f.Seek(int64(size-headerSize), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		payload := make([]byte, size-headerSize)
+		if _, err := io.ReadFull(f, payload); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		box := &mp4Box{Type: boxType, Payload: payload}
+		if boxType == "moov" {
+			if box.Children, err = parseMP4Boxes(payload); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			file.moov = box
+		} else {
+			file.ftyp = box
+		}
+	}
+
+	if file.moov == nil {
+		return nil, fmt.Errorf("%s: no moov box, the recording was not finalized", path)
+	}
+
+	for _, c := range file.moov.Children {
+		if c.Type != "trak" {
+			continue
+		}
+		if hdlr := c.path("mdia", "hdlr"); hdlr != nil && len(hdlr.Payload) >= 12 && string(hdlr.Payload[8:12]) == "vide" {
+			file.trak = c
+			break
+		}
+	}
+	if file.trak == nil {
+		return nil, fmt.Errorf("%s: no video track", path)
+	}
+
+	mdhd := file.trak.path("mdia", "mdhd")
+	if mdhd == nil {
+		return nil, fmt.Errorf("%s: missing mdhd", path)
+	}
+	file.timescale, _ = fullBoxTimes(mdhd.Payload)
+
+	stbl := file.trak.path("mdia", "minf", "stbl")
+	if stbl == nil {
+		return nil, fmt.Errorf("%s: missing sample table", path)
+	}
+	if file.samples, err = readSampleTable(stbl); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, nil
+}
+
+// fullBoxTimes reads timescale and duration from an mvhd or mdhd payload.
+func fullBoxTimes(payload []byte) (uint32, uint64) {
+	if len(payload) > 0 && payload[0] == 1 && len(payload) >= 32 {
+		return binary.BigEndian.Uint32(payload[20:]), binary.BigEndian.Uint64(payload[24:])
+	}
+	if len(payload) >= 20 {
+		return binary.BigEndian.Uint32(payload[12:]), uint64(binary.BigEndian.Uint32(payload[16:]))
+	}
+	return 0, 0
+}
+
+// setDuration rewrites the duration field of an mvhd, mdhd or tkhd payload.
+func setDuration(box *mp4Box, duration uint64) error {
+	payload := append([]byte(nil), box.Payload...)
+	version1 := len(payload) > 0 && payload[0] == 1
+
+	offset, size := 16, 4
+	if box.Type == "tkhd" {
+		offset = 20
+	}
+	if version1 {
+		offset, size = 24, 8
+		if box.Type == "tkhd" {
+			offset = 28
+		}
+	}
+	if len(payload) < offset+size {
+		return fmt.Errorf("%s box is too short", box.Type)
+	}
+
+	if version1 {
+		binary.BigEndian.PutUint64(payload[offset:], duration)
+	} else {
+		binary.BigEndian.PutUint32(payload[offset:], uint32(min(duration, math.MaxUint32)))
+	}
+	box.Payload = payload
+	return nil
+}
+
+type tableReader struct {
+	data []byte
+	err  error
+}
+
+func newTableReader(box *mp4Box) *tableReader {
+	if box == nil {
+		return nil
+	}
+	if len(box.Payload) < 4 {
+		return &tableReader{err: fmt.Errorf("truncated %s box", box.Type)}
+	}
+	// Skip version and flags.
+	return &tableReader{data: box.Payload[4:]}
+}
+
+func (r *tableReader) u32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 4 {
+		r.err = fmt.Errorf("truncated sample table")
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *tableReader) u64() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 8 {
+		r.err = fmt.Errorf("truncated sample table")
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return v
+}
+
+func readSampleTable(stbl *mp4Box) ([]mp4Sample, error) {
+	stsz := newTableReader(stbl.child("stsz"))
+	stts := newTableReader(stbl.child("stts"))
+	stsc := newTableReader(stbl.child("stsc"))
+	if stsz == nil || stts == nil || stsc == nil {
+		return nil, fmt.Errorf("incomplete sample table")
+	}
+
+	uniformSize, count := stsz.u32(), stsz.u32()
+	if (uniformSize == 0 && int64(count)*4 > int64(len(stsz.data))) || count > 1<<26 {
+		return nil, fmt.Errorf("invalid sample count %d", count)
+	}
+	samples := make([]mp4Sample, count)
+	for i := range samples {
+		samples[i].size = uniformSize
+		if uniformSize == 0 {
+			samples[i].size = stsz.u32()
+		}
+		samples[i].sync = true
+	}
+
+	i := 0
+	for n := stts.u32(); n > 0 && stts.err == nil; n-- {
+		count, delta := stts.u32(), stts.u32()
+		for ; count > 0 && i < len(samples); count-- {
+			samples[i].duration = delta
+			i++
+		}
+	}
+
+	if ctts := newTableReader(stbl.child("ctts")); ctts != nil {
+		i = 0
+		for n := ctts.u32(); n > 0 && ctts.err == nil; n-- {
+			count, shift := ctts.u32(), int32(ctts.u32())
+			for ; count > 0 && i < len(samples); count-- {
+				samples[i].ctsShift = shift
+				i++
+			}
+		}
+		if ctts.err != nil {
+			return nil, ctts.err
+		}
+	}
+
+	if stss := newTableReader(stbl.child("stss")); stss != nil {
+		for i := range samples {
+			samples[i].sync = false
+		}
+		for n := stss.u32(); n > 0 && stss.err == nil; n-- {
+			if index := int(stss.u32()) - 1; index >= 0 && index < len(samples) {
+				samples[index].sync = true
+			}
+		}
+		if stss.err != nil {
+			return nil, stss.err
+		}
+	}
+
+	var chunkOffsets []int64
+	if stco := newTableReader(stbl.child("stco")); stco != nil {
+		for n := stco.u32(); n > 0 && stco.err == nil; n-- {
+			chunkOffsets = append(chunkOffsets, int64(stco.u32()))
+		}
+		if stco.err != nil {
+			return nil, stco.err
+		}
+	} else if co64 := newTableReader(stbl.child("co64")); co64 != nil {
+		for n := co64.u32(); n > 0 && co64.err == nil; n-- {
+			chunkOffsets = append(chunkOffsets, int64(co64.u64()))
+		}
+		if co64.err != nil {
+			return nil, co64.err
+		}
+	} else {
+		return nil, fmt.Errorf("missing chunk offsets")
+	}
+
+	type stscEntry struct{ firstChunk, samplesPerChunk uint32 }
+	var entries []stscEntry
+	for n := stsc.u32(); n > 0 && stsc.err == nil; n-- {
+		entries = append(entries, stscEntry{stsc.u32(), stsc.u32()})
+		stsc.u32() // sample description index
+	}
+
+	for _, r := range []*tableReader{stsz, stts, stsc} {
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+
+	i = 0
+	for chunk := range chunkOffsets {
+		perChunk := uint32(0)
+		for _, e := range entries {
+			if int(e.firstChunk) <= chunk+1 {
+				perChunk = e.samplesPerChunk
+			}
+		}
+		offset := chunkOffsets[chunk]
+		for ; perChunk > 0 && i < len(samples); perChunk-- {
+			samples[i].offset = offset
+			offset += int64(samples[i].size)
+			i++
+		}
+	}
+	if i != len(samples) {
+		return nil, fmt.Errorf("sample table covers %d of %d samples", i, len(samples))
+	}
+	return samples, nil
+}
+
+// concatMP4 writes the video tracks of inputs, in order, to outputPath.
+func concatMP4(outputPath string, inputs []string) error {
+	if len(inputs) == 0 {
+		return fmt.Errorf("no recordings to join")
+	}
+
+	files := make([]*mp4File, 0, len(inputs))
+	for _, input := range inputs {
+		file, err := readMP4File(input)
+		if err != nil {
+			return err
+		}
+		if len(file.samples) > 0 {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("recordings contain no video")
+	}
+
+	first := files[0]
+	stsd := first.trak.path("mdia", "minf", "stbl", "stsd")
+	for _, file := range files[1:] {
+		if other := file.trak.path("mdia", "minf", "stbl", "stsd"); stsd == nil || other == nil || !bytes.Equal(other.Payload, stsd.Payload) {
+			return fmt.Errorf("%s was encoded with different video settings than %s and cannot be joined", file.path, first.path)
+		}
+	}
+
+	timescale := first.timescale
+	var chunkSamples []uint32
+	var samples []mp4Sample
+	for _, file := range files {
+		for _, s := range file.samples {
+			if file.timescale != timescale && file.timescale != 0 {
+				s.duration = uint32(uint64(s.duration) * uint64(timescale) / uint64(file.timescale))
+				s.ctsShift = int32(int64(s.ctsShift) * int64(timescale) / int64(file.timescale))
+			}
+			samples = append(samples, s)
+		}
+		chunkSamples = append(chunkSamples, uint32(len(file.samples)))
+	}
+
+	var mdatSize int64
+	for _, s := range samples {
+		mdatSize += int64(s.size)
+	}
+
+	// co64 entries have a fixed size, so the moov size does not depend on the
+	// offsets and one pass with placeholder offsets finds where mdat starts.
+	chunkOffsets := make([]uint64, len(files))
+	moov, err := buildMoov(first, samples, chunkSamples, chunkOffsets, timescale)
+	if err != nil {
+		return err
+	}
+	ftyp := first.ftyp
+	if ftyp == nil {
+		ftyp = &mp4Box{Type: "ftyp", Payload: append([]byte("isom\x00\x00\x02\x00"), "isomiso2avc1mp41"...)}
+	}
+
+	const mdatHeaderSize = 16
+	dataStart := uint64(len(ftyp.encode()) + len(moov.encode()) + mdatHeaderSize)
+	offset := dataStart
+	for i, file := range files {
+		chunkOffsets[i] = offset
+		for _, s := range file.samples {
+			offset += uint64(s.size)
+		}
+	}
+	if moov, err = buildMoov(first, samples, chunkSamples, chunkOffsets, timescale); err != nil {
+		return err
+	}
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(out, 1<<20)
+
+	mdatHeader := make([]byte, mdatHeaderSize)
+	binary.BigEndian.PutUint32(mdatHeader, 1)
+	copy(mdatHeader[4:], "mdat")
+	binary.BigEndian.PutUint64(mdatHeader[8:], uint64(mdatSize)+mdatHeaderSize)
+
+	for _, part := range [][]byte{ftyp.encode(), moov.encode(), mdatHeader} {
+		if _, err := w.Write(part); err != nil {
+			out.Close()
+			return err
+		}
+	}
+
+	for _, file := range files {
+		if err := copySamples(w, file); err != nil {
+			out.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func copySamples(w io.Writer, file *mp4File) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, s := range file.samples {
+		if _, err := io.Copy(w, io.NewSectionReader(f, s.offset, int64(s.size))); err != nil {
+			return fmt.Errorf("%s: %w", file.path, err)
+		}
+	}
+	return nil
+}
+
+func buildMoov(first *mp4File, samples []mp4Sample, chunkSamples []uint32, chunkOffsets []uint64, timescale uint32) (*mp4Box, error) {
+	var mediaDuration uint64
+	for _, s := range samples {
+		mediaDuration += uint64(s.duration)
+	}
+
+	stbl := &mp4Box{Type: "stbl"}
+	if stsd := first.trak.path("mdia", "minf", "stbl", "stsd"); stsd != nil {
+		stbl.Children = append(stbl.Children, stsd)
+	}
+	stbl.Children = append(stbl.Children, buildStts(samples))
+	if ctts := buildCtts(samples); ctts != nil {
+		stbl.Children = append(stbl.Children, ctts)
+	}
+	if stss := buildStss(samples); stss != nil {
+		stbl.Children = append(stbl.Children, stss)
+	}
+	stbl.Children = append(stbl.Children, buildStsz(samples), buildStsc(chunkSamples), buildCo64(chunkOffsets))
+
+	minf := &mp4Box{Type: "minf"}
+	for _, c := range first.trak.path("mdia", "minf").Children {
+		if c.Type == "stbl" {
+			minf.Children = append(minf.Children, stbl)
+		} else {
+			minf.Children = append(minf.Children, c)
+		}
+	}
+
+	mdia := &mp4Box{Type: "mdia"}
+	for _, c := range first.trak.child("mdia").Children {
+		switch c.Type {
+		case "mdhd":
+			mdhd := &mp4Box{Type: "mdhd", Payload: c.Payload}
+			if err := setDuration(mdhd, mediaDuration); err != nil {
+				return nil, fmt.Errorf("%s: %w", first.path, err)
+			}
+			mdia.Children = append(mdia.Children, mdhd)
+		case "minf":
+			mdia.Children = append(mdia.Children, minf)
+		default:
+			mdia.Children = append(mdia.Children, c)
+		}
+	}
+
+	movieTimescale := uint32(1000)
+	mvhd := first.moov.child("mvhd")
+	if mvhd != nil {
+		if ts, _ := fullBoxTimes(mvhd.Payload); ts != 0 {
+			movieTimescale = ts
+		}
+	}
+	movieDuration := mediaDuration
+	if timescale != 0 {
+		movieDuration = mediaDuration * uint64(movieTimescale) / uint64(timescale)
+	}
+
+	trak := &mp4Box{Type: "trak"}
+	for _, c := range first.trak.Children {
+		switch c.Type {
+		case "tkhd":
+			tkhd := &mp4Box{Type: "tkhd", Payload: c.Payload}
+			if err := setDuration(tkhd, movieDuration); err != nil {
+				return nil, fmt.Errorf("%s: %w", first.path, err)
+			}
+			trak.Children = append(trak.Children, tkhd)
+		case "mdia":
+			trak.Children = append(trak.Children, mdia)
+		case "edts":
+			// Edit lists describe a single segment's timeline; drop them.
+		default:
+			trak.Children = append(trak.Children, c)
+		}
+	}
+
+	moov := &mp4Box{Type: "moov"}
+	if mvhd != nil {
+		movieHeader := &mp4Box{Type: "mvhd", Payload: mvhd.Payload}
+		if err := setDuration(movieHeader, movieDuration); err != nil {
+			return nil, fmt.Errorf("%s: %w", first.path, err)
+		}
+		moov.Children = append(moov.Children, movieHeader)
+	}
+	moov.Children = append(moov.Children, trak)
+	return moov, nil
+}
+
+type tableWriter struct {
+	bytes.Buffer
+}
+
+func newTableWriter(version byte) *tableWriter {
+	w := &tableWriter{}
+	w.Write([]byte{version, 0, 0, 0})
+	return w
+}
+
+func (w *tableWriter) u32(v uint32) {
+	binary.Write(&w.Buffer, binary.BigEndian, v)
+}
+
+func (w *tableWriter) u64(v uint64) {
+	binary.Write(&w.Buffer, binary.BigEndian, v)
+}
+
+func buildStts(samples []mp4Sample) *mp4Box {
+	type run struct{ count, delta uint32 }
+	var runs []run
+	for _, s := range samples {
+		if n := len(runs); n > 0 && runs[n-1].delta == s.duration {
+			runs[n-1].count++
+		} else {
+			runs = append(runs, run{1, s.duration})
+		}
+	}
+
+	w := newTableWriter(0)
+	w.u32(uint32(len(runs)))
+	for _, r := range runs {
+		w.u32(r.count)
+		w.u32(r.delta)
+	}
+	return &mp4Box{Type: "stts", Payload: w.Bytes()}
+}
+
+func buildCtts(samples []mp4Sample) *mp4Box {
+	type run struct {
+		count uint32
+		shift int32
+	}
+	var runs []run
+	version := byte(0)
+	hasShift := false
+	for _, s := range samples {
+		if s.ctsShift != 0 {
+			hasShift = true
+		}
+		if s.ctsShift < 0 {
+			version = 1
+		}
+		if n := len(runs); n > 0 && runs[n-1].shift == s.ctsShift {
+			runs[n-1].count++
+		} else {
+			runs = append(runs, run{1, s.ctsShift})
+		}
+	}
+	if !hasShift {
+		return nil
+	}
+
+	w := newTableWriter(version)
+	w.u32(uint32(len(runs)))
+	for _, r := range runs {
+		w.u32(r.count)
+		w.u32(uint32(r.shift))
+	}
+	return &mp4Box{Type: "ctts", Payload: w.Bytes()}
+}
+
+func buildStss(samples []mp4Sample) *mp4Box {
+	var sync []uint32
+	for i, s := range samples {
+		if s.sync {
+			sync = append(sync, uint32(i+1))
+		}
+	}
+	if len(sync) == len(samples) {
+		return nil
+	}
+
+	w := newTableWriter(0)
+	w.u32(uint32(len(sync)))
+	for _, n := range sync {
+		w.u32(n)
+	}
+	return &mp4Box{Type: "stss", Payload: w.Bytes()}
+}
+
+func buildStsz(samples []mp4Sample) *mp4Box {
+	w := newTableWriter(0)
+	w.u32(0)
+	w.u32(uint32(len(samples)))
+	for _, s := range samples {
+		w.u32(s.size)
+	}
+	return &mp4Box{Type: "stsz", Payload: w.Bytes()}
+}
+
+// buildStsc maps every segment to one chunk, since each segment's samples are
+// written contiguously.
+func buildStsc(chunkSamples []uint32) *mp4Box {
+	type entry struct{ firstChunk, perChunk uint32 }
+	var entries []entry
+	for i, n := range chunkSamples {
+		if len(entries) == 0 || entries[len(entries)-1].perChunk != n {
+			entries = append(entries, entry{uint32(i + 1), n})
+		}
+	}
+
+	w := newTableWriter(0)
+	w.u32(uint32(len(entries)))
+	for _, e := range entries {
+		w.u32(e.firstChunk)
+		w.u32(e.perChunk)
+		w.u32(1)
+	}
+	return &mp4Box{Type: "stsc", Payload: w.Bytes()}
+}
+
+func buildCo64(offsets []uint64) *mp4Box {
+	w := newTableWriter(0)
+	w.u32(uint32(len(offsets)))
+	for _, offset := range offsets {
+		w.u64(offset)
+	}
+	return &mp4Box{Type: "co64", Payload: w.Bytes()}
+}
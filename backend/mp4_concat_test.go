@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		box     string
+		payload []byte
+		offset  int
+		wide    bool
+		wantErr bool
+	}{
+		{"mvhd v0", "mvhd", make([]byte, 20), 16, false, false},
+		{"mvhd v1", "mvhd", append([]byte{1}, make([]byte, 31)...), 24, true, false},
+		{"mdhd v0", "mdhd", make([]byte, 24), 16, false, false},
+		{"tkhd v0", "tkhd", make([]byte, 84), 20, false, false},
+		{"tkhd v1", "tkhd", append([]byte{1}, make([]byte, 95)...), 28, true, false},
+		{"empty", "mvhd", nil, 0, false, true},
+		{"short mdhd v0", "mdhd", make([]byte, 19), 0, false, true},
+		{"short tkhd v0", "tkhd", make([]byte, 20), 0, false, true},
+		{"short tkhd v1", "tkhd", append([]byte{1}, make([]byte, 30)...), 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := &mp4Box{Type: tt.box, Payload: tt.payload}
+			err := setDuration(box, 5000)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("setDuration succeeded on a truncated box")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got uint64
+			if tt.wide {
+				got = binary.BigEndian.Uint64(box.Payload[tt.offset:])
+			} else {
+				got = uint64(binary.BigEndian.Uint32(box.Payload[tt.offset:]))
+			}
+			if got != 5000 {
+				t.Errorf("duration = %d, want 5000", got)
+			}
+			if len(box.Payload) != len(tt.payload) {
+				t.Errorf("payload grew from %d to %d bytes", len(tt.payload), len(box.Payload))
+			}
+		})
+	}
+}
+
+// writeTestMP4 writes a one-track video file holding frames, with stsd as
+// its sample description.
+func writeTestMP4(t *testing.T, name string, stsd []byte, frames ...string) string {
+	t.Helper()
+
+	samples := make([]mp4Sample, len(frames))
+	for i, frame := range frames {
+		samples[i] = mp4Sample{size: uint32(len(frame)), duration: 1000, sync: true}
+	}
+
+	timescaled := func(boxType string, size, timescaleAt int) *mp4Box {
+		payload := make([]byte, size)
+		binary.BigEndian.PutUint32(payload[timescaleAt:], 30000)
+		return &mp4Box{Type: boxType, Payload: payload}
+	}
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:], "vide")
+
+	build := func(offset uint64) *mp4Box {
+		stbl := &mp4Box{Type: "stbl", Children: []*mp4Box{
+			{Type: "stsd", Payload: stsd},
+			buildStts(samples),
+			buildStsz(samples),
+			buildStsc([]uint32{uint32(len(samples))}),
+			buildCo64([]uint64{offset}),
+		}}
+		mdia := &mp4Box{Type: "mdia", Children: []*mp4Box{
+			timescaled("mdhd", 24, 12),
+			{Type: "hdlr", Payload: hdlr},
+			{Type: "minf", Children: []*mp4Box{stbl}},
+		}}
+		trak := &mp4Box{Type: "trak", Children: []*mp4Box{{Type: "tkhd", Payload: make([]byte, 84)}, mdia}}
+		return &mp4Box{Type: "moov", Children: []*mp4Box{timescaled("mvhd", 100, 12), trak}}
+	}
+
+	ftyp := &mp4Box{Type: "ftyp", Payload: []byte("isom\x00\x00\x02\x00isom")}
+	mdat := &mp4Box{Type: "mdat", Payload: []byte(strings.Join(frames, ""))}
+	moov := build(0)
+	moov = build(uint64(len(ftyp.encode()) + len(moov.encode()) + 8))
+
+	var buf bytes.Buffer
+	for _, box := range []*mp4Box{ftyp, moov, mdat} {
+		buf.Write(box.encode())
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConcatMP4(t *testing.T) {
+	avc := []byte("\x00\x00\x00\x00\x00\x00\x00\x01avc1 1280x720")
+
+	tests := []struct {
+		name    string
+		inputs  func(t *testing.T) []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "same settings",
+			inputs: func(t *testing.T) []string {
+				return []string{writeTestMP4(t, "a.mp4", avc, "AAA", "BB"), writeTestMP4(t, "b.mp4", avc, "CCCC")}
+			},
+			want: "AAABBCCCC",
+		},
+		{
+			name: "empty segment skipped",
+			inputs: func(t *testing.T) []string {
+				return []string{writeTestMP4(t, "a.mp4", avc, "AAA"), writeTestMP4(t, "b.mp4", []byte("other")), writeTestMP4(t, "c.mp4", avc, "C")}
+			},
+			want: "AAAC",
+		},
+		{
+			name: "different sample description",
+			inputs: func(t *testing.T) []string {
+				return []string{writeTestMP4(t, "a.mp4", avc, "AAA"), writeTestMP4(t, "b.mp4", []byte("\x00\x00\x00\x00\x00\x00\x00\x01avc1 720x1280"), "BBB")}
+			},
+			wantErr: "different video settings",
+		},
+		{
+			name:    "no inputs",
+			inputs:  func(t *testing.T) []string { return nil },
+			wantErr: "no recordings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "joined.mp4")
+			err := concatMP4(output, tt.inputs(t))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("concatMP4 error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			joined, err := readMP4File(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got strings.Builder
+			for _, s := range joined.samples {
+				got.Write(data[s.offset : s.offset+int64(s.size)])
+			}
+			if got.String() != tt.want {
+				t.Errorf("samples = %q, want %q", got.String(), tt.want)
+			}
+			if _, duration := fullBoxTimes(joined.trak.path("mdia", "mdhd").Payload); duration != uint64(1000*len(joined.samples)) {
+				t.Errorf("media duration = %d, want %d", duration, 1000*len(joined.samples))
+			}
+		})
+	}
+}
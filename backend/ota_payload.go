@@ -0,0 +1,525 @@
+package backend
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	payloadMagic = "CrAU"
+	// Manifests of real OTAs are a few hundred KB; this only guards against
+	// allocating whatever a corrupt header claims.
+	maxPayloadManifestSize = 64 << 20
+	// update_engine splits operation data into 2 MB chunks, or one blob per
+	// partition in old payloads; anything past this is a corrupt length.
+	maxPayloadOperationSize = 4 << 30
+)
+
+// InstallOperation types from update_engine's update_metadata.proto.
+const (
+	opReplace   = 0
+	opReplaceBz = 1
+	opZero      = 6
+	opDiscard   = 7
+	opReplaceXz = 8
+)
+
+var payloadOperationNames = map[uint64]string{
+	0: "REPLACE", 1: "REPLACE_BZ", 2: "MOVE", 3: "BSDIFF", 4: "SOURCE_COPY",
+	5: "SOURCE_BSDIFF", 6: "ZERO", 7: "DISCARD", 8: "REPLACE_XZ", 9: "PUFFDIFF",
+	10: "BROTLI_BSDIFF", 11: "ZUCCHINI", 12: "LZ4DIFF_BSDIFF", 13: "LZ4DIFF_PUFFDIFF",
+}
+
+type PayloadPartition struct {
+	Name string
+	Size int64
+	// Incremental is set when the partition needs the old image (delta OTA)
+	// and so cannot be extracted on its own.
+	Incremental bool
+}
+
+type PayloadInfo struct {
+	Path       string
+	Version    uint64
+	BlockSize  int
+	Partitions []PayloadPartition
+}
+
+type payloadExtent struct {
+	startBlock uint64
+	numBlocks  uint64
+}
+
+type payloadOperation struct {
+	kind       uint64
+	dataOffset uint64
+	dataLength uint64
+	dstExtents []payloadExtent
+	dataSHA256 []byte
+}
+
+type payloadPartition struct {
+	name       string
+	size       uint64
+	operations []payloadOperation
+}
+
+// payloadFile is an opened payload.bin, read in place from an OTA zip when
+// it is stored uncompressed, which A/B OTAs require for streaming installs.
+type payloadFile struct {
+	file       *os.File
+	reader     io.ReaderAt
+	size       int64
+	version    uint64
+	blockSize  uint64
+	dataOffset int64
+	partitions []payloadPartition
+}
+
+// ListPayloadPartitions reads the manifest of an A/B OTA zip or a bare
+// payload.bin.
+func (a *App) ListPayloadPartitions(path string) (PayloadInfo, error) {
+	payload, err := openPayload(path)
+	if err != nil {
+		return PayloadInfo{}, err
+	}
+	defer payload.Close()
+
+	info := PayloadInfo{Path: path, Version: payload.version, BlockSize: int(payload.blockSize)}
+	for _, partition := range payload.partitions {
+		info.Partitions = append(info.Partitions, PayloadPartition{
+			Name:        partition.name,
+			Size:        int64(partition.size),
+			Incremental: !partition.isFull(),
+		})
+	}
+	return info, nil
+}
+
+// ExtractPayloadPartitions writes the selected partitions to
+// outputDir/<name>.img as a job and returns the job id. An empty selection
+// extracts every partition a full OTA carries.
+func (a *App) ExtractPayloadPartitions(path string, partitions []string, outputDir string) (string, error) {
+	if outputDir == "" {
+		return "", fmt.Errorf("no output folder selected")
+	}
+	payload, err := openPayload(path)
+	if err != nil {
+		return "", err
+	}
+
+	selected, err := payload.selectPartitions(partitions)
+	if err != nil {
+		payload.Close()
+		return "", err
+	}
+
+	job := a.jobs.start(nil, "payload-extract", "Extract "+filepath.Base(path), 0)
+	go func() {
+		defer payload.Close()
+		result, err := payload.extract(job, selected, outputDir)
+		job.finish(result, err)
+	}()
+	return job.id, nil
+}
+
+func openPayload(path string) (*payloadFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no OTA package selected")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OTA package: %w", err)
+	}
+
+	payload := &payloadFile{file: file}
+	if err := payload.locate(path); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := payload.readManifest(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return payload, nil
+}
+
+// locate points reader at payload.bin, either the file itself or the entry
+// inside an OTA zip.
+func (p *payloadFile) locate(path string) error {
+	magic := make([]byte, 4)
+	if _, err := p.file.ReadAt(magic, 0); err != nil {
+		return fmt.Errorf("failed to read OTA package: %w", err)
+	}
+	info, err := p.file.Stat()
+	if err != nil {
+		return err
+	}
+	if string(magic) == payloadMagic {
+		p.reader = p.file
+		p.size = info.Size()
+		return nil
+	}
+
+	archive, err := zip.NewReader(p.file, info.Size())
+	if err != nil {
+		return fmt.Errorf("%s is neither a payload.bin nor an OTA zip: %w", filepath.Base(path), err)
+	}
+	for _, entry := range archive.File {
+		if entry.Name != "payload.bin" {
+			continue
+		}
+		if entry.Method != zip.Store {
+			return fmt.Errorf("payload.bin is compressed inside the zip; extract it and open payload.bin directly")
+		}
+		offset, err := entry.DataOffset()
+		if err != nil {
+			return err
+		}
+		p.size = int64(entry.UncompressedSize64)
+		p.reader = io.NewSectionReader(p.file, offset, p.size)
+		return nil
+	}
+	return fmt.Errorf("payload.bin not found; only A/B OTA packages are supported")
+}
+
+func (p *payloadFile) readManifest() error {
+	header := make([]byte, 24)
+	if _, err := p.reader.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("failed to read payload header: %w", err)
+	}
+	if string(header[:4]) != payloadMagic {
+		return fmt.Errorf("invalid payload magic %q", header[:4])
+	}
+
+	p.version = binary.BigEndian.Uint64(header[4:12])
+	manifestSize := binary.BigEndian.Uint64(header[12:20])
+	headerSize := int64(20)
+	signatureSize := uint64(0)
+	if p.version >= 2 {
+		signatureSize = uint64(binary.BigEndian.Uint32(header[20:24]))
+		headerSize = 24
+	}
+	if manifestSize > maxPayloadManifestSize {
+		return fmt.Errorf("payload manifest too large (%d bytes)", manifestSize)
+	}
+
+	manifest := make([]byte, manifestSize)
+	if _, err := p.reader.ReadAt(manifest, headerSize); err != nil {
+		return fmt.Errorf("failed to read payload manifest: %w", err)
+	}
+	p.dataOffset = headerSize + int64(manifestSize) + int64(signatureSize)
+	if err := p.parseManifest(manifest); err != nil {
+		return err
+	}
+	if p.blockSize == 0 {
+		return fmt.Errorf("invalid payload block size")
+	}
+	return nil
+}
+
+// parseManifest decodes the DeltaArchiveManifest fields the extractor uses:
+// block_size (3) and partitions (13).
+func (p *payloadFile) parseManifest(data []byte) error {
+	p.blockSize = 4096
+	return protoFields(data, func(field int, wire int, value uint64, raw []byte) error {
+		switch {
+		case field == 3 && wire == 0:
+			p.blockSize = value
+		case field == 13 && wire == 2:
+			partition, err := parsePayloadPartition(raw)
+			if err != nil {
+				return err
+			}
+			p.partitions = append(p.partitions, partition)
+		}
+		return nil
+	})
+}
+
+// parsePayloadPartition decodes a PartitionUpdate: partition_name (1),
+// new_partition_info (7) and operations (8).
+func parsePayloadPartition(data []byte) (payloadPartition, error) {
+	var partition payloadPartition
+	err := protoFields(data, func(field int, wire int, value uint64, raw []byte) error {
+		switch {
+		case field == 1 && wire == 2:
+			partition.name = string(raw)
+		case field == 7 && wire == 2:
+			return protoFields(raw, func(field int, wire int, value uint64, _ []byte) error {
+				if field == 1 && wire == 0 {
+					partition.size = value
+				}
+				return nil
+			})
+		case field == 8 && wire == 2:
+			op, err := parsePayloadOperation(raw)
+			if err != nil {
+				return err
+			}
+			partition.operations = append(partition.operations, op)
+		}
+		return nil
+	})
+	return partition, err
+}
+
+// parsePayloadOperation decodes an InstallOperation: type (1),
+// data_offset (2), data_length (3), dst_extents (6) and data_sha256_hash (8).
+func parsePayloadOperation(data []byte) (payloadOperation, error) {
+	var op payloadOperation
+	err := protoFields(data, func(field int, wire int, value uint64, raw []byte) error {
+		switch {
+		case field == 1 && wire == 0:
+			op.kind = value
+		case field == 2 && wire == 0:
+			op.dataOffset = value
+		case field == 3 && wire == 0:
+			op.dataLength = value
+		case field == 6 && wire == 2:
+			var extent payloadExtent
+			if err := protoFields(raw, func(field int, wire int, value uint64, _ []byte) error {
+				if wire == 0 && field == 1 {
+					extent.startBlock = value
+				} else if wire == 0 && field == 2 {
+					extent.numBlocks = value
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			op.dstExtents = append(op.dstExtents, extent)
+		case field == 8 && wire == 2:
+			op.dataSHA256 = raw
+		}
+		return nil
+	})
+	return op, err
+}
+
+// protoFields walks the protobuf wire format, calling fn with each field's
+// number and wire type plus its varint value or length-delimited bytes.
+func protoFields(data []byte, fn func(field int, wire int, value uint64, raw []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("malformed payload manifest")
+		}
+		data = data[n:]
+		field, wire := int(key>>3), int(key&7)
+
+		var value uint64
+		var raw []byte
+		switch wire {
+		case 0:
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("malformed payload manifest")
+			}
+			data = data[n:]
+		case 1:
+			if len(data) < 8 {
+				return fmt.Errorf("malformed payload manifest")
+			}
+			value = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("malformed payload manifest")
+			}
+			raw = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case 5:
+			if len(data) < 4 {
+				return fmt.Errorf("malformed payload manifest")
+			}
+			value = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return fmt.Errorf("malformed payload manifest: wire type %d", wire)
+		}
+
+		if err := fn(field, wire, value, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *payloadPartition) isFull() bool {
+	for _, op := range p.operations {
+		switch op.kind {
+		case opReplace, opReplaceBz, opReplaceXz, opZero, opDiscard:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (p *payloadFile) selectPartitions(names []string) ([]payloadPartition, error) {
+	if len(names) == 0 {
+		var selected []payloadPartition
+		for _, partition := range p.partitions {
+			if partition.isFull() {
+				selected = append(selected, partition)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("this is a delta OTA; only full OTAs can be extracted")
+		}
+		return selected, nil
+	}
+
+	var selected []payloadPartition
+	for _, name := range names {
+		found := false
+		for _, partition := range p.partitions {
+			if partition.name != name {
+				continue
+			}
+			if !partition.isFull() {
+				return nil, fmt.Errorf("%s is a delta update and needs the previous image; only full OTAs can be extracted", name)
+			}
+			selected = append(selected, partition)
+			found = true
+			break
+		}
+		if !found {
+			return nil, fmt.Errorf("partition %s not found in payload", name)
+		}
+	}
+	return selected, nil
+}
+
+func (p *payloadFile) extract(job *jobHandle, partitions []payloadPartition, outputDir string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output folder: %w", err)
+	}
+
+	var total, done uint64
+	for _, partition := range partitions {
+		for _, op := range partition.operations {
+			total += op.dataLength
+		}
+	}
+
+	var written []string
+	for _, partition := range partitions {
+		target := filepath.Join(outputDir, partition.name+".img")
+		err := p.extractPartition(job.ctx, partition, target, func(n uint64) {
+			done += n
+			if total > 0 {
+				job.setProgress(float64(done) / float64(total) * 100)
+			}
+		})
+		if err != nil {
+			os.Remove(target)
+			if ctxErr := contextError(job.ctx, "extract "+partition.name); ctxErr != nil {
+				return "", ctxErr
+			}
+			return "", fmt.Errorf("failed to extract %s: %w", partition.name, err)
+		}
+		written = append(written, partition.name+".img")
+	}
+	return fmt.Sprintf("Extracted %s to %s", strings.Join(written, ", "), outputDir), nil
+}
+
+func (p *payloadFile) extractPartition(ctx context.Context, partition payloadPartition, target string, onData func(uint64)) error {
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := out.Truncate(int64(partition.size)); err != nil {
+		return err
+	}
+
+	for _, op := range partition.operations {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.applyOperation(op, out); err != nil {
+			name := payloadOperationNames[op.kind]
+			if name == "" {
+				name = fmt.Sprintf("type %d", op.kind)
+			}
+			return fmt.Errorf("%s operation: %w", name, err)
+		}
+		onData(op.dataLength)
+	}
+	return out.Close()
+}
+
+func (p *payloadFile) applyOperation(op payloadOperation, out *os.File) error {
+	switch op.kind {
+	case opZero, opDiscard:
+		// The output was truncated to size, so these blocks already read as zero.
+		return nil
+	case opReplace, opReplaceBz, opReplaceXz:
+	default:
+		return fmt.Errorf("unsupported in full OTA extraction")
+	}
+
+	if op.dataLength > maxPayloadOperationSize {
+		return fmt.Errorf("operation data too large (%d bytes)", op.dataLength)
+	}
+	available := uint64(max(p.size-p.dataOffset, 0))
+	if op.dataOffset > available || op.dataLength > available-op.dataOffset {
+		return fmt.Errorf("operation data at %d+%d is outside the %d byte payload data", op.dataOffset, op.dataLength, available)
+	}
+
+	data := io.NewSectionReader(p.reader, p.dataOffset+int64(op.dataOffset), int64(op.dataLength))
+	hash := sha256.New()
+	var source io.Reader = io.TeeReader(data, hash)
+	switch op.kind {
+	case opReplaceBz:
+		source = bzip2.NewReader(source)
+	case opReplaceXz:
+		reader, err := xz.NewReader(source)
+		if err != nil {
+			return err
+		}
+		source = reader
+	}
+
+	for _, extent := range op.dstExtents {
+		writer := io.NewOffsetWriter(out, int64(extent.startBlock*p.blockSize))
+		length := int64(extent.numBlocks * p.blockSize)
+		if _, err := io.CopyN(writer, source, length); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("payload data shorter than its extents")
+			}
+			return err
+		}
+	}
+
+	if len(op.dataSHA256) > 0 {
+		// The decompressors may stop short of the end of the blob.
+		if _, err := io.Copy(hash, data); err != nil {
+			return fmt.Errorf("failed to read payload data: %w", err)
+		}
+		if !bytes.Equal(hash.Sum(nil), op.dataSHA256) {
+			return fmt.Errorf("payload data checksum mismatch")
+		}
+	}
+	return nil
+}
+
+func (p *payloadFile) Close() error {
+	return p.file.Close()
+}
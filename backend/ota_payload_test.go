@@ -0,0 +1,209 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+const testPayloadBlockSize = 16
+
+func protoVarintField(field int, value uint64) []byte {
+	return binary.AppendUvarint(binary.AppendUvarint(nil, uint64(field)<<3), value)
+}
+
+func protoBytesField(field int, data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(field)<<3|2)
+	out = binary.AppendUvarint(out, uint64(len(data)))
+	return append(out, data...)
+}
+
+type testPayloadOp struct {
+	kind       uint64
+	data       []byte
+	startBlock uint64
+	numBlocks  uint64
+	sha256     []byte
+	// length, when set, replaces the real data length in the manifest.
+	length uint64
+}
+
+// writeTestPayload builds a version 2 payload.bin holding one partition made
+// of ops, with their data blobs laid out back to back.
+func writeTestPayload(t *testing.T, name string, blocks uint64, ops []testPayloadOp) string {
+	t.Helper()
+	partition := protoBytesField(1, []byte(name))
+	partition = append(partition, protoBytesField(7, protoVarintField(1, blocks*testPayloadBlockSize))...)
+
+	var blobs []byte
+	for _, op := range ops {
+		length := uint64(len(op.data))
+		if op.length != 0 {
+			length = op.length
+		}
+		encoded := protoVarintField(1, op.kind)
+		if len(op.data) > 0 || op.length != 0 {
+			encoded = append(encoded, protoVarintField(2, uint64(len(blobs)))...)
+			encoded = append(encoded, protoVarintField(3, length)...)
+		}
+		extent := append(protoVarintField(1, op.startBlock), protoVarintField(2, op.numBlocks)...)
+		encoded = append(encoded, protoBytesField(6, extent)...)
+		if op.sha256 != nil {
+			encoded = append(encoded, protoBytesField(8, op.sha256)...)
+		}
+		partition = append(partition, protoBytesField(8, encoded)...)
+		blobs = append(blobs, op.data...)
+	}
+	manifest := append(protoVarintField(3, testPayloadBlockSize), protoBytesField(13, partition)...)
+
+	payload := []byte(payloadMagic)
+	payload = binary.BigEndian.AppendUint64(payload, 2)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(len(manifest)))
+	payload = binary.BigEndian.AppendUint32(payload, 0)
+	payload = append(payload, manifest...)
+	payload = append(payload, blobs...)
+
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	if err := os.WriteFile(path, payload, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func extractTestPayload(t *testing.T, path string) ([]byte, error) {
+	t.Helper()
+	payload, err := openPayload(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer payload.Close()
+	if len(payload.partitions) != 1 {
+		t.Fatalf("parsed %d partitions, want 1", len(payload.partitions))
+	}
+
+	target := filepath.Join(t.TempDir(), "out.img")
+	if err := payload.extractPartition(testContext(t), payload.partitions[0], target, func(uint64) {}); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(target)
+}
+
+func TestProtoFields(t *testing.T) {
+	type field struct {
+		field, wire int
+		value       uint64
+		raw         string
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []field
+		wantErr bool
+	}{
+		{"varint", protoVarintField(3, 300), []field{{3, 0, 300, ""}}, false},
+		{"bytes", protoBytesField(1, []byte("system")), []field{{1, 2, 0, "system"}}, false},
+		{"fixed64", []byte{2<<3 | 1, 1, 0, 0, 0, 0, 0, 0, 0}, []field{{2, 1, 1, ""}}, false},
+		{"fixed32", []byte{4<<3 | 5, 2, 0, 0, 0}, []field{{4, 5, 2, ""}}, false},
+		{"sequence", append(protoVarintField(1, 8), protoBytesField(2, nil)...), []field{{1, 0, 8, ""}, {2, 2, 0, ""}}, false},
+		{"truncated key", []byte{0x80}, nil, true},
+		{"truncated varint", []byte{1 << 3, 0x80}, nil, true},
+		{"length past end", []byte{1<<3 | 2, 5, 'a'}, nil, true},
+		{"huge length", append([]byte{1<<3 | 2}, binary.AppendUvarint(nil, 1<<63)...), nil, true},
+		{"short fixed64", []byte{1<<3 | 1, 0, 0}, nil, true},
+		{"short fixed32", []byte{1<<3 | 5, 0}, nil, true},
+		{"group wire type", []byte{1<<3 | 3}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []field
+			err := protoFields(tt.data, func(f int, wire int, value uint64, raw []byte) error {
+				got = append(got, field{f, wire, value, string(raw)})
+				return nil
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("protoFields succeeded with %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("fields = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("field %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPayloadExtract(t *testing.T) {
+	replace := bytes.Repeat([]byte("A"), testPayloadBlockSize)
+	// bzip2 of 16 "B" bytes; the standard library only decompresses bzip2.
+	replaceBz := []byte("BZh91AY&SY6\xaf\x1aE\x00\x00\x02D\x00\x00\x04\x10\x00 \x00!\x00\x82\x0b\x17rE8P\x906\xaf\x1aE")
+	var replaceXz bytes.Buffer
+	w, err := xz.NewWriter(&replaceXz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(bytes.Repeat([]byte("C"), 2*testPayloadBlockSize))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(replaceBz)
+
+	path := writeTestPayload(t, "system", 5, []testPayloadOp{
+		{kind: opReplace, data: replace, startBlock: 0, numBlocks: 1},
+		{kind: opReplaceBz, data: replaceBz, startBlock: 1, numBlocks: 1, sha256: sum[:]},
+		{kind: opReplaceXz, data: replaceXz.Bytes(), startBlock: 2, numBlocks: 2},
+		{kind: opZero, startBlock: 4, numBlocks: 1},
+	})
+	got, err := extractTestPayload(t, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append([]byte(nil), replace...)
+	want = append(want, bytes.Repeat([]byte("B"), testPayloadBlockSize)...)
+	want = append(want, bytes.Repeat([]byte("C"), 2*testPayloadBlockSize)...)
+	want = append(want, make([]byte, testPayloadBlockSize)...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("extracted %q, want %q", got, want)
+	}
+}
+
+func TestPayloadExtractMalformed(t *testing.T) {
+	block := bytes.Repeat([]byte("A"), testPayloadBlockSize)
+	tests := []struct {
+		name    string
+		op      testPayloadOp
+		wantErr string
+	}{
+		{"huge length", testPayloadOp{kind: opReplace, data: block, numBlocks: 1, length: 1 << 62}, "too large"},
+		{"length past the payload", testPayloadOp{kind: opReplace, data: block, numBlocks: 1, length: 2 * testPayloadBlockSize}, "outside"},
+		{"data shorter than extents", testPayloadOp{kind: opReplace, data: block, numBlocks: 2}, "shorter than its extents"},
+		{"checksum mismatch", testPayloadOp{kind: opReplace, data: block, numBlocks: 1, sha256: make([]byte, sha256.Size)}, "checksum mismatch"},
+		{"delta operation", testPayloadOp{kind: 4, numBlocks: 1}, "SOURCE_COPY operation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestPayload(t, "system", 2, []testPayloadOp{tt.op})
+			_, err := extractTestPayload(t, path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
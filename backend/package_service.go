@@ -2,34 +2,32 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 )
 
 func (a *App) InstallPackage(filePath string) (string, error) {
+	job := a.jobs.start(nil, "install", fmt.Sprintf("Install %s", filepath.Base(filePath)), 15*time.Minute)
+	output, err := a.installPackage(job.ctx, filePath)
+	job.finish(output, err)
+	return output, err
+}
 
-	
-	a.opMutex.Lock()
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	a.currentCancel = cancel
-	a.opMutex.Unlock()
-
-	defer func() {
-		cancel() // context cancel
-		a.opMutex.Lock()
-		if a.currentCancel != nil {
-			a.currentCancel = nil
-		}
-		a.opMutex.Unlock()
-	}()
-
-	// Use runCommandContext directly to utilize the cancellable context
-	output, err := a.runCommandContext(ctx, "adb", "install", "-r", filePath)
+func (a *App) installPackage(ctx context.Context, filePath string) (string, error) {
+	var output string
+	var err error
+	if serial, ok := a.directTarget(); ok {
+		output, err = a.adb.Install(ctx, serial, filePath)
+	} else {
+		output, err = a.runCommandContext(ctx, "adb", "install", "-r", filePath)
+	}
 	if err != nil {
 		if ctx.Err() == context.Canceled {
-			return "", fmt.Errorf("installation cancelled by user")
+			return "", newCancelledError("adb install", "installation cancelled by user")
 		}
 		return "", fmt.Errorf("failed to install package: %w. Output: %s", err, output)
 	}
@@ -39,7 +37,7 @@ func (a *App) InstallPackage(filePath string) (string, error) {
 func (a *App) UninstallPackage(packageName string) (string, error) {
 
 	
-	output, err := a.runCommand("adb", "shell", "pm", "uninstall", packageName)
+	output, err := a.runCommand("adb", "shell", shellJoin("pm", "uninstall", packageName))
 	if err != nil {
 		return "", fmt.Errorf("failed to uninstall package: %w. Output: %s", err, output)
 	}
@@ -162,14 +160,14 @@ func (a *App) ListPackages(filterType string) ([]PackageInfo, error) {
 func (a *App) ClearData(packageName string) (string, error) {
 
 
-	output, err := a.runCommand("adb", "shell", "pm", "clear", packageName)
+	output, err := a.runCommand("adb", "shell", shellJoin("pm", "clear", packageName))
 
 	if err != nil {
 		return "", fmt.Errorf("failed to run clear data command for %s: %w", packageName, err)
 	}
 
 	if strings.Contains(output, "Failed") {
-		return "", fmt.Errorf("failed to clear data for %s: %s", packageName, output)
+		return "", fmt.Errorf("failed to clear data for %s: %w", packageName, newCommandError("", "adb shell "+shellJoin("pm", "clear", packageName), 0, output))
 	}
 
 	return "Data cleared successfully", nil
@@ -178,7 +176,7 @@ func (a *App) ClearData(packageName string) (string, error) {
 func (a *App) DisablePackage(packageName string) (string, error) {
 
 
-	output, err := a.runCommand("adb", "shell", "pm", "disable-user", "--user", "0", packageName)
+	output, err := a.runCommand("adb", "shell", shellJoin("pm", "disable-user", "--user", "0", packageName))
 	if err != nil {
 		return "", fmt.Errorf("failed to run disable command for %s: %w", packageName, err)
 	}
@@ -191,13 +189,13 @@ func (a *App) DisablePackage(packageName string) (string, error) {
 		return output, nil
 	}
 	
-	return "", fmt.Errorf("failed to disable package %s: %s", packageName, output)
+	return "", fmt.Errorf("failed to disable package %s: %w", packageName, newCommandError("", "adb shell "+shellJoin("pm", "disable-user", "--user", "0", packageName), 0, output))
 }
 
 func (a *App) EnablePackage(packageName string) (string, error) {
 
 
-	output, err := a.runCommand("adb", "shell", "pm", "enable", "--user", "0", packageName)
+	output, err := a.runCommand("adb", "shell", shellJoin("pm", "enable", "--user", "0", packageName))
 	if err != nil {
 		return "", fmt.Errorf("failed to run enable command for %s: %w", packageName, err)
 	}
@@ -206,13 +204,13 @@ func (a *App) EnablePackage(packageName string) (string, error) {
 		return output, nil
 	}
 
-	return "", fmt.Errorf("failed to enable package %s: %s", packageName, output)
+	return "", fmt.Errorf("failed to enable package %s: %w", packageName, newCommandError("", "adb shell "+shellJoin("pm", "enable", "--user", "0", packageName), 0, output))
 }
 
 func (a *App) PullApk(packageName string) (string, error) {
 
 
-	pathOutput, err := a.runCommand("adb", "shell", "pm", "path", packageName)
+	pathOutput, err := a.runCommand("adb", "shell", shellJoin("pm", "path", packageName))
 	if err != nil {
 		return "", fmt.Errorf("failed to find package path for %s: %w", packageName, err)
 	}
@@ -239,21 +237,24 @@ func (a *App) PullApk(packageName string) (string, error) {
 		return "APK pull cancelled by user", nil
 	}
 
-	a.opMutex.Lock()
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	a.currentCancel = cancel
-	a.opMutex.Unlock()
-	defer func() {
-		cancel()
-		a.opMutex.Lock()
-		a.currentCancel = nil
-		a.opMutex.Unlock()
-	}()
+	serial, err := a.resolveAdbSerial("")
+	if err != nil {
+		return "", err
+	}
+	job := a.jobs.startOn(nil, serial, "pull-apk", fmt.Sprintf("Pull %s", defaultFilename), 10*time.Minute)
+	result, err := a.pullApk(job.ctx, job.serial, remotePath, localPath)
+	job.finish(result, err)
+	return result, err
+}
 
-	output, err := a.runCommandContext(ctx, "adb", "pull", remotePath, localPath)
+func (a *App) pullApk(ctx context.Context, serial string, remotePath string, localPath string) (string, error) {
+	output, err := a.syncPull(ctx, serial, remotePath, localPath)
+	if errors.Is(err, errAdbServerUnavailable) {
+		output, err = a.runCommandContext(ctx, "adb", serialArgs(serial, "pull", remotePath, localPath)...)
+	}
 	if err != nil {
 		if ctx.Err() == context.Canceled {
-			return "", fmt.Errorf("pull cancelled by user")
+			return "", newCancelledError("pull", "pull cancelled by user")
 		}
 		return "", fmt.Errorf("adb pull failed: %w. Output: %s", err, output)
 	}
@@ -301,8 +302,7 @@ func (a *App) DisableMultiplePackages(packageNames []string) (string, error) {
 		_, err := a.DisablePackage(pkgName)
 		if err != nil {
 			failCount++
-			errorMsg := err.Error()
-			if strings.Contains(errorMsg, "is not allowed") {
+			if errors.Is(err, ErrPermissionDenied) {
 				errorMessages.WriteString(fmt.Sprintf("Failed %s: (System app?)\n", pkgName))
 			} else {
 				errorMessages.WriteString(fmt.Sprintf("Failed %s: %v\n", pkgName, err))
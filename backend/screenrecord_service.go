@@ -0,0 +1,297 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	EventRecordingStatus = "recording:status"
+
+	// screenrecord refuses --time-limit above 180 seconds.
+	screenrecordMaxSegment = 180
+	// Time screenrecord gets to write its moov box after SIGINT.
+	recordingStopTimeout = 15 * time.Second
+	recordingPullTimeout = 10 * time.Minute
+)
+
+const (
+	RecordingStateRecording = "recording"
+	RecordingStateStopping  = "stopping"
+	RecordingStatePulling   = "pulling"
+	RecordingStateStitching = "stitching"
+	RecordingStateDone      = "done"
+	RecordingStateFailed    = "failed"
+)
+
+var recordingSizePattern = regexp.MustCompile(`^\d+x\d+$`)
+
+type RecordingOptions struct {
+	// BitRate in bits per second; 0 keeps the screenrecord default.
+	BitRate int
+	// Size as WIDTHxHEIGHT; empty keeps the display resolution.
+	Size string
+	// TimeLimit is the total length in seconds; 0 records until stopped.
+	TimeLimit  int
+	DisplayID  string
+	OutputPath string
+}
+
+type RecordingStatus struct {
+	JobID          string
+	State          string
+	Segment        int
+	ElapsedSeconds float64
+	OutputPath     string
+	Error          string
+}
+
+// StartScreenRecording records the screen as a job and returns the job id.
+// screenrecord is restarted every 180 seconds so recordings can run longer;
+// stopping the job (StopScreenRecording, CancelJob or CancelOperation) ends
+// the current segment cleanly and the segments are joined into OutputPath.
+func (a *App) StartScreenRecording(opts RecordingOptions) (string, error) {
+	if opts.OutputPath == "" {
+		return "", fmt.Errorf("no output file selected")
+	}
+	if opts.Size != "" && !recordingSizePattern.MatchString(opts.Size) {
+		return "", fmt.Errorf("invalid size %q, expected WIDTHxHEIGHT", opts.Size)
+	}
+	if opts.DisplayID != "" && !displayIDPattern.MatchString(opts.DisplayID) {
+		return "", fmt.Errorf("invalid display id %q", opts.DisplayID)
+	}
+	if opts.BitRate < 0 || opts.TimeLimit < 0 {
+		return "", fmt.Errorf("bit rate and time limit must not be negative")
+	}
+
+	serial, err := a.resolveAdbSerial("")
+	if err != nil {
+		return "", err
+	}
+
+	job := a.jobs.startOn(nil, serial, "screenrecord", "Screen recording to "+filepath.Base(opts.OutputPath), 0)
+	go func() {
+		output, err := a.recordScreen(job, opts)
+		job.finish(output, err)
+	}()
+	return job.id, nil
+}
+
+// StopScreenRecording stops a recording and keeps what was recorded.
+func (a *App) StopScreenRecording(jobID string) error {
+	return a.jobs.cancel(jobID)
+}
+
+func (a *App) recordScreen(job *jobHandle, opts RecordingOptions) (string, error) {
+	status := RecordingStatus{JobID: job.id, OutputPath: opts.OutputPath}
+	fail := func(err error) (string, error) {
+		status.State = RecordingStateFailed
+		status.Error = err.Error()
+		a.emitRecordingStatus(status)
+		return "", err
+	}
+
+	prefix := fmt.Sprintf("/sdcard/adbkit-rec-%d", time.Now().UnixNano())
+	var segments []string
+	var recordErr error
+	started := time.Now()
+
+	for job.ctx.Err() == nil {
+		limit := screenrecordMaxSegment
+		if opts.TimeLimit > 0 {
+			remaining := opts.TimeLimit - int(time.Since(started).Seconds())
+			if remaining <= 0 {
+				break
+			}
+			limit = min(limit, remaining)
+		}
+
+		remote := fmt.Sprintf("%s-%03d.mp4", prefix, len(segments)+1)
+		segments = append(segments, remote)
+		status.State = RecordingStateRecording
+		status.Segment = len(segments)
+		status.ElapsedSeconds = time.Since(started).Seconds()
+		a.emitRecordingStatus(status)
+
+		err := a.recordSegment(job.ctx, job.serial, opts, limit, remote, func() {
+			status.State = RecordingStateStopping
+			a.emitRecordingStatus(status)
+		})
+		if err != nil {
+			// The failed segment is unlikely to be playable; what was
+			// recorded before it is still saved.
+			recordErr = err
+			break
+		}
+		if opts.TimeLimit > 0 {
+			job.setProgress(min(time.Since(started).Seconds()/float64(opts.TimeLimit)*100, 99))
+		}
+	}
+	status.ElapsedSeconds = time.Since(started).Seconds()
+
+	recorded := segments
+	if recordErr != nil {
+		recorded = segments[:len(segments)-1]
+		if len(recorded) == 0 {
+			a.removeRemoteSegments(job.serial, segments)
+			return fail(recordErr)
+		}
+	}
+
+	// Finalizing must survive the job's cancellation, which is how Stop arrives.
+	ctx, cancel := withCommandTimeout(context.Background(), recordingPullTimeout)
+	defer cancel()
+
+	// Remote segments are only removed once the recording is saved, so a
+	// failure below leaves them on the device or next to OutputPath.
+	status.State = RecordingStatePulling
+	a.emitRecordingStatus(status)
+
+	tempDir, err := os.MkdirTemp("", "adbkit-rec-")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var local []string
+	for _, remote := range recorded {
+		// A stop right after a segment started can leave no file behind.
+		if _, err := a.runShellCommandOn(job.serial, shellJoin("ls", remote)); err != nil {
+			continue
+		}
+		path := filepath.Join(tempDir, filepath.Base(remote))
+		if _, err := a.pullFile(ctx, job.serial, remote, path); err != nil {
+			return fail(fmt.Errorf("%w; the segments are still on the device as %s", err, strings.Join(recorded, ", ")))
+		}
+		local = append(local, path)
+	}
+	if len(local) == 0 {
+		return fail(fmt.Errorf("no video was recorded"))
+	}
+
+	status.State = RecordingStateStitching
+	a.emitRecordingStatus(status)
+
+	if len(local) == 1 {
+		err = copyLocalFile(local[0], opts.OutputPath)
+	} else {
+		err = concatMP4(opts.OutputPath, local)
+	}
+	if err != nil {
+		os.Remove(opts.OutputPath)
+		kept, keepErr := keepSegments(local, opts.OutputPath)
+		if keepErr != nil {
+			return fail(fmt.Errorf("failed to save recording: %w; the segments are still on the device as %s", err, strings.Join(recorded, ", ")))
+		}
+		a.removeRemoteSegments(job.serial, segments)
+		return fail(fmt.Errorf("failed to save recording: %w; the segments were kept as %s", err, strings.Join(kept, ", ")))
+	}
+	a.removeRemoteSegments(job.serial, segments)
+
+	if recordErr != nil {
+		return fail(fmt.Errorf("recording stopped after %d segments, which were saved to %s: %w", len(local), opts.OutputPath, recordErr))
+	}
+	status.State = RecordingStateDone
+	a.emitRecordingStatus(status)
+	return fmt.Sprintf("Recording saved to %s (%d segments, %.0fs)", opts.OutputPath, len(local), status.ElapsedSeconds), nil
+}
+
+// keepSegments moves pulled segments next to outputPath as <name>-001.mp4
+// and so on, for when they cannot be joined.
+func keepSegments(local []string, outputPath string) ([]string, error) {
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	var kept []string
+	for i, path := range local {
+		target := fmt.Sprintf("%s-%03d.mp4", base, i+1)
+		// The temp directory can be on another filesystem.
+		if err := os.Rename(path, target); err != nil {
+			if err := copyLocalFile(path, target); err != nil {
+				return kept, err
+			}
+		}
+		kept = append(kept, target)
+	}
+	return kept, nil
+}
+
+// recordSegment runs one screenrecord invocation. When ctx is cancelled the
+// process gets SIGINT, which makes it finish the file instead of leaving an
+// unplayable one, and nil is returned.
+func (a *App) recordSegment(ctx context.Context, serial string, opts RecordingOptions, limit int, remote string, onStop func()) error {
+	args := []string{"screenrecord", "--time-limit", strconv.Itoa(limit)}
+	if opts.BitRate > 0 {
+		args = append(args, "--bit-rate", strconv.Itoa(opts.BitRate))
+	}
+	if opts.Size != "" {
+		args = append(args, "--size", opts.Size)
+	}
+	if opts.DisplayID != "" {
+		args = append(args, "--display-id", opts.DisplayID)
+	}
+	args = append(args, remote)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.streamShellCommand(runCtx, serial, shellJoin(args...), io.Discard)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("screenrecord failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		onStop()
+		_, _ = a.runShellCommandOn(serial, shellJoin("pkill", "-INT", "-f", remote))
+		select {
+		case <-done:
+		case <-time.After(recordingStopTimeout):
+		}
+		return nil
+	}
+}
+
+func (a *App) removeRemoteSegments(serial string, segments []string) {
+	if len(segments) == 0 {
+		return
+	}
+	_, _ = a.runShellCommandOn(serial, shellJoin(append([]string{"rm", "-f"}, segments...)...))
+}
+
+func (a *App) emitRecordingStatus(status RecordingStatus) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, EventRecordingStatus, status)
+}
+
+func copyLocalFile(source string, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKeepSegments(t *testing.T) {
+	tempDir := t.TempDir()
+	var local []string
+	for _, name := range []string{"seg-001.mp4", "seg-002.mp4"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		local = append(local, path)
+	}
+	outputDir := t.TempDir()
+
+	kept, err := keepSegments(local, filepath.Join(outputDir, "demo.mp4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(outputDir, "demo-001.mp4"), filepath.Join(outputDir, "demo-002.mp4")}
+	if !reflect.DeepEqual(kept, want) {
+		t.Fatalf("kept = %q, want %q", kept, want)
+	}
+	for i, path := range kept {
+		if data, err := os.ReadFile(path); err != nil || string(data) != filepath.Base(local[i]) {
+			t.Errorf("%s = %q, %v; want the contents of %s", path, data, err, local[i])
+		}
+	}
+}
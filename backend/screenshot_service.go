@@ -0,0 +1,178 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	screenshotTimeout         = 30 * time.Second
+	defaultScreenshotTemplate = "{nickname}_{timestamp}"
+)
+
+var (
+	pngSignature        = []byte("\x89PNG\r\n\x1a\n")
+	displayIDPattern    = regexp.MustCompile(`^\d+$`)
+	displayLinePattern  = regexp.MustCompile(`(?m)^Display (\d+) (.*)$`)
+	unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+type ScreenshotOptions struct {
+	// DisplayID selects a display on multi-display devices, see GetDisplays.
+	DisplayID string
+	Save      bool
+	SaveDir   string
+	// FilenameTemplate may use {nickname}, {model}, {serial} and {timestamp}.
+	FilenameTemplate string
+	Nickname         string
+}
+
+type Screenshot struct {
+	Base64 string
+	Width  int
+	Height int
+	Path   string
+}
+
+type DisplayInfo struct {
+	ID          string
+	Description string
+}
+
+// CaptureScreenshot grabs a PNG with `exec-out screencap -p`, which is binary
+// safe unlike `shell screencap`, and optionally saves it to opts.SaveDir.
+func (a *App) CaptureScreenshot(opts ScreenshotOptions) (Screenshot, error) {
+	args := []string{"screencap", "-p"}
+	if opts.DisplayID != "" {
+		if !displayIDPattern.MatchString(opts.DisplayID) {
+			return Screenshot{}, fmt.Errorf("invalid display id %q", opts.DisplayID)
+		}
+		args = append(args, "-d", opts.DisplayID)
+	}
+
+	ctx, cancel := withCommandTimeout(context.Background(), screenshotTimeout)
+	defer cancel()
+
+	var buf bytes.Buffer
+	if err := a.execOut(ctx, shellJoin(args...), &buf); err != nil {
+		return Screenshot{}, fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, pngSignature) {
+		// screencap reports errors as text on the same stream.
+		message := strings.TrimSpace(string(data))
+		if message == "" {
+			message = "empty output"
+		}
+		return Screenshot{}, fmt.Errorf("failed to capture screenshot: %s", message)
+	}
+
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Screenshot{}, fmt.Errorf("invalid screenshot image: %w", err)
+	}
+
+	shot := Screenshot{
+		Base64: base64.StdEncoding.EncodeToString(data),
+		Width:  config.Width,
+		Height: config.Height,
+	}
+
+	if opts.Save {
+		path, err := a.saveScreenshot(data, opts)
+		if err != nil {
+			return shot, err
+		}
+		shot.Path = path
+	}
+
+	return shot, nil
+}
+
+func (a *App) saveScreenshot(data []byte, opts ScreenshotOptions) (string, error) {
+	if opts.SaveDir == "" {
+		return "", fmt.Errorf("no screenshot folder configured")
+	}
+	if err := os.MkdirAll(opts.SaveDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create screenshot folder: %w", err)
+	}
+
+	name := a.screenshotFilename(opts)
+	path := filepath.Join(opts.SaveDir, name+".png")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(opts.SaveDir, fmt.Sprintf("%s_%d.png", name, i))
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save screenshot: %w", err)
+	}
+	return path, nil
+}
+
+func (a *App) screenshotFilename(opts ScreenshotOptions) string {
+	template := opts.FilenameTemplate
+	if strings.TrimSpace(template) == "" {
+		template = defaultScreenshotTemplate
+	}
+
+	model := ""
+	if strings.Contains(template, "{model}") || (opts.Nickname == "" && strings.Contains(template, "{nickname}")) {
+		if model = a.getProp("ro.product.model"); model == "N/A" {
+			model = ""
+		}
+	}
+
+	serial := a.GetTargetDevice()
+	if serial == "" && strings.Contains(template, "{serial}") {
+		if serial = a.getProp("ro.serialno"); serial == "N/A" {
+			serial = ""
+		}
+	}
+
+	nickname := opts.Nickname
+	if nickname == "" {
+		nickname = model
+	}
+	if nickname == "" {
+		nickname = "screenshot"
+	}
+
+	name := strings.NewReplacer(
+		"{nickname}", nickname,
+		"{model}", model,
+		"{serial}", serial,
+		"{timestamp}", time.Now().Format("20060102_150405"),
+	).Replace(template)
+
+	name = strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "_"), "_.")
+	if name == "" {
+		name = "screenshot_" + time.Now().Format("20060102_150405")
+	}
+	return name
+}
+
+// GetDisplays lists the display ids screencap accepts for -d.
+func (a *App) GetDisplays() ([]DisplayInfo, error) {
+	output, err := a.runShellCommand(shellJoin("dumpsys", "SurfaceFlinger", "--display-id"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list displays: %w", err)
+	}
+
+	displays := []DisplayInfo{}
+	for _, match := range displayLinePattern.FindAllStringSubmatch(output, -1) {
+		displays = append(displays, DisplayInfo{ID: match[1], Description: strings.TrimSpace(match[2])})
+	}
+	return displays, nil
+}
@@ -0,0 +1,37 @@
+package backend
+
+import "strings"
+
+// shellQuote quotes arg for the device shell (mksh/toybox sh) so it is passed
+// through as exactly one word with no expansion. Single quotes are the only
+// character that needs escaping inside a single-quoted POSIX string.
+func shellQuote(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if isShellSafe(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// shellJoin builds a device shell command line from argv, quoting every argument.
+func shellJoin(argv ...string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func isShellSafe(arg string) bool {
+	for _, r := range arg {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("@%+=:,./_-", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
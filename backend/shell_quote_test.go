@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"", "''"},
+		{"notes.txt", "notes.txt"},
+		{"/sdcard/DCIM/a-b_c.jpg", "/sdcard/DCIM/a-b_c.jpg"},
+		{"-rf", "-rf"},
+		{"Tom's notes.txt", `'Tom'\''s notes.txt'`},
+		{"a&b.jpg", "'a&b.jpg'"},
+		{"$(reboot)", "'$(reboot)'"},
+		{"`reboot`", "'`reboot`'"},
+		{"line1\nline2", "'line1\nline2'"},
+		{"*.apk", "'*.apk'"},
+		{"''", `''\'''\'''`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.arg); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+
+	if got, want := shellJoin("ls", "-l", "My Files"), "ls -l 'My Files'"; got != want {
+		t.Errorf("shellJoin = %s, want %s", got, want)
+	}
+}
+
+// FuzzShellQuote checks that a quoted argument reaches a POSIX shell as one
+// unchanged word.
+func FuzzShellQuote(f *testing.F) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		f.Skip("no sh to run quoted arguments through")
+	}
+	for _, seed := range []string{"", "Tom's notes.txt", "a&b.jpg", "$(reboot)", "`id`", "a\nb", "-n", "'", `\`, "${HOME}", "~", "a b\tc"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, arg string) {
+		// Arguments cannot hold NUL bytes.
+		if strings.ContainsRune(arg, 0) {
+			t.Skip()
+		}
+		out, err := exec.Command(sh, "-c", "printf %s "+shellQuote(arg)).Output()
+		if err != nil {
+			t.Fatalf("sh -c printf %%s %s: %v", shellQuote(arg), err)
+		}
+		if string(out) != arg {
+			t.Errorf("round trip of %q gave %q", arg, out)
+		}
+	})
+}
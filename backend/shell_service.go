@@ -2,6 +2,7 @@ package backend
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 )
 
@@ -33,13 +34,30 @@ func (a *App) RunAdbHostCommand(args string) (string, error) {
 	return output, nil
 }
 
+// SideloadPackage sends filePath to a device in recovery sideload mode as a
+// job and returns its id. adb's "(~NN%)" progress is emitted as
+// FlashProgress events.
 func (a *App) SideloadPackage(filePath string) (string, error) {
-
-
-	output, err := a.runCommand("adb", "sideload", filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to sideload package: %w. Output: %s", err, output)
+	if filePath == "" {
+		return "", fmt.Errorf("file path cannot be empty")
 	}
 
-	return output, nil
+	job := a.jobs.start(nil, "sideload", "Sideload "+filepath.Base(filePath), 0)
+	go func() {
+		reporter := &flashReporter{app: a, job: job}
+		lines := &lineSplitter{}
+		_, err := a.runCommandStream(job.ctx, func(chunk []byte) {
+			lines.feed(chunk, func(line string, complete bool) {
+				if percent := parseSideloadProgress(line); percent >= 0 {
+					reporter.report(percent, "Sending "+filepath.Base(filePath))
+				}
+			})
+		}, "adb", "sideload", filePath)
+		if err != nil {
+			job.finish("", fmt.Errorf("failed to sideload package: %w", err))
+			return
+		}
+		job.finish("Sideloaded "+filepath.Base(filePath), nil)
+	}()
+	return job.id, nil
 }
@@ -0,0 +1,335 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	EventShellOutput = "shell:output"
+	EventShellExit   = "shell:exit"
+
+	shellOpenTimeout = 10 * time.Second
+	// Keystrokes are tiny, but a paste can be large; adbd reads stdin in
+	// buffers of this size.
+	shellMaxStdinChunk = 4096
+	// Output kept per session so a terminal re-opened in the UI can redraw.
+	shellScrollbackSize = 256 * 1024
+	shellTerm           = "xterm-256color"
+)
+
+type ShellSession struct {
+	ID          string
+	Serial      string
+	Interactive bool
+	StartedAt   time.Time
+}
+
+type ShellOutput struct {
+	SessionID string
+	Stream    string
+	Data      string
+}
+
+type ShellExit struct {
+	SessionID string
+	ExitCode  int
+	Error     string
+}
+
+// shellSession is one open device shell. Devices with shell_v2 get a real PTY
+// with resize support and an exit code; older ones fall back to the legacy
+// "shell:" service, which is a raw byte stream.
+type shellSession struct {
+	info ShellSession
+	conn net.Conn
+	v2   bool
+
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	scrollback []byte
+	closed     bool
+}
+
+type shellManager struct {
+	app *App
+
+	mu       sync.Mutex
+	nextID   int
+	sessions map[string]*shellSession
+}
+
+func newShellManager(app *App) *shellManager {
+	return &shellManager{
+		app:      app,
+		sessions: make(map[string]*shellSession),
+	}
+}
+
+func (m *shellManager) get(id string) (*shellSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("shell session %s not found", id)
+	}
+	return session, nil
+}
+
+// OpenShell starts an interactive shell on serial (the target device when
+// empty) and returns its session id. Output arrives as shell:output events
+// and the end of the session as a single shell:exit event.
+func (a *App) OpenShell(serial string) (string, error) {
+	if serial == "" {
+		serial = a.GetTargetDevice()
+	}
+
+	ctx, cancel := withCommandTimeout(context.Background(), shellOpenTimeout)
+	defer cancel()
+
+	conn, v2, err := a.openShellConn(ctx, serial)
+	if errors.Is(err, errAdbServerUnavailable) && !a.IsHeadlessAdb() {
+		if _, startErr := a.runCommand("adb", "start-server"); startErr == nil {
+			conn, v2, err = a.openShellConn(ctx, serial)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", newTimeoutError("adb shell", "timed out opening shell")
+		}
+		if errors.Is(err, errAdbServerUnavailable) {
+			return "", fmt.Errorf("failed to open shell: %w", err)
+		}
+		return "", newCommandError(serial, "adb shell", -1, err.Error())
+	}
+	// dial applied the open timeout as a deadline; the session itself has none.
+	_ = conn.SetDeadline(time.Time{})
+
+	m := a.shells
+	m.mu.Lock()
+	m.nextID++
+	session := &shellSession{
+		info: ShellSession{
+			ID:          fmt.Sprintf("shell-%d", m.nextID),
+			Serial:      serial,
+			Interactive: v2,
+			StartedAt:   time.Now(),
+		},
+		conn: conn,
+		v2:   v2,
+	}
+	m.sessions[session.info.ID] = session
+	m.mu.Unlock()
+
+	go m.read(session)
+	return session.info.ID, nil
+}
+
+func (a *App) openShellConn(ctx context.Context, serial string) (net.Conn, bool, error) {
+	features, err := a.adb.Features(ctx, serial)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if features["shell_v2"] {
+		conn, err := a.adb.openService(ctx, serial, "shell,v2,TERM="+shellTerm+",pty:")
+		return conn, true, err
+	}
+	conn, err := a.adb.openService(ctx, serial, "shell:")
+	return conn, false, err
+}
+
+func (m *shellManager) read(session *shellSession) {
+	exit := ShellExit{SessionID: session.info.ID, ExitCode: -1}
+	pending := map[string][]byte{}
+
+	emit := func(stream string, data []byte) {
+		data = append(pending[stream], data...)
+		data, pending[stream] = splitIncompleteUTF8(data)
+		if len(data) == 0 {
+			return
+		}
+		session.appendScrollback(data)
+		m.emit(EventShellOutput, ShellOutput{SessionID: session.info.ID, Stream: stream, Data: string(data)})
+	}
+
+	if session.v2 {
+		for {
+			id, data, err := readShellPacket(session.conn)
+			if err != nil {
+				if !session.isClosed() {
+					exit.Error = err.Error()
+				}
+				break
+			}
+			if id == shellIDExit {
+				if len(data) > 0 {
+					exit.ExitCode = int(data[0])
+				}
+				break
+			}
+			switch id {
+			case shellIDStdout:
+				emit("stdout", data)
+			case shellIDStderr:
+				emit("stderr", data)
+			}
+		}
+	} else {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := session.conn.Read(buf)
+			if n > 0 {
+				emit("stdout", buf[:n])
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	session.close()
+	m.mu.Lock()
+	delete(m.sessions, session.info.ID)
+	m.mu.Unlock()
+	m.emit(EventShellExit, exit)
+}
+
+// splitIncompleteUTF8 holds back a multi-byte character cut off at the end of
+// a chunk so it is not turned into a replacement character on the way to JS.
+func splitIncompleteUTF8(data []byte) ([]byte, []byte) {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i], append([]byte(nil), data[i:]...)
+			}
+			break
+		}
+	}
+	return data, nil
+}
+
+func (s *shellSession) appendScrollback(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scrollback = append(s.scrollback, data...)
+	if excess := len(s.scrollback) - shellScrollbackSize; excess > 0 {
+		s.scrollback = append([]byte(nil), s.scrollback[excess:]...)
+	}
+}
+
+func (s *shellSession) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *shellSession) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.conn.Close()
+}
+
+func (s *shellSession) write(id byte, data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if !s.v2 {
+		_, err := s.conn.Write(data)
+		return err
+	}
+	return writeShellPacket(s.conn, id, data)
+}
+
+func (m *shellManager) emit(name string, payload interface{}) {
+	if m.app.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(m.app.ctx, name, payload)
+}
+
+// WriteShell sends keystrokes or pasted text to a session's stdin.
+func (a *App) WriteShell(id string, data string) error {
+	session, err := a.shells.get(id)
+	if err != nil {
+		return err
+	}
+
+	input := []byte(data)
+	for len(input) > 0 {
+		n := min(len(input), shellMaxStdinChunk)
+		if err := session.write(shellIDStdin, input[:n]); err != nil {
+			return fmt.Errorf("failed to write to shell: %w", err)
+		}
+		input = input[n:]
+	}
+	return nil
+}
+
+// ResizeShell updates the PTY window size. Legacy shells have no channel for
+// it, so the call is a no-op there.
+func (a *App) ResizeShell(id string, cols int, rows int) error {
+	session, err := a.shells.get(id)
+	if err != nil {
+		return err
+	}
+	if !session.v2 || cols <= 0 || rows <= 0 {
+		return nil
+	}
+
+	size := fmt.Sprintf("%dx%d,%dx%d", rows, cols, 0, 0)
+	if err := session.write(shellIDWindowSize, append([]byte(size), 0)); err != nil {
+		return fmt.Errorf("failed to resize shell: %w", err)
+	}
+	return nil
+}
+
+// CloseShell ends a session. The shell:exit event still follows.
+func (a *App) CloseShell(id string) error {
+	session, err := a.shells.get(id)
+	if err != nil {
+		return err
+	}
+	session.close()
+	return nil
+}
+
+func (a *App) ListShells() []ShellSession {
+	m := a.shells
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessions := make([]ShellSession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session.info)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	return sessions
+}
+
+// GetShellScrollback returns the recent output of a session, for redrawing a
+// terminal that was closed in the UI while the session kept running.
+func (a *App) GetShellScrollback(id string) (string, error) {
+	session, err := a.shells.get(id)
+	if err != nil {
+		return "", err
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return string(session.scrollback), nil
+}
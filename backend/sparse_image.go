@@ -0,0 +1,707 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	sparseMagic           = 0xed26ff3a
+	sparseHeaderSize      = 28
+	sparseChunkHeaderSize = 12
+	sparseBlockSize       = 4096
+
+	chunkTypeRaw      = 0xcac1
+	chunkTypeFill     = 0xcac2
+	chunkTypeDontCare = 0xcac3
+	chunkTypeCRC32    = 0xcac4
+)
+
+type SparseImageInfo struct {
+	Path     string
+	IsSparse bool
+	// FileSize is the size on disk; LogicalSize is what the partition
+	// receives once the image is expanded. They match for raw images.
+	FileSize       int64
+	LogicalSize    int64
+	BlockSize      int
+	TotalBlocks    int64
+	TotalChunks    int
+	RawChunks      int
+	FillChunks     int
+	DontCareChunks int
+	CRCChunks      int
+	// RawBytes is the data actually stored in RAW chunks.
+	RawBytes int64
+}
+
+type sparseHeader struct {
+	blockSize   uint32
+	totalBlocks uint32
+	totalChunks uint32
+	headerSize  uint16
+	chunkHeader uint16
+}
+
+// sparseChunk is a chunk of a parsed sparse file. offset is where its data
+// starts in the file and startBlock where it lands in the expanded image.
+type sparseChunk struct {
+	kind       uint16
+	blocks     uint32
+	startBlock uint32
+	offset     int64
+	dataSize   int64
+	fill       uint32
+}
+
+// InspectSparseImage reports whether path is an Android sparse image and,
+// if so, its expanded size and chunk makeup.
+func (a *App) InspectSparseImage(path string) (SparseImageInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return SparseImageInfo{}, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return SparseImageInfo{}, err
+	}
+	info := SparseImageInfo{Path: path, FileSize: stat.Size(), LogicalSize: stat.Size()}
+
+	header, chunks, err := readSparseImage(file)
+	if errors.Is(err, errNotSparse) {
+		return info, nil
+	}
+	if err != nil {
+		return SparseImageInfo{}, err
+	}
+
+	info.IsSparse = true
+	info.BlockSize = int(header.blockSize)
+	info.TotalBlocks = int64(header.totalBlocks)
+	info.LogicalSize = int64(header.totalBlocks) * int64(header.blockSize)
+	info.TotalChunks = len(chunks)
+	for _, chunk := range chunks {
+		switch chunk.kind {
+		case chunkTypeRaw:
+			info.RawChunks++
+			info.RawBytes += chunk.dataSize
+		case chunkTypeFill:
+			info.FillChunks++
+		case chunkTypeDontCare:
+			info.DontCareChunks++
+		case chunkTypeCRC32:
+			info.CRCChunks++
+		}
+	}
+	return info, nil
+}
+
+// ConvertSparseImage converts source to destination as a job and returns its
+// id: sparse to raw when toSparse is false, raw to sparse otherwise.
+func (a *App) ConvertSparseImage(source string, destination string, toSparse bool) (string, error) {
+	if source == "" || destination == "" {
+		return "", fmt.Errorf("source and destination cannot be empty")
+	}
+	if filepath.Clean(source) == filepath.Clean(destination) {
+		return "", fmt.Errorf("destination must differ from the source image")
+	}
+
+	description := "Convert " + filepath.Base(source) + " to raw"
+	if toSparse {
+		description = "Convert " + filepath.Base(source) + " to sparse"
+	}
+	job := a.jobs.start(nil, "sparse-convert", description, 0)
+	go func() {
+		var err error
+		if toSparse {
+			err = rawToSparse(job.ctx, source, destination, job.setProgress)
+		} else {
+			err = sparseToRaw(job.ctx, source, destination, job.setProgress)
+		}
+		if err != nil {
+			os.Remove(destination)
+			if ctxErr := contextError(job.ctx, description); ctxErr != nil {
+				err = ctxErr
+			}
+			job.finish("", err)
+			return
+		}
+		job.finish("Saved "+destination, nil)
+	}()
+	return job.id, nil
+}
+
+var errNotSparse = errors.New("not a sparse image")
+
+func readSparseHeader(r io.ReaderAt) (sparseHeader, error) {
+	buf := make([]byte, sparseHeaderSize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return sparseHeader{}, errNotSparse
+		}
+		return sparseHeader{}, err
+	}
+	le := binary.LittleEndian
+	if le.Uint32(buf) != sparseMagic {
+		return sparseHeader{}, errNotSparse
+	}
+	if major := le.Uint16(buf[4:]); major != 1 {
+		return sparseHeader{}, fmt.Errorf("unsupported sparse format version %d", major)
+	}
+
+	header := sparseHeader{
+		headerSize:  le.Uint16(buf[8:]),
+		chunkHeader: le.Uint16(buf[10:]),
+		blockSize:   le.Uint32(buf[12:]),
+		totalBlocks: le.Uint32(buf[16:]),
+		totalChunks: le.Uint32(buf[20:]),
+	}
+	if header.headerSize < sparseHeaderSize || header.chunkHeader < sparseChunkHeaderSize {
+		return sparseHeader{}, fmt.Errorf("invalid sparse header")
+	}
+	if header.blockSize == 0 || header.blockSize%4 != 0 {
+		return sparseHeader{}, fmt.Errorf("invalid sparse block size %d", header.blockSize)
+	}
+	return header, nil
+}
+
+// readSparseImage parses the header and chunk table of a sparse image.
+func readSparseImage(r io.ReaderAt) (sparseHeader, []sparseChunk, error) {
+	header, err := readSparseHeader(r)
+	if err != nil {
+		return header, nil, err
+	}
+
+	le := binary.LittleEndian
+	chunks := make([]sparseChunk, 0, min(header.totalChunks, 1<<16))
+	offset := int64(header.headerSize)
+	block := uint32(0)
+	buf := make([]byte, sparseChunkHeaderSize)
+	for i := uint32(0); i < header.totalChunks; i++ {
+		if _, err := r.ReadAt(buf, offset); err != nil {
+			return header, nil, fmt.Errorf("sparse image truncated at chunk %d", i)
+		}
+		chunk := sparseChunk{
+			kind:       le.Uint16(buf),
+			blocks:     le.Uint32(buf[4:]),
+			startBlock: block,
+			offset:     offset + int64(header.chunkHeader),
+		}
+		totalSize := int64(le.Uint32(buf[8:]))
+		chunk.dataSize = totalSize - int64(header.chunkHeader)
+		if chunk.dataSize < 0 {
+			return header, nil, fmt.Errorf("invalid size in sparse chunk %d", i)
+		}
+
+		switch chunk.kind {
+		case chunkTypeRaw:
+			if chunk.dataSize != int64(chunk.blocks)*int64(header.blockSize) {
+				return header, nil, fmt.Errorf("raw chunk %d size does not match its blocks", i)
+			}
+		case chunkTypeFill:
+			if chunk.dataSize != 4 {
+				return header, nil, fmt.Errorf("invalid fill chunk %d", i)
+			}
+			fill := make([]byte, 4)
+			if _, err := r.ReadAt(fill, chunk.offset); err != nil {
+				return header, nil, fmt.Errorf("sparse image truncated at chunk %d", i)
+			}
+			chunk.fill = le.Uint32(fill)
+		case chunkTypeDontCare, chunkTypeCRC32:
+		default:
+			return header, nil, fmt.Errorf("unknown sparse chunk type 0x%x", chunk.kind)
+		}
+
+		if uint64(block)+uint64(chunk.blocks) > uint64(header.totalBlocks) {
+			return header, nil, fmt.Errorf("sparse chunks exceed the image size")
+		}
+		block += chunk.blocks
+		offset = chunk.offset + chunk.dataSize
+		chunks = append(chunks, chunk)
+	}
+	return header, chunks, nil
+}
+
+func sparseToRaw(ctx context.Context, source string, destination string, progress func(float64)) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	header, chunks, err := readSparseImage(in)
+	if errors.Is(err, errNotSparse) {
+		return fmt.Errorf("%s is not a sparse image", filepath.Base(source))
+	}
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	// Unwritten ranges (DONT_CARE) stay as holes that read back as zeros.
+	if err := out.Truncate(int64(header.totalBlocks) * int64(header.blockSize)); err != nil {
+		return err
+	}
+
+	blockSize := int64(header.blockSize)
+	for i, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		writer := io.NewOffsetWriter(out, int64(chunk.startBlock)*blockSize)
+		switch chunk.kind {
+		case chunkTypeRaw:
+			if _, err := io.Copy(writer, io.NewSectionReader(in, chunk.offset, chunk.dataSize)); err != nil {
+				return err
+			}
+		case chunkTypeFill:
+			if chunk.fill == 0 {
+				break
+			}
+			pattern := make([]byte, blockSize)
+			for j := 0; j < len(pattern); j += 4 {
+				binary.LittleEndian.PutUint32(pattern[j:], chunk.fill)
+			}
+			for b := uint32(0); b < chunk.blocks; b++ {
+				if _, err := writer.Write(pattern); err != nil {
+					return err
+				}
+			}
+		}
+		progress(float64(i+1) / float64(len(chunks)) * 100)
+	}
+	return out.Close()
+}
+
+func rawToSparse(ctx context.Context, source string, destination string, progress func(float64)) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if _, err := readSparseHeader(in); err == nil {
+		return fmt.Errorf("%s is already a sparse image", filepath.Base(source))
+	}
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	size := stat.Size()
+	totalBlocks := (size + sparseBlockSize - 1) / sparseBlockSize
+	if totalBlocks > 0xffffffff {
+		return fmt.Errorf("image too large for the sparse format")
+	}
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	w := bufio.NewWriterSize(out, 1<<20)
+
+	// The header is rewritten with the chunk count once it is known.
+	if _, err := w.Write(make([]byte, sparseHeaderSize)); err != nil {
+		return err
+	}
+
+	encoder := &sparseEncoder{w: w, source: in}
+	block := make([]byte, sparseBlockSize)
+	reader := bufio.NewReaderSize(in, 1<<20)
+	for b := int64(0); b < totalBlocks; b++ {
+		if b%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			progress(float64(b) / float64(totalBlocks) * 100)
+		}
+		clear(block)
+		if _, err := io.ReadFull(reader, block); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		if err := encoder.add(b, block); err != nil {
+			return err
+		}
+	}
+	if err := encoder.flush(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	header := encodeSparseHeader(sparseBlockSize, uint32(totalBlocks), encoder.chunks)
+	if _, err := out.WriteAt(header, 0); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// sparseEncoder merges consecutive blocks into RAW chunks, or FILL chunks
+// when every 4-byte word of a block is the same.
+type sparseEncoder struct {
+	w      io.Writer
+	source io.ReaderAt
+	chunks uint32
+
+	kind   uint16
+	start  int64
+	blocks int64
+	fill   uint32
+}
+
+func (e *sparseEncoder) add(index int64, block []byte) error {
+	fill, isFill := blockFillValue(block)
+	kind := uint16(chunkTypeRaw)
+	if isFill {
+		kind = chunkTypeFill
+	}
+
+	if e.blocks > 0 && (kind != e.kind || (isFill && fill != e.fill)) {
+		if err := e.flush(); err != nil {
+			return err
+		}
+	}
+	if e.blocks == 0 {
+		e.kind, e.start, e.fill = kind, index, fill
+	}
+	e.blocks++
+	return nil
+}
+
+func (e *sparseEncoder) flush() error {
+	if e.blocks == 0 {
+		return nil
+	}
+	defer func() { e.blocks = 0 }()
+	e.chunks++
+
+	if e.kind == chunkTypeFill {
+		chunk := encodeChunkHeader(chunkTypeFill, uint32(e.blocks), 4)
+		chunk = binary.LittleEndian.AppendUint32(chunk, e.fill)
+		_, err := e.w.Write(chunk)
+		return err
+	}
+
+	dataSize := e.blocks * sparseBlockSize
+	if _, err := e.w.Write(encodeChunkHeader(chunkTypeRaw, uint32(e.blocks), dataSize)); err != nil {
+		return err
+	}
+	// The tail of the last block may be past the end of the source file.
+	data := io.NewSectionReader(e.source, e.start*sparseBlockSize, dataSize)
+	n, err := io.Copy(e.w, data)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(make([]byte, dataSize-n))
+	return err
+}
+
+func blockFillValue(block []byte) (uint32, bool) {
+	pattern := block[:4]
+	for i := 4; i < len(block); i += 4 {
+		if !bytes.Equal(block[i:i+4], pattern) {
+			return 0, false
+		}
+	}
+	return binary.LittleEndian.Uint32(pattern), true
+}
+
+func encodeSparseHeader(blockSize uint32, totalBlocks uint32, totalChunks uint32) []byte {
+	le := binary.LittleEndian
+	header := make([]byte, sparseHeaderSize)
+	le.PutUint32(header, sparseMagic)
+	le.PutUint16(header[4:], 1)
+	le.PutUint16(header[6:], 0)
+	le.PutUint16(header[8:], sparseHeaderSize)
+	le.PutUint16(header[10:], sparseChunkHeaderSize)
+	le.PutUint32(header[12:], blockSize)
+	le.PutUint32(header[16:], totalBlocks)
+	le.PutUint32(header[20:], totalChunks)
+	return header
+}
+
+func encodeChunkHeader(kind uint16, blocks uint32, dataSize int64) []byte {
+	le := binary.LittleEndian
+	header := make([]byte, sparseChunkHeaderSize)
+	le.PutUint16(header, kind)
+	le.PutUint32(header[4:], blocks)
+	le.PutUint32(header[8:], uint32(sparseChunkHeaderSize+dataSize))
+	return header
+}
+
+// splitSparseImage resparses source into files of at most maxSize bytes in
+// dir, the way fastboot does for images over max-download-size. Each piece
+// is a complete sparse image covering the whole partition, with DONT_CARE
+// outside its own blocks, so flashing them in order writes the full image.
+// Raw images are converted to sparse first.
+func splitSparseImage(ctx context.Context, source string, maxSize int64, dir string) ([]string, error) {
+	in, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	header, chunks, err := readSparseImage(in)
+	if errors.Is(err, errNotSparse) {
+		in.Close()
+		converted := filepath.Join(dir, filepath.Base(source)+".sparse")
+		if err := rawToSparse(ctx, source, converted, func(float64) {}); err != nil {
+			return nil, err
+		}
+		return splitSparseImage(ctx, converted, maxSize, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blockSize := int64(header.blockSize)
+	// Header plus the leading and trailing DONT_CARE chunks.
+	overhead := int64(sparseHeaderSize + 2*sparseChunkHeaderSize)
+	budget := maxSize - overhead
+	if budget < sparseChunkHeaderSize+blockSize {
+		return nil, fmt.Errorf("max-download-size %d is too small to split into", maxSize)
+	}
+
+	// Chunks are packed in order so each piece covers a contiguous block
+	// range; RAW chunks that do not fit are cut at a block boundary.
+	var pieces [][]sparseChunk
+	var current []sparseChunk
+	used := int64(0)
+	for _, chunk := range chunks {
+		if chunk.kind == chunkTypeCRC32 {
+			continue
+		}
+		for chunk.blocks > 0 {
+			size := sparseChunkHeaderSize + chunk.dataSize
+			if used+size > budget && chunk.kind == chunkTypeRaw {
+				fit := (budget - used - sparseChunkHeaderSize) / blockSize
+				if fit <= 0 && len(current) == 0 {
+					return nil, fmt.Errorf("max-download-size %d is too small to split into", maxSize)
+				}
+				if fit > 0 {
+					head := chunk
+					head.blocks = uint32(fit)
+					head.dataSize = fit * blockSize
+					current = append(current, head)
+					chunk.blocks -= uint32(fit)
+					chunk.startBlock += uint32(fit)
+					chunk.offset += fit * blockSize
+					chunk.dataSize -= fit * blockSize
+				}
+				pieces = append(pieces, current)
+				current, used = nil, 0
+				continue
+			}
+			if used+size > budget && len(current) > 0 {
+				pieces = append(pieces, current)
+				current, used = nil, 0
+			}
+			current = append(current, chunk)
+			used += size
+			break
+		}
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, current)
+	}
+
+	var paths []string
+	base := filepath.Base(source)
+	for i, piece := range pieces {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s.%d", base, i+1))
+		if err := writeSparsePiece(in, header, piece, path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writeSparsePiece(in io.ReaderAt, header sparseHeader, chunks []sparseChunk, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	w := bufio.NewWriterSize(out, 1<<20)
+
+	var body [][]byte
+	count := uint32(0)
+	first := chunks[0].startBlock
+	last := chunks[len(chunks)-1].startBlock + chunks[len(chunks)-1].blocks
+	if first > 0 {
+		body = append(body, encodeChunkHeader(chunkTypeDontCare, first, 0))
+		count++
+	}
+	count += uint32(len(chunks))
+	if last < header.totalBlocks {
+		count++
+	}
+
+	if _, err := w.Write(encodeSparseHeader(header.blockSize, header.totalBlocks, count)); err != nil {
+		return err
+	}
+	for _, b := range body {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	blockSize := int64(header.blockSize)
+	for _, chunk := range chunks {
+		if _, err := w.Write(encodeChunkHeader(chunk.kind, chunk.blocks, chunk.dataSize)); err != nil {
+			return err
+		}
+		switch chunk.kind {
+		case chunkTypeRaw:
+			if _, err := io.Copy(w, io.NewSectionReader(in, chunk.offset, int64(chunk.blocks)*blockSize)); err != nil {
+				return err
+			}
+		case chunkTypeFill:
+			if err := binary.Write(w, binary.LittleEndian, chunk.fill); err != nil {
+				return err
+			}
+		}
+	}
+	if last < header.totalBlocks {
+		if _, err := w.Write(encodeChunkHeader(chunkTypeDontCare, header.totalBlocks-last, 0)); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// splitForDownload returns the files to flash for filePath on serial: the
+// file itself when it fits the device's max-download-size, or sparse pieces
+// in a temp directory otherwise. cleanup removes the pieces. Devices that do
+// not know max-download-size get the file unchanged; other getvar failures,
+// such as a timeout or an unplugged device, are returned.
+func (a *App) splitForDownload(ctx context.Context, serial string, filePath string) ([]string, func(), error) {
+	noop := func() {}
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	getvarCtx, cancel := withCommandTimeout(ctx, DefaultCommandTimeout)
+	defer cancel()
+	value, err := a.fastbootGetvar(getvarCtx, serial, "max-download-size")
+	if isUnknownVariable(err) {
+		return []string{filePath}, noop, nil
+	}
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to read max-download-size: %w", err)
+	}
+	maxSize := parseGetvarInt(value)
+	if maxSize <= 0 || stat.Size() <= maxSize {
+		return []string{filePath}, noop, nil
+	}
+
+	dir, err := os.MkdirTemp("", "adbkit-sparse-")
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	pieces, err := splitSparseImage(ctx, filePath, maxSize, dir)
+	if err != nil {
+		cleanup()
+		return nil, noop, fmt.Errorf("failed to split image for max-download-size %d: %w", maxSize, err)
+	}
+	return pieces, cleanup, nil
+}
+
+// sparseReader reads a sparse image as if it were the expanded raw image,
+// so parsers can use it without converting the file first.
+type sparseReader struct {
+	file      io.ReaderAt
+	blockSize int64
+	size      int64
+	chunks    []sparseChunk
+}
+
+func newSparseReader(r io.ReaderAt) (*sparseReader, error) {
+	header, chunks, err := readSparseImage(r)
+	if err != nil {
+		return nil, err
+	}
+	reader := &sparseReader{
+		file:      r,
+		blockSize: int64(header.blockSize),
+		size:      int64(header.totalBlocks) * int64(header.blockSize),
+	}
+	for _, chunk := range chunks {
+		if chunk.blocks > 0 {
+			reader.chunks = append(reader.chunks, chunk)
+		}
+	}
+	return reader, nil
+}
+
+func (s *sparseReader) Size() int64 {
+	return s.size
+}
+
+func (s *sparseReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+	n := 0
+	for n < len(p) {
+		if off >= s.size {
+			return n, io.EOF
+		}
+		i := sort.Search(len(s.chunks), func(i int) bool {
+			chunk := s.chunks[i]
+			return (int64(chunk.startBlock)+int64(chunk.blocks))*s.blockSize > off
+		})
+		if i == len(s.chunks) {
+			// Blocks past the last chunk read as zeros.
+			clear(p[n:])
+			return len(p), nil
+		}
+		chunk := s.chunks[i]
+		start := int64(chunk.startBlock) * s.blockSize
+		rel := off - start
+		count := int(min(int64(len(p)-n), int64(chunk.blocks)*s.blockSize-rel))
+		dst := p[n : n+count]
+
+		switch chunk.kind {
+		case chunkTypeRaw:
+			if _, err := s.file.ReadAt(dst, chunk.offset+rel); err != nil {
+				return n, err
+			}
+		case chunkTypeFill:
+			var pattern [4]byte
+			binary.LittleEndian.PutUint32(pattern[:], chunk.fill)
+			for j := range dst {
+				dst[j] = pattern[(rel+int64(j))%4]
+			}
+		default:
+			clear(dst)
+		}
+		n += count
+		off += int64(count)
+	}
+	return n, nil
+}
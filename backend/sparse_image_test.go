@@ -0,0 +1,153 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testRawImage is 40 blocks of mixed content: random data that must be
+// stored RAW, zero and pattern runs that become FILL chunks, and a last
+// partial block.
+func testRawImage() []byte {
+	rng := rand.New(rand.NewSource(1))
+	var image []byte
+	random := func(blocks int) {
+		data := make([]byte, blocks*sparseBlockSize)
+		rng.Read(data)
+		image = append(image, data...)
+	}
+	random(10)
+	image = append(image, make([]byte, 8*sparseBlockSize)...)
+	random(12)
+	image = append(image, bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 6*sparseBlockSize/4)...)
+	random(3)
+	return append(image, []byte("partial")...)
+}
+
+// applySparse writes the chunks of a sparse file over image, as a device
+// does when it flashes one; DONT_CARE blocks keep what image had.
+func applySparse(t *testing.T, path string, image []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, chunks, err := readSparseImage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("%s: %v", filepath.Base(path), err)
+	}
+	if want := int64(header.totalBlocks) * int64(header.blockSize); int64(len(image)) != want {
+		t.Fatalf("%s covers %d bytes, want %d", filepath.Base(path), want, len(image))
+	}
+	for _, chunk := range chunks {
+		start := int64(chunk.startBlock) * int64(header.blockSize)
+		end := start + int64(chunk.blocks)*int64(header.blockSize)
+		switch chunk.kind {
+		case chunkTypeRaw:
+			copy(image[start:end], data[chunk.offset:chunk.offset+chunk.dataSize])
+		case chunkTypeFill:
+			for off := start; off < end; off += 4 {
+				binary.LittleEndian.PutUint32(image[off:], chunk.fill)
+			}
+		}
+	}
+}
+
+func TestSparseRoundTrip(t *testing.T) {
+	raw := testRawImage()
+	// The sparse image is padded to whole blocks with zeroes.
+	want := append(append([]byte(nil), raw...), make([]byte, sparseBlockSize-len("partial"))...)
+	dir := t.TempDir()
+	rawPath := filepath.Join(dir, "system.img")
+	if err := os.WriteFile(rawPath, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := testContext(t)
+
+	sparsePath := filepath.Join(dir, "system.sparse")
+	if err := rawToSparse(ctx, rawPath, sparsePath, func(float64) {}); err != nil {
+		t.Fatal(err)
+	}
+	sparse, err := os.ReadFile(sparsePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, chunks, err := readSparseImage(bytes.NewReader(sparse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[uint16]int{}
+	for _, chunk := range chunks {
+		kinds[chunk.kind]++
+	}
+	if kinds[chunkTypeRaw] == 0 || kinds[chunkTypeFill] != 2 {
+		t.Errorf("chunk kinds = %v, want RAW chunks and 2 FILL chunks", kinds)
+	}
+	if len(sparse) >= len(raw) {
+		t.Errorf("sparse image is %d bytes, raw %d", len(sparse), len(raw))
+	}
+
+	expanded := filepath.Join(dir, "expanded.img")
+	if err := sparseToRaw(ctx, sparsePath, expanded, func(float64) {}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(expanded); !bytes.Equal(got, want) {
+		t.Error("sparse to raw does not give back the raw image")
+	}
+
+	for _, source := range []string{sparsePath, rawPath} {
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			// Room for about five blocks of data per piece.
+			const maxSize = 5*sparseBlockSize + 200
+			pieces, err := splitSparseImage(ctx, source, maxSize, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pieces) < 5 {
+				t.Errorf("split into %d pieces, want at least 5", len(pieces))
+			}
+
+			// Start from garbage, so any block no piece writes shows up.
+			got := bytes.Repeat([]byte{0x55}, len(want))
+			for _, piece := range pieces {
+				if stat, err := os.Stat(piece); err != nil || stat.Size() > maxSize {
+					t.Fatalf("%s: %v, size over %d", filepath.Base(piece), err, maxSize)
+				}
+				applySparse(t, piece, got)
+			}
+			if !bytes.Equal(got, want) {
+				t.Error("flashing the pieces in order does not give back the raw image")
+			}
+		})
+	}
+}
+
+func TestSplitForDownload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "system.img")
+	if err := os.WriteFile(path, testRawImage(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The target device is some other phone; the job's serial must win.
+	a := &App{targetSerial: "other"}
+	ctx := testContext(t)
+
+	small := startFakeFastboot(t, map[string]string{"max-download-size": "0x8000"})
+	pieces, cleanup, err := a.splitForDownload(ctx, small.serial, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if len(pieces) < 2 {
+		t.Errorf("pieces = %v, want the image split", pieces)
+	}
+
+	unknown := startFakeFastboot(t, map[string]string{})
+	pieces, _, err = a.splitForDownload(ctx, unknown.serial, path)
+	if err != nil || len(pieces) != 1 || pieces[0] != path {
+		t.Errorf("without max-download-size: pieces = %v, %v; want the image itself", pieces, err)
+	}
+}
@@ -0,0 +1,451 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	lpGeometryMagic = 0x616c4467
+	lpHeaderMagic   = 0x414c5030
+	// The geometry follows a reserved area and is stored twice.
+	lpReservedBytes = 4096
+	lpGeometrySize  = 4096
+	lpSectorSize    = 512
+
+	lpPartitionEntrySize   = 52
+	lpExtentEntrySize      = 24
+	lpGroupEntrySize       = 48
+	lpBlockDeviceEntrySize = 64
+
+	lpTargetLinear = 0
+	lpTargetZero   = 1
+)
+
+var lpPartitionAttributes = []string{"readonly", "slot-suffixed", "updated", "disabled"}
+
+type SuperPartition struct {
+	Name  string
+	Group string
+	Size  int64
+	// Attributes lists flags such as readonly and slot-suffixed.
+	Attributes []string
+	Extents    int
+}
+
+type SuperGroup struct {
+	Name string
+	// MaximumSize is 0 when the group is unlimited.
+	MaximumSize int64
+}
+
+type SuperBlockDevice struct {
+	Name string
+	Size int64
+}
+
+type SuperImageInfo struct {
+	Path            string
+	IsSparse        bool
+	MetadataVersion string
+	MetadataMaxSize int
+	SlotCount       int
+	BlockDevices    []SuperBlockDevice
+	Groups          []SuperGroup
+	Partitions      []SuperPartition
+}
+
+type lpExtent struct {
+	sectors     uint64
+	targetType  uint32
+	targetData  uint64
+	targetIndex uint32
+}
+
+type lpPartition struct {
+	name    string
+	group   string
+	extents []lpExtent
+}
+
+func (p lpPartition) size() int64 {
+	var sectors uint64
+	for _, extent := range p.extents {
+		sectors += extent.sectors
+	}
+	return int64(sectors * lpSectorSize)
+}
+
+// superImage is an opened super.img with its slot 0 metadata, the slot
+// lpunpack reads by default.
+type superImage struct {
+	file       *os.File
+	reader     io.ReaderAt
+	sparse     bool
+	info       SuperImageInfo
+	partitions []lpPartition
+}
+
+// InspectSuperImage lists the logical partitions, groups and block devices
+// described by the LP metadata of a raw or sparse super.img.
+func (a *App) InspectSuperImage(path string) (SuperImageInfo, error) {
+	image, err := openSuperImage(path)
+	if err != nil {
+		return SuperImageInfo{}, err
+	}
+	defer image.Close()
+	return image.info, nil
+}
+
+// ExtractSuperPartitions writes the selected logical partitions to
+// outputDir/<name>.img as a job and returns the job id. An empty selection
+// extracts every partition that has data, like lpunpack.
+func (a *App) ExtractSuperPartitions(path string, partitions []string, outputDir string) (string, error) {
+	if outputDir == "" {
+		return "", fmt.Errorf("no output folder selected")
+	}
+	image, err := openSuperImage(path)
+	if err != nil {
+		return "", err
+	}
+
+	selected, err := image.selectPartitions(partitions)
+	if err != nil {
+		image.Close()
+		return "", err
+	}
+
+	job := a.jobs.start(nil, "super-extract", "Extract "+filepath.Base(path), 0)
+	go func() {
+		defer image.Close()
+		result, err := image.extract(job, selected, outputDir)
+		job.finish(result, err)
+	}()
+	return job.id, nil
+}
+
+func openSuperImage(path string) (*superImage, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no super image selected")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open super image: %w", err)
+	}
+
+	image := &superImage{file: file, reader: file}
+	sparse, err := newSparseReader(file)
+	if err == nil {
+		image.reader = sparse
+		image.sparse = true
+	} else if !errors.Is(err, errNotSparse) {
+		file.Close()
+		return nil, err
+	}
+
+	if err := image.readMetadata(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	image.info.Path = path
+	image.info.IsSparse = image.sparse
+	return image, nil
+}
+
+func (s *superImage) readMetadata() error {
+	maxSize, slotCount, err := s.readGeometry()
+	if err != nil {
+		return err
+	}
+
+	// Primary metadata for every slot comes first, then the backups.
+	base := int64(lpReservedBytes + 2*lpGeometrySize)
+	var lastErr error
+	for _, offset := range []int64{base, base + int64(slotCount)*int64(maxSize)} {
+		data := make([]byte, maxSize)
+		if _, err := s.reader.ReadAt(data, offset); err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read LP metadata: %w", err)
+		}
+		if lastErr = s.parseMetadata(data); lastErr == nil {
+			s.info.MetadataMaxSize = int(maxSize)
+			s.info.SlotCount = int(slotCount)
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// readGeometry returns the metadata slot size and count, falling back to
+// the backup geometry when the primary copy is damaged.
+func (s *superImage) readGeometry() (uint32, uint32, error) {
+	le := binary.LittleEndian
+	buf := make([]byte, 52)
+	for _, offset := range []int64{lpReservedBytes, lpReservedBytes + lpGeometrySize} {
+		if _, err := s.reader.ReadAt(buf, offset); err != nil {
+			return 0, 0, fmt.Errorf("failed to read LP geometry: %w", err)
+		}
+		if le.Uint32(buf) != lpGeometryMagic {
+			continue
+		}
+		structSize := le.Uint32(buf[4:])
+		if structSize < 52 || structSize > lpGeometrySize {
+			continue
+		}
+		geometry := make([]byte, structSize)
+		if _, err := s.reader.ReadAt(geometry, offset); err != nil {
+			return 0, 0, fmt.Errorf("failed to read LP geometry: %w", err)
+		}
+		if !lpChecksumValid(geometry, 8) {
+			continue
+		}
+		maxSize := le.Uint32(geometry[40:])
+		slotCount := le.Uint32(geometry[44:])
+		if maxSize == 0 || maxSize%lpSectorSize != 0 || maxSize > 1<<20 || slotCount == 0 || slotCount > 8 {
+			return 0, 0, fmt.Errorf("invalid LP geometry")
+		}
+		return maxSize, slotCount, nil
+	}
+	return 0, 0, fmt.Errorf("no valid LP metadata geometry found; this is not a super image")
+}
+
+// lpChecksumValid checks the SHA-256 stored at data[at:at+32], which is
+// computed with that field zeroed.
+func lpChecksumValid(data []byte, at int) bool {
+	stored := append([]byte(nil), data[at:at+32]...)
+	copy(data[at:at+32], make([]byte, 32))
+	sum := sha256.Sum256(data)
+	copy(data[at:at+32], stored)
+	return bytes.Equal(sum[:], stored)
+}
+
+func (s *superImage) parseMetadata(data []byte) error {
+	le := binary.LittleEndian
+	if len(data) < 128 || le.Uint32(data) != lpHeaderMagic {
+		return fmt.Errorf("invalid LP metadata header")
+	}
+	major, minor := le.Uint16(data[4:]), le.Uint16(data[6:])
+	headerSize := le.Uint32(data[8:])
+	tablesSize := le.Uint32(data[44:])
+	if headerSize < 128 || uint64(headerSize)+uint64(tablesSize) > uint64(len(data)) {
+		return fmt.Errorf("LP metadata header is out of range")
+	}
+	if !lpChecksumValid(data[:headerSize], 12) {
+		return fmt.Errorf("LP metadata header checksum mismatch")
+	}
+	tables := data[headerSize : headerSize+tablesSize]
+	if sum := sha256.Sum256(tables); !bytes.Equal(sum[:], data[48:80]) {
+		return fmt.Errorf("LP metadata tables checksum mismatch")
+	}
+
+	table := func(at int, minEntry uint32) ([][]byte, error) {
+		offset, count, entrySize := le.Uint32(data[at:]), le.Uint32(data[at+4:]), le.Uint32(data[at+8:])
+		if entrySize < minEntry || uint64(offset)+uint64(count)*uint64(entrySize) > uint64(len(tables)) {
+			return nil, fmt.Errorf("LP metadata table is out of range")
+		}
+		entries := make([][]byte, count)
+		for i := range entries {
+			start := offset + uint32(i)*entrySize
+			entries[i] = tables[start : start+entrySize]
+		}
+		return entries, nil
+	}
+	partitionEntries, err := table(80, lpPartitionEntrySize)
+	if err != nil {
+		return err
+	}
+	extentEntries, err := table(92, lpExtentEntrySize)
+	if err != nil {
+		return err
+	}
+	groupEntries, err := table(104, lpGroupEntrySize)
+	if err != nil {
+		return err
+	}
+	deviceEntries, err := table(116, lpBlockDeviceEntrySize)
+	if err != nil {
+		return err
+	}
+
+	info := SuperImageInfo{MetadataVersion: fmt.Sprintf("%d.%d", major, minor)}
+	for _, entry := range groupEntries {
+		info.Groups = append(info.Groups, SuperGroup{
+			Name:        cString(entry[:36]),
+			MaximumSize: int64(le.Uint64(entry[40:])),
+		})
+	}
+	for _, entry := range deviceEntries {
+		info.BlockDevices = append(info.BlockDevices, SuperBlockDevice{
+			Name: cString(entry[24:60]),
+			Size: int64(le.Uint64(entry[16:])),
+		})
+	}
+
+	var partitions []lpPartition
+	for _, entry := range partitionEntries {
+		attributes := le.Uint32(entry[36:])
+		first, count := le.Uint32(entry[40:]), le.Uint32(entry[44:])
+		groupIndex := le.Uint32(entry[48:])
+		if uint64(first)+uint64(count) > uint64(len(extentEntries)) || int(groupIndex) >= len(info.Groups) {
+			return fmt.Errorf("LP partition entry is out of range")
+		}
+
+		partition := lpPartition{name: cString(entry[:36]), group: info.Groups[groupIndex].Name}
+		for _, extent := range extentEntries[first : first+count] {
+			partition.extents = append(partition.extents, lpExtent{
+				sectors:     le.Uint64(extent),
+				targetType:  le.Uint32(extent[8:]),
+				targetData:  le.Uint64(extent[12:]),
+				targetIndex: le.Uint32(extent[20:]),
+			})
+		}
+		partitions = append(partitions, partition)
+
+		summary := SuperPartition{
+			Name:       partition.name,
+			Group:      partition.group,
+			Size:       partition.size(),
+			Attributes: []string{},
+			Extents:    len(partition.extents),
+		}
+		for bit, name := range lpPartitionAttributes {
+			if attributes&(1<<bit) != 0 {
+				summary.Attributes = append(summary.Attributes, name)
+			}
+		}
+		info.Partitions = append(info.Partitions, summary)
+	}
+
+	s.info = info
+	s.partitions = partitions
+	return nil
+}
+
+func (s *superImage) selectPartitions(names []string) ([]lpPartition, error) {
+	if len(names) == 0 {
+		var selected []lpPartition
+		for _, partition := range s.partitions {
+			if partition.size() > 0 {
+				selected = append(selected, partition)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("the super image has no partitions with data")
+		}
+		return selected, nil
+	}
+
+	var selected []lpPartition
+	for _, name := range names {
+		found := false
+		for _, partition := range s.partitions {
+			if partition.name == name {
+				selected = append(selected, partition)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("partition %s not found in super image", name)
+		}
+	}
+	for _, partition := range selected {
+		for _, extent := range partition.extents {
+			// Retrofit devices spread super over several block devices;
+			// only the first one is in this file.
+			if extent.targetType == lpTargetLinear && extent.targetIndex != 0 {
+				return nil, fmt.Errorf("%s lives on block device %d, which is not part of this image", partition.name, extent.targetIndex)
+			}
+		}
+	}
+	return selected, nil
+}
+
+func (s *superImage) extract(job *jobHandle, partitions []lpPartition, outputDir string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output folder: %w", err)
+	}
+
+	var total, done int64
+	for _, partition := range partitions {
+		total += partition.size()
+	}
+
+	var written []string
+	for _, partition := range partitions {
+		target := filepath.Join(outputDir, partition.name+".img")
+		err := s.extractPartition(job.ctx, partition, target, func(n int64) {
+			done += n
+			if total > 0 {
+				job.setProgress(float64(done) / float64(total) * 100)
+			}
+		})
+		if err != nil {
+			os.Remove(target)
+			if ctxErr := contextError(job.ctx, "extract "+partition.name); ctxErr != nil {
+				return "", ctxErr
+			}
+			return "", fmt.Errorf("failed to extract %s: %w", partition.name, err)
+		}
+		written = append(written, partition.name+".img")
+	}
+	return fmt.Sprintf("Extracted %s to %s", strings.Join(written, ", "), outputDir), nil
+}
+
+func (s *superImage) extractPartition(ctx context.Context, partition lpPartition, target string, onData func(int64)) error {
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := out.Truncate(partition.size()); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1<<20)
+	position := int64(0)
+	for _, extent := range partition.extents {
+		length := int64(extent.sectors * lpSectorSize)
+		if extent.targetType != lpTargetLinear {
+			// ZERO extents are already zero after Truncate.
+			position += length
+			onData(length)
+			continue
+		}
+
+		source := io.NewSectionReader(s.reader, int64(extent.targetData*lpSectorSize), length)
+		for copied := int64(0); copied < length; {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			n, err := source.Read(buf[:min(int64(len(buf)), length-copied)])
+			if n > 0 {
+				if _, err := out.WriteAt(buf[:n], position); err != nil {
+					return err
+				}
+				copied += int64(n)
+				position += int64(n)
+				onData(int64(n))
+			}
+			if err == io.EOF && copied < length {
+				return fmt.Errorf("image is truncated")
+			}
+			if err != nil && err != io.EOF {
+				return err
+			}
+		}
+	}
+	return out.Close()
+}
+
+func (s *superImage) Close() error {
+	return s.file.Close()
+}
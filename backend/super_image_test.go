@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testSuperMetadataSize = 4096
+	testSuperSlots        = 2
+	// Partition data starts after the primary and backup metadata.
+	testSuperDataSector = (lpReservedBytes + 2*lpGeometrySize + 2*testSuperSlots*testSuperMetadataSize) / lpSectorSize
+)
+
+func testLpGeometry() []byte {
+	geometry := make([]byte, 52)
+	le := binary.LittleEndian
+	le.PutUint32(geometry, lpGeometryMagic)
+	le.PutUint32(geometry[4:], 52)
+	le.PutUint32(geometry[40:], testSuperMetadataSize)
+	le.PutUint32(geometry[44:], testSuperSlots)
+	le.PutUint32(geometry[48:], 4096)
+	sum := sha256.Sum256(geometry)
+	copy(geometry[8:], sum[:])
+	return geometry
+}
+
+// testLpMetadata builds a version 10.0 metadata blob: system_a made of a
+// LINEAR, a ZERO and another LINEAR extent, and an empty vendor_a.
+func testLpMetadata() []byte {
+	le := binary.LittleEndian
+	extent := func(sectors uint64, targetType uint32, targetData uint64) []byte {
+		entry := make([]byte, lpExtentEntrySize)
+		le.PutUint64(entry, sectors)
+		le.PutUint32(entry[8:], targetType)
+		le.PutUint64(entry[12:], targetData)
+		return entry
+	}
+	partition := func(name string, attributes, firstExtent, extents, group uint32) []byte {
+		entry := make([]byte, lpPartitionEntrySize)
+		copy(entry, name)
+		le.PutUint32(entry[36:], attributes)
+		le.PutUint32(entry[40:], firstExtent)
+		le.PutUint32(entry[44:], extents)
+		le.PutUint32(entry[48:], group)
+		return entry
+	}
+	group := func(name string, maxSize uint64) []byte {
+		entry := make([]byte, lpGroupEntrySize)
+		copy(entry, name)
+		le.PutUint64(entry[40:], maxSize)
+		return entry
+	}
+	device := make([]byte, lpBlockDeviceEntrySize)
+	le.PutUint64(device[16:], 8<<20)
+	copy(device[24:], "super")
+
+	sections := [][][]byte{
+		{partition("system_a", 1|2, 0, 3, 1), partition("vendor_a", 2, 3, 0, 1)},
+		{extent(2, lpTargetLinear, testSuperDataSector), extent(1, lpTargetZero, 0), extent(1, lpTargetLinear, testSuperDataSector+3)},
+		{group("default", 0), group("main_a", 4<<20)},
+		{device},
+	}
+	entrySizes := []int{lpPartitionEntrySize, lpExtentEntrySize, lpGroupEntrySize, lpBlockDeviceEntrySize}
+
+	header := make([]byte, 128)
+	le.PutUint32(header, lpHeaderMagic)
+	le.PutUint16(header[4:], 10)
+	le.PutUint32(header[8:], 128)
+	var tables []byte
+	for i, entries := range sections {
+		le.PutUint32(header[80+12*i:], uint32(len(tables)))
+		le.PutUint32(header[84+12*i:], uint32(len(entries)))
+		le.PutUint32(header[88+12*i:], uint32(entrySizes[i]))
+		for _, entry := range entries {
+			tables = append(tables, entry...)
+		}
+	}
+	le.PutUint32(header[44:], uint32(len(tables)))
+	tablesSum := sha256.Sum256(tables)
+	copy(header[48:], tablesSum[:])
+	headerSum := sha256.Sum256(header)
+	copy(header[12:], headerSum[:])
+	return append(header, tables...)
+}
+
+// testSuperData is the data of the two LINEAR extents of system_a.
+var testSuperData = [][]byte{
+	bytes.Repeat([]byte("ab"), lpSectorSize),
+	bytes.Repeat([]byte("c"), lpSectorSize),
+}
+
+// buildTestSuper lays out a super image: geometry and its backup, slot 0
+// metadata and its backup, then the partition data.
+func buildTestSuper() []byte {
+	image := make([]byte, testSuperDataSector*lpSectorSize)
+	geometry := testLpGeometry()
+	copy(image[lpReservedBytes:], geometry)
+	copy(image[lpReservedBytes+lpGeometrySize:], geometry)
+	metadata := testLpMetadata()
+	primary := lpReservedBytes + 2*lpGeometrySize
+	copy(image[primary:], metadata)
+	copy(image[primary+testSuperSlots*testSuperMetadataSize:], metadata)
+	image = append(image, testSuperData[0]...)
+	// No extent maps this sector, so it must not show up.
+	image = append(image, bytes.Repeat([]byte("x"), lpSectorSize)...)
+	return append(image, testSuperData[1]...)
+}
+
+func TestSuperImageMetadata(t *testing.T) {
+	primaryGeometry := lpReservedBytes + 20
+	backupGeometry := lpReservedBytes + lpGeometrySize + 20
+	primaryHeader := lpReservedBytes + 2*lpGeometrySize + 20
+	backupHeader := primaryHeader + testSuperSlots*testSuperMetadataSize
+	// The tables follow the 128-byte header.
+	primaryTables := primaryHeader - 20 + 128
+	backupTables := backupHeader - 20 + 128
+
+	tests := []struct {
+		name    string
+		corrupt []int
+		wantErr string
+	}{
+		{name: "intact"},
+		{name: "primary geometry damaged", corrupt: []int{primaryGeometry}},
+		{name: "primary header damaged", corrupt: []int{primaryHeader}},
+		{name: "primary tables damaged", corrupt: []int{primaryTables}},
+		{name: "both geometries damaged", corrupt: []int{primaryGeometry, backupGeometry}, wantErr: "not a super image"},
+		{name: "both headers damaged", corrupt: []int{primaryHeader, backupHeader}, wantErr: "header checksum mismatch"},
+		{name: "both tables damaged", corrupt: []int{primaryTables, backupTables}, wantErr: "tables checksum mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildTestSuper()
+			for _, at := range tt.corrupt {
+				data[at] ^= 0xff
+			}
+			image := &superImage{reader: bytes.NewReader(data)}
+			err := image.readMetadata()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := SuperImageInfo{
+				MetadataVersion: "10.0",
+				MetadataMaxSize: testSuperMetadataSize,
+				SlotCount:       testSuperSlots,
+				BlockDevices:    []SuperBlockDevice{{Name: "super", Size: 8 << 20}},
+				Groups:          []SuperGroup{{Name: "default"}, {Name: "main_a", MaximumSize: 4 << 20}},
+				Partitions: []SuperPartition{
+					{Name: "system_a", Group: "main_a", Size: 4 * lpSectorSize, Attributes: []string{"readonly", "slot-suffixed"}, Extents: 3},
+					{Name: "vendor_a", Group: "main_a", Attributes: []string{"slot-suffixed"}},
+				},
+			}
+			if !reflect.DeepEqual(image.info, want) {
+				t.Errorf("info = %+v\nwant   %+v", image.info, want)
+			}
+		})
+	}
+}
+
+func TestSuperImageExtract(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "super.img")
+	if err := os.WriteFile(path, buildTestSuper(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	image, err := openSuperImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer image.Close()
+
+	// An empty selection skips vendor_a, which has no data.
+	selected, err := image.selectPartitions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 1 || selected[0].name != "system_a" {
+		t.Fatalf("selected %+v, want system_a", selected)
+	}
+
+	target := filepath.Join(t.TempDir(), "system_a.img")
+	var reported int64
+	if err := image.extractPartition(testContext(t), selected[0], target, func(n int64) { reported += n }); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(append([]byte(nil), testSuperData[0]...), make([]byte, lpSectorSize)...)
+	want = append(want, testSuperData[1]...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("extracted %d bytes that differ from the LINEAR and ZERO extents", len(got))
+	}
+	if reported != int64(len(want)) {
+		t.Errorf("reported %d bytes, want %d", reported, len(want))
+	}
+
+	if _, err := image.selectPartitions([]string{"odm_a"}); err == nil {
+		t.Error("selecting a missing partition succeeded")
+	}
+}
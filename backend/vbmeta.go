@@ -0,0 +1,353 @@
+package backend
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+const (
+	avbMagic       = "AVB0"
+	avbFooterMagic = "AVBf"
+	avbHeaderSize  = 256
+	avbFooterSize  = 64
+	// vbmeta structs are small; anything larger is not a vbmeta image.
+	maxVbmetaSize = 64 << 10
+
+	avbFlagHashtreeDisabled     = 1
+	avbFlagVerificationDisabled = 2
+)
+
+const (
+	VbmetaDescriptorProperty = "property"
+	VbmetaDescriptorHashtree = "hashtree"
+	VbmetaDescriptorHash     = "hash"
+	VbmetaDescriptorCmdline  = "kernel_cmdline"
+	VbmetaDescriptorChain    = "chain_partition"
+)
+
+var avbAlgorithms = []string{
+	"NONE",
+	"SHA256_RSA2048",
+	"SHA256_RSA4096",
+	"SHA256_RSA8192",
+	"SHA512_RSA2048",
+	"SHA512_RSA4096",
+	"SHA512_RSA8192",
+}
+
+var avbDescriptorTypes = []string{
+	VbmetaDescriptorProperty,
+	VbmetaDescriptorHashtree,
+	VbmetaDescriptorHash,
+	VbmetaDescriptorCmdline,
+	VbmetaDescriptorChain,
+}
+
+type VbmetaInfo struct {
+	Path string
+	// Footer is set when the vbmeta struct was found in an AVB footer at the
+	// end of a chained partition image such as boot.img.
+	Footer bool
+	// OriginalImageSize is the image size before the footer was appended.
+	OriginalImageSize     int64
+	LibavbVersion         string
+	Algorithm             string
+	RollbackIndex         uint64
+	RollbackIndexLocation int
+	Flags                 int
+	HashtreeDisabled      bool
+	VerificationDisabled  bool
+	ReleaseString         string
+	// PublicKeySHA1 is what avbtool prints as the public key digest.
+	PublicKeySHA1 string
+	Descriptors   []VbmetaDescriptor
+}
+
+// VbmetaDescriptor flattens the AVB descriptor types; fields that do not
+// apply to Type are left empty.
+type VbmetaDescriptor struct {
+	Type          string
+	Partition     string
+	ImageSize     int64
+	HashAlgorithm string
+	Salt          string
+	Digest        string
+	Flags         int
+	// RollbackIndexLocation and PublicKeySHA1 describe chain_partition
+	// descriptors.
+	RollbackIndexLocation int
+	PublicKeySHA1         string
+	Key                   string
+	Value                 string
+	Cmdline               string
+}
+
+// InspectVbmeta parses the AVB metadata of a vbmeta image or of a partition
+// image that carries an AVB footer.
+func (a *App) InspectVbmeta(filePath string) (VbmetaInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return VbmetaInfo{}, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return VbmetaInfo{}, err
+	}
+
+	info := VbmetaInfo{Path: filePath}
+	offset := int64(0)
+	magic := make([]byte, 4)
+	if _, err := file.ReadAt(magic, 0); err != nil {
+		return VbmetaInfo{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	if string(magic) != avbMagic {
+		if binary.LittleEndian.Uint32(magic) == sparseMagic {
+			return VbmetaInfo{}, fmt.Errorf("sparse images must be converted to raw before reading AVB metadata")
+		}
+		footerOffset, footerSize, originalSize, err := readAvbFooter(file, stat.Size())
+		if err != nil {
+			return VbmetaInfo{}, err
+		}
+		info.Footer = true
+		info.OriginalImageSize = originalSize
+		offset = footerOffset
+		if footerSize < avbHeaderSize || footerSize > maxVbmetaSize {
+			return VbmetaInfo{}, fmt.Errorf("AVB footer reports an invalid vbmeta size %d", footerSize)
+		}
+	}
+
+	data := make([]byte, maxVbmetaSize)
+	n, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return VbmetaInfo{}, fmt.Errorf("failed to read vbmeta: %w", err)
+	}
+	if err := parseVbmeta(data[:n], &info); err != nil {
+		return VbmetaInfo{}, err
+	}
+	return info, nil
+}
+
+// readAvbFooter returns the vbmeta location recorded in the footer at the end
+// of a chained partition image.
+func readAvbFooter(r io.ReaderAt, size int64) (int64, int64, int64, error) {
+	if size < avbFooterSize {
+		return 0, 0, 0, fmt.Errorf("not a vbmeta image and no AVB footer found")
+	}
+	footer := make([]byte, avbFooterSize)
+	if _, err := r.ReadAt(footer, size-avbFooterSize); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to read AVB footer: %w", err)
+	}
+	if string(footer[:4]) != avbFooterMagic {
+		return 0, 0, 0, fmt.Errorf("not a vbmeta image and no AVB footer found")
+	}
+	originalSize := int64(binary.BigEndian.Uint64(footer[12:]))
+	offset := int64(binary.BigEndian.Uint64(footer[20:]))
+	vbmetaSize := int64(binary.BigEndian.Uint64(footer[28:]))
+	if offset < 0 || offset >= size {
+		return 0, 0, 0, fmt.Errorf("AVB footer points outside the image")
+	}
+	return offset, vbmetaSize, originalSize, nil
+}
+
+func parseVbmeta(data []byte, info *VbmetaInfo) error {
+	if len(data) < avbHeaderSize || string(data[:4]) != avbMagic {
+		return fmt.Errorf("not a vbmeta image")
+	}
+	be := binary.BigEndian
+
+	info.LibavbVersion = fmt.Sprintf("%d.%d", be.Uint32(data[4:]), be.Uint32(data[8:]))
+	authSize := be.Uint64(data[12:])
+	auxSize := be.Uint64(data[20:])
+	algorithm := be.Uint32(data[28:])
+	publicKeyOffset := be.Uint64(data[64:])
+	publicKeySize := be.Uint64(data[72:])
+	descriptorsOffset := be.Uint64(data[96:])
+	descriptorsSize := be.Uint64(data[104:])
+	info.RollbackIndex = be.Uint64(data[112:])
+	info.Flags = int(be.Uint32(data[120:]))
+	info.RollbackIndexLocation = int(be.Uint32(data[124:]))
+	info.ReleaseString = cString(data[128:176])
+
+	info.Algorithm = "UNKNOWN(" + strconv.Itoa(int(algorithm)) + ")"
+	if int(algorithm) < len(avbAlgorithms) {
+		info.Algorithm = avbAlgorithms[algorithm]
+	}
+	info.HashtreeDisabled = info.Flags&avbFlagHashtreeDisabled != 0
+	info.VerificationDisabled = info.Flags&avbFlagVerificationDisabled != 0
+
+	blocks := uint64(len(data) - avbHeaderSize)
+	if authSize > blocks || auxSize > blocks-authSize {
+		return fmt.Errorf("vbmeta blocks exceed the image")
+	}
+	auxStart := avbHeaderSize + authSize
+	aux := data[auxStart : auxStart+auxSize]
+
+	if publicKeySize > 0 {
+		if publicKeyOffset > uint64(len(aux)) || publicKeySize > uint64(len(aux))-publicKeyOffset {
+			return fmt.Errorf("vbmeta public key exceeds the auxiliary block")
+		}
+		info.PublicKeySHA1 = sha1Hex(aux[publicKeyOffset : publicKeyOffset+publicKeySize])
+	}
+
+	if descriptorsOffset > uint64(len(aux)) || descriptorsSize > uint64(len(aux))-descriptorsOffset {
+		return fmt.Errorf("vbmeta descriptors exceed the auxiliary block")
+	}
+	descriptors, err := parseAvbDescriptors(aux[descriptorsOffset : descriptorsOffset+descriptorsSize])
+	if err != nil {
+		return err
+	}
+	info.Descriptors = descriptors
+	return nil
+}
+
+func parseAvbDescriptors(data []byte) ([]VbmetaDescriptor, error) {
+	be := binary.BigEndian
+	descriptors := []VbmetaDescriptor{}
+	for len(data) >= 16 {
+		tag := be.Uint64(data)
+		length := be.Uint64(data[8:])
+		if length > uint64(len(data)-16) {
+			return nil, fmt.Errorf("truncated AVB descriptor")
+		}
+		body := data[16 : 16+length]
+		data = data[16+length:]
+
+		if tag >= uint64(len(avbDescriptorTypes)) {
+			// Unknown descriptors are skipped, as libavb does.
+			continue
+		}
+		descriptor := VbmetaDescriptor{Type: avbDescriptorTypes[tag]}
+		var ok bool
+		switch descriptor.Type {
+		case VbmetaDescriptorProperty:
+			ok = parsePropertyDescriptor(body, &descriptor)
+		case VbmetaDescriptorHashtree:
+			ok = parseHashtreeDescriptor(body, &descriptor)
+		case VbmetaDescriptorHash:
+			ok = parseHashDescriptor(body, &descriptor)
+		case VbmetaDescriptorCmdline:
+			ok = parseCmdlineDescriptor(body, &descriptor)
+		case VbmetaDescriptorChain:
+			ok = parseChainDescriptor(body, &descriptor)
+		}
+		if !ok {
+			return nil, fmt.Errorf("malformed %s descriptor", descriptor.Type)
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	return descriptors, nil
+}
+
+func parsePropertyDescriptor(body []byte, d *VbmetaDescriptor) bool {
+	if len(body) < 16 {
+		return false
+	}
+	keySize := binary.BigEndian.Uint64(body)
+	valueSize := binary.BigEndian.Uint64(body[8:])
+	fields, ok := splitAvbFields(body[16:], keySize, 1, valueSize)
+	if !ok {
+		return false
+	}
+	d.Key = string(fields[0])
+	d.Value = string(fields[2])
+	return true
+}
+
+func parseHashtreeDescriptor(body []byte, d *VbmetaDescriptor) bool {
+	const fixed = 164
+	if len(body) < fixed {
+		return false
+	}
+	be := binary.BigEndian
+	d.ImageSize = int64(be.Uint64(body[4:]))
+	d.HashAlgorithm = cString(body[56:88])
+	nameSize := be.Uint32(body[88:])
+	saltSize := be.Uint32(body[92:])
+	digestSize := be.Uint32(body[96:])
+	d.Flags = int(be.Uint32(body[100:]))
+	return setHashFields(body[fixed:], nameSize, saltSize, digestSize, d)
+}
+
+func parseHashDescriptor(body []byte, d *VbmetaDescriptor) bool {
+	const fixed = 116
+	if len(body) < fixed {
+		return false
+	}
+	be := binary.BigEndian
+	d.ImageSize = int64(be.Uint64(body))
+	d.HashAlgorithm = cString(body[8:40])
+	nameSize := be.Uint32(body[40:])
+	saltSize := be.Uint32(body[44:])
+	digestSize := be.Uint32(body[48:])
+	d.Flags = int(be.Uint32(body[52:]))
+	return setHashFields(body[fixed:], nameSize, saltSize, digestSize, d)
+}
+
+func setHashFields(rest []byte, nameSize, saltSize, digestSize uint32, d *VbmetaDescriptor) bool {
+	fields, ok := splitAvbFields(rest, uint64(nameSize), uint64(saltSize), uint64(digestSize))
+	if !ok {
+		return false
+	}
+	d.Partition = string(fields[0])
+	d.Salt = hex.EncodeToString(fields[1])
+	d.Digest = hex.EncodeToString(fields[2])
+	return true
+}
+
+func parseCmdlineDescriptor(body []byte, d *VbmetaDescriptor) bool {
+	if len(body) < 8 {
+		return false
+	}
+	d.Flags = int(binary.BigEndian.Uint32(body))
+	size := binary.BigEndian.Uint32(body[4:])
+	fields, ok := splitAvbFields(body[8:], uint64(size))
+	if !ok {
+		return false
+	}
+	d.Cmdline = string(fields[0])
+	return true
+}
+
+func parseChainDescriptor(body []byte, d *VbmetaDescriptor) bool {
+	const fixed = 76
+	if len(body) < fixed {
+		return false
+	}
+	be := binary.BigEndian
+	d.RollbackIndexLocation = int(be.Uint32(body))
+	nameSize := be.Uint32(body[4:])
+	keySize := be.Uint32(body[8:])
+	d.Flags = int(be.Uint32(body[12:]))
+	fields, ok := splitAvbFields(body[fixed:], uint64(nameSize), uint64(keySize))
+	if !ok {
+		return false
+	}
+	d.Partition = string(fields[0])
+	d.PublicKeySHA1 = sha1Hex(fields[1])
+	return true
+}
+
+// splitAvbFields cuts data into consecutive fields of the given sizes.
+func splitAvbFields(data []byte, sizes ...uint64) ([][]byte, bool) {
+	fields := make([][]byte, 0, len(sizes))
+	for _, size := range sizes {
+		if size > uint64(len(data)) {
+			return nil, false
+		}
+		fields = append(fields, data[:size])
+		data = data[size:]
+	}
+	return fields, true
+}
+
+func sha1Hex(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
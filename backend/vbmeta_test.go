@@ -0,0 +1,201 @@
+package backend
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAvbDescriptor(tag uint64, body []byte) []byte {
+	out := binary.BigEndian.AppendUint64(nil, tag)
+	out = binary.BigEndian.AppendUint64(out, uint64(len(body)))
+	return append(out, body...)
+}
+
+func testPropertyDescriptor(key, value string) []byte {
+	body := binary.BigEndian.AppendUint64(nil, uint64(len(key)))
+	body = binary.BigEndian.AppendUint64(body, uint64(len(value)))
+	body = append(body, key...)
+	body = append(body, 0)
+	return testAvbDescriptor(0, append(body, value...))
+}
+
+func testHashDescriptor(partition string, salt, digest []byte) []byte {
+	body := make([]byte, 116)
+	binary.BigEndian.PutUint64(body, 4096)
+	copy(body[8:], "sha256")
+	binary.BigEndian.PutUint32(body[40:], uint32(len(partition)))
+	binary.BigEndian.PutUint32(body[44:], uint32(len(salt)))
+	binary.BigEndian.PutUint32(body[48:], uint32(len(digest)))
+	body = append(body, partition...)
+	body = append(body, salt...)
+	return testAvbDescriptor(2, append(body, digest...))
+}
+
+// testVbmetaHeader describes the header fields parseVbmeta checks against
+// the blocks that follow it.
+type testVbmetaHeader struct {
+	authSize, auxSize                  uint64
+	publicKeyOffset, publicKeySize     uint64
+	descriptorsOffset, descriptorsSize uint64
+}
+
+func buildTestVbmeta(h testVbmetaHeader, blocks []byte) []byte {
+	header := make([]byte, avbHeaderSize)
+	copy(header, avbMagic)
+	be := binary.BigEndian
+	be.PutUint32(header[4:], 1)
+	be.PutUint32(header[8:], 2)
+	be.PutUint64(header[12:], h.authSize)
+	be.PutUint64(header[20:], h.auxSize)
+	be.PutUint32(header[28:], 1)
+	be.PutUint64(header[64:], h.publicKeyOffset)
+	be.PutUint64(header[72:], h.publicKeySize)
+	be.PutUint64(header[96:], h.descriptorsOffset)
+	be.PutUint64(header[104:], h.descriptorsSize)
+	be.PutUint64(header[112:], 7)
+	be.PutUint32(header[120:], avbFlagHashtreeDisabled)
+	copy(header[128:], "avbtool 1.2.0")
+	return append(header, blocks...)
+}
+
+func TestParseVbmeta(t *testing.T) {
+	descriptors := testPropertyDescriptor("com.android.build.boot.os_version", "14")
+	key := []byte("public key")
+	// 8 bytes of authentication block, then the descriptors and the key.
+	blocks := append(make([]byte, 8), descriptors...)
+	blocks = append(blocks, key...)
+	valid := testVbmetaHeader{
+		authSize:        8,
+		auxSize:         uint64(len(descriptors) + len(key)),
+		publicKeyOffset: uint64(len(descriptors)),
+		publicKeySize:   uint64(len(key)),
+		descriptorsSize: uint64(len(descriptors)),
+	}
+	const huge = ^uint64(0) - 7
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"valid", buildTestVbmeta(valid, blocks), ""},
+		{"truncated header", buildTestVbmeta(valid, blocks)[:avbHeaderSize-1], "not a vbmeta image"},
+		{"bad magic", append([]byte("AVBf"), buildTestVbmeta(valid, blocks)[4:]...), "not a vbmeta image"},
+		{"truncated blocks", buildTestVbmeta(valid, blocks[:len(blocks)-1]), "blocks exceed"},
+		{"overflowing auth size", buildTestVbmeta(testVbmetaHeader{authSize: huge, auxSize: 16}, blocks), "blocks exceed"},
+		{"overflowing aux size", buildTestVbmeta(testVbmetaHeader{authSize: 8, auxSize: huge}, blocks), "blocks exceed"},
+		{"overflowing key offset", buildTestVbmeta(testVbmetaHeader{authSize: 8, auxSize: valid.auxSize, publicKeyOffset: huge, publicKeySize: 16}, blocks), "public key exceeds"},
+		{"key past aux", buildTestVbmeta(testVbmetaHeader{authSize: 8, auxSize: valid.auxSize, publicKeyOffset: valid.publicKeyOffset, publicKeySize: valid.publicKeySize + 1}, blocks), "public key exceeds"},
+		{"overflowing descriptor offset", buildTestVbmeta(testVbmetaHeader{authSize: 8, auxSize: valid.auxSize, descriptorsOffset: huge, descriptorsSize: 16}, blocks), "descriptors exceed"},
+		{"overflowing descriptor size", buildTestVbmeta(testVbmetaHeader{authSize: 8, auxSize: valid.auxSize, descriptorsOffset: 8, descriptorsSize: huge}, blocks), "descriptors exceed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info VbmetaInfo
+			err := parseVbmeta(tt.data, &info)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.LibavbVersion != "1.2" || info.Algorithm != "SHA256_RSA2048" || info.RollbackIndex != 7 || !info.HashtreeDisabled || info.VerificationDisabled || info.ReleaseString != "avbtool 1.2.0" {
+				t.Errorf("info = %+v", info)
+			}
+			if info.PublicKeySHA1 != sha1Hex(key) {
+				t.Errorf("PublicKeySHA1 = %s, want %s", info.PublicKeySHA1, sha1Hex(key))
+			}
+			if len(info.Descriptors) != 1 || info.Descriptors[0].Value != "14" {
+				t.Errorf("descriptors = %+v", info.Descriptors)
+			}
+		})
+	}
+}
+
+func TestParseAvbDescriptors(t *testing.T) {
+	hash := testHashDescriptor("boot", []byte{0xaa}, []byte{0x01, 0x02})
+	truncatedHash := testHashDescriptor("boot", nil, nil)
+	// Claim a longer name than the descriptor holds.
+	binary.BigEndian.PutUint32(truncatedHash[16+40:], 100)
+	overflowingLength := testAvbDescriptor(0, nil)
+	binary.BigEndian.PutUint64(overflowingLength[8:], ^uint64(0))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []VbmetaDescriptor
+		wantErr string
+	}{
+		{"empty", nil, []VbmetaDescriptor{}, ""},
+		{"property", testPropertyDescriptor("key", "value"), []VbmetaDescriptor{{Type: VbmetaDescriptorProperty, Key: "key", Value: "value"}}, ""},
+		{"hash", hash, []VbmetaDescriptor{{Type: VbmetaDescriptorHash, Partition: "boot", ImageSize: 4096, HashAlgorithm: "sha256", Salt: "aa", Digest: "0102"}}, ""},
+		{"unknown tag skipped", append(testAvbDescriptor(99, []byte("x")), testPropertyDescriptor("k", "v")...), []VbmetaDescriptor{{Type: VbmetaDescriptorProperty, Key: "k", Value: "v"}}, ""},
+		{"truncated length", testPropertyDescriptor("key", "value")[:20], nil, "truncated AVB descriptor"},
+		{"overflowing length", overflowingLength, nil, "truncated AVB descriptor"},
+		{"short hash body", testAvbDescriptor(2, make([]byte, 40)), nil, "malformed hash descriptor"},
+		{"hash name past body", truncatedHash, nil, "malformed hash descriptor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAvbDescriptors(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("descriptors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlashVbmeta(t *testing.T) {
+	device := startFakeFastboot(t, map[string]string{})
+	a := &App{targetSerial: device.serial}
+	a.jobs = newJobManager(a)
+	path := writeTempImage(t, "vbmeta.img", buildTestVbmeta(testVbmetaHeader{}, nil))
+
+	id, err := a.FlashVbmeta(path, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The job keeps the device it started on.
+	a.targetMutex.Lock()
+	a.targetSerial = "other"
+	a.targetMutex.Unlock()
+
+	var job Job
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		for _, j := range a.jobs.list() {
+			if j.ID == id {
+				job = j
+			}
+		}
+		if job.Status != JobStatusRunning {
+			break
+		}
+	}
+	if job.Status != JobStatusSucceeded {
+		t.Fatalf("job = %+v", job)
+	}
+	flashed := device.image("vbmeta")
+	if len(flashed) < avbHeaderSize {
+		t.Fatalf("flashed %d bytes", len(flashed))
+	}
+	if flags := binary.BigEndian.Uint32(flashed[avbFlagsOffset:]); flags != avbFlagHashtreeDisabled|avbFlagVerificationDisabled {
+		t.Errorf("flashed flags = %d, want verity and verification disabled", flags)
+	}
+}
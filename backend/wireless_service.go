@@ -1,15 +1,59 @@
 package backend
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// directConnectTimeout leaves time to accept the debugging prompt on a device
+// that does not know this computer's key yet.
+const directConnectTimeout = 90 * time.Second
+
+// SetHeadlessAdb switches headless mode: wireless devices are connected
+// in-process over the adbd protocol, and the app no longer starts an adb
+// server. Devices that only the server can reach, such as USB ones, need it
+// off.
+func (a *App) SetHeadlessAdb(enabled bool) {
+	a.headlessMutex.Lock()
+	a.headlessAdb = enabled
+	a.headlessMutex.Unlock()
+}
+
+func (a *App) IsHeadlessAdb() bool {
+	a.headlessMutex.Lock()
+	defer a.headlessMutex.Unlock()
+	return a.headlessAdb
+}
+
+// directTarget returns the target device when it is connected in-process.
+// In headless mode a single in-process device is the target by default.
+func (a *App) directTarget() (string, bool) {
+	serial := a.GetTargetDevice()
+	if serial == "" && a.IsHeadlessAdb() {
+		if direct := a.adb.directDevices(); len(direct) == 1 {
+			serial = direct[0].Serial
+		}
+	}
+	return serial, a.adb.directTransport(serial) != nil
+}
+
 func (a *App) EnableWirelessAdb(port string) (string, error) {
 	if port == "" {
 		port = "5555"
 	}
 
+	if serial, ok := a.directTarget(); ok {
+		ctx, cancel := withCommandTimeout(context.Background(), DefaultCommandTimeout)
+		defer cancel()
+		var output strings.Builder
+		if err := a.adb.streamService(ctx, serial, "tcpip:"+port, &output); err != nil {
+			return "", fmt.Errorf("failed to enable tcpip: %w", err)
+		}
+		return strings.TrimSpace(output.String()), nil
+	}
 	
 	output, err := a.runCommand("adb", "tcpip", port)
 	if err != nil {
@@ -27,6 +71,18 @@ func (a *App) ConnectWirelessAdb(ipAddress string, port string) (string, error)
 
 	
 	address := fmt.Sprintf("%s:%s", ipAddress, port)
+
+	// In headless mode, or when there is no adb server at all, the device is
+	// connected in-process and its services go straight to adbd.
+	if a.IsHeadlessAdb() {
+		return a.connectDirectAdb(address)
+	}
+	ctx, cancel := withCommandTimeout(context.Background(), adbDialTimeout)
+	_, err := a.adb.Version(ctx)
+	cancel()
+	if errors.Is(err, errAdbServerUnavailable) {
+		return a.connectDirectAdb(address)
+	}
 	
 	output, _ := a.runCommand("adb", "connect", address)
 
@@ -51,6 +107,12 @@ func (a *App) DisconnectWirelessAdb(ipAddress string, port string) (string, erro
 
 	
 	address := fmt.Sprintf("%s:%s", ipAddress, port)
+	if a.adb.DisconnectDirect(address) {
+		if a.watcher != nil {
+			a.watcher.refreshDirect()
+		}
+		return fmt.Sprintf("Disconnected from %s", address), nil
+	}
 	
 	output, err := a.runCommand("adb", "disconnect", address)
 	if err != nil {
@@ -67,3 +129,24 @@ func (a *App) DisconnectWirelessAdb(ipAddress string, port string) (string, erro
 	
 	return cleanOutput, nil
 }
+
+func (a *App) connectDirectAdb(address string) (string, error) {
+	ctx, cancel := withCommandTimeout(context.Background(), directConnectTimeout)
+	defer cancel()
+
+	t, err := a.adb.ConnectDirect(ctx, address)
+	if err != nil {
+		var commandErr *CommandError
+		if errors.As(err, &commandErr) {
+			return "", err
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", newTimeoutError("connect "+address, fmt.Sprintf("no answer from %s within %s", address, directConnectTimeout))
+		}
+		return "", fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	if a.watcher != nil {
+		a.watcher.refreshDirect()
+	}
+	return fmt.Sprintf("connected to %s (in-process, %s)", address, t.device.Status), nil
+}
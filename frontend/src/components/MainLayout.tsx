@@ -2,7 +2,7 @@
 
 import React, { useState, useEffect } from "react";
 import "@/styles/global.css";
-import { LayoutDashboard, Box, FolderOpen, Terminal, Settings } from "lucide-react";
+import { LayoutDashboard, Box, FolderOpen, Terminal, Settings, ScrollText } from "lucide-react";
 import { cn } from "@/lib/utils";
 import { TooltipProvider } from "@/components/ui/tooltip";
 import { motion, AnimatePresence } from "framer-motion";
@@ -16,6 +16,7 @@ import { Toaster } from "@/components/ui/sonner";
 
 import { ThemeProvider } from "./ThemeProvider";
 import { ViewShell } from "./views/ViewShell";
+import { ViewLogcat } from "./views/ViewLogcat";
 import { AppSidebar } from "@/components/layout/AppSidebar";
 import { LoadingOverlay } from "@/components/layout/LoadingOverlay";
 
@@ -26,6 +27,7 @@ const VIEWS = {
   FLASHER: "flasher",
   UTILS: "utils",
   SHELL: "shell",
+  LOGCAT: "logcat",
 } as const;
 
 type ViewType = (typeof VIEWS)[keyof typeof VIEWS];
@@ -50,6 +52,7 @@ const NAV_ITEMS = [
   { id: VIEWS.FLASHER, icon: Terminal, label: "Flasher" },
   { id: VIEWS.UTILS, icon: Settings, label: "Utility" },
   { id: VIEWS.SHELL, icon: Terminal, label: "Terminal" },
+  { id: VIEWS.LOGCAT, icon: ScrollText, label: "Logcat" },
 ];
 
 export function MainLayout() {
@@ -74,6 +77,8 @@ export function MainLayout() {
         return <ViewUtilities activeView={activeView} />;
       case VIEWS.SHELL:
         return <ViewShell activeView={activeView} history={shellHistory} setHistory={setShellHistory} commandHistory={shellCommandHistory} setCommandHistory={setShellCommandHistory} />;
+      case VIEWS.LOGCAT:
+        return <ViewLogcat activeView={activeView} />;
       default:
         return <ViewDashboard activeView={activeView} />;
     }
@@ -49,6 +49,11 @@ export function DeviceInfoCard({ devices, deviceInfo, isRefreshing, onRefresh }:
       label: "Android Version",
       value: deviceInfo?.AndroidVersion,
     },
+    {
+      icon: <ShieldCheck size={18} />,
+      label: "Security Patch",
+      value: deviceInfo?.SecurityPatch,
+    },
     {
       icon: <Battery size={18} />,
       label: "Battery",
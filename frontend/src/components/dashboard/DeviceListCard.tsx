@@ -3,7 +3,7 @@ import { backend } from "../../../wailsjs/go/models";
 import { toast } from "sonner";
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
 import { Button } from "@/components/ui/button";
-import { Loader2, Pencil, RefreshCw, Smartphone } from "lucide-react";
+import { CheckCircle2, Loader2, Pencil, RefreshCw, Smartphone } from "lucide-react";
 import { Input } from "@/components/ui/input";
 import { getNickname, setNickname } from "@/lib/nicknameStore";
 import { AlertDialog, AlertDialogAction, AlertDialogCancel, AlertDialogContent, AlertDialogDescription, AlertDialogFooter, AlertDialogHeader, AlertDialogTitle } from "@/components/ui/alert-dialog";
@@ -15,9 +15,11 @@ interface DeviceListCardProps {
   devices: Device[];
   isRefreshing: boolean;
   onRefresh: () => void | Promise<void>;
+  selectedSerial: string;
+  onSelect: (serial: string) => void | Promise<void>;
 }
 
-export function DeviceListCard({ devices, isRefreshing, onRefresh }: DeviceListCardProps) {
+export function DeviceListCard({ devices, isRefreshing, onRefresh, selectedSerial, onSelect }: DeviceListCardProps) {
   const [editingDevice, setEditingDevice] = useState<Device | null>(null);
   const [newNickname, setNewNickname] = useState("");
 
@@ -59,16 +61,27 @@ export function DeviceListCard({ devices, isRefreshing, onRefresh }: DeviceListC
               {devices.map((device) => {
                 const displayName = getNickname(device.Serial) || device.Serial;
                 const isOnline = device.Status === "device";
+                const isSelected = device.Serial === selectedSerial;
                 return (
-                  <div key={device.Serial} className="flex items-center justify-between rounded-lg bg-muted p-3 group">
+                  <div
+                    key={device.Serial}
+                    className={`flex cursor-pointer items-center justify-between rounded-lg bg-muted p-3 group ${isSelected ? "ring-2 ring-primary" : ""}`}
+                    onClick={() => onSelect(isSelected ? "" : device.Serial)}
+                  >
                     <div className="flex flex-col">
-                      <span className="text-lg font-semibold">{displayName}</span>
+                      <span className="flex items-center gap-2 text-lg font-semibold">
+                        {isSelected && <CheckCircle2 className="h-4 w-4 text-primary" />}
+                        {displayName}
+                      </span>
                       {displayName !== device.Serial && <span className="font-mono text-xs text-muted-foreground">{device.Serial}</span>}
                     </div>
 
                     <div className="flex items-center gap-2">
                       <span className={`font-semibold ${isOnline ? "text-green-500" : "text-yellow-500"}`}>{device.Status}</span>
-                      <Button variant="ghost" size="icon" className="h-8 w-8 opacity-0 group-hover:opacity-100 transition-opacity" onClick={() => handleOpenEdit(device)}>
+                      <Button variant="ghost" size="icon" className="h-8 w-8 opacity-0 group-hover:opacity-100 transition-opacity" onClick={(e) => {
+                          e.stopPropagation();
+                          handleOpenEdit(device);
+                        }}>
                         <Pencil className="h-4 w-4" />
                       </Button>
                     </div>
@@ -1,9 +1,12 @@
 import React, { useEffect, useState } from "react";
-import { ConnectWirelessAdb, DisconnectWirelessAdb, EnableWirelessAdb } from "../../../wailsjs/go/backend/App";
+import { ConnectWirelessAdb, DisconnectWirelessAdb, EnableWirelessAdb, IsHeadlessAdb, SetHeadlessAdb } from "../../../wailsjs/go/backend/App";
 import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Label } from "@/components/ui/label";
 import { Loader2, PlugZap, Usb, Wifi } from "lucide-react";
 
 interface WirelessAdbCardProps {
@@ -18,6 +21,16 @@ export function WirelessAdbCard({ hasUsbDevice, defaultIp, onDevicesUpdated }: W
   const [isEnablingTcpip, setIsEnablingTcpip] = useState(false);
   const [isConnecting, setIsConnecting] = useState(false);
   const [isDisconnecting, setIsDisconnecting] = useState(false);
+  const [headless, setHeadless] = useState(false);
+
+  useEffect(() => {
+    IsHeadlessAdb().then(setHeadless);
+  }, []);
+
+  const handleHeadlessChange = async (enabled: boolean) => {
+    await SetHeadlessAdb(enabled);
+    setHeadless(enabled);
+  };
 
   useEffect(() => {
     if (defaultIp && !defaultIp.startsWith("N/A")) {
@@ -39,7 +52,7 @@ export function WirelessAdbCard({ hasUsbDevice, defaultIp, onDevicesUpdated }: W
     } catch (error) {
       toast.error("Failed to enable wireless mode", {
         id: toastId,
-        description: String(error),
+        description: errorMessage(error),
       });
     }
     setIsEnablingTcpip(false);
@@ -62,7 +75,7 @@ export function WirelessAdbCard({ hasUsbDevice, defaultIp, onDevicesUpdated }: W
     } catch (error) {
       toast.error("Connection failed", {
         id: toastId,
-        description: String(error),
+        description: errorMessage(error),
       });
     }
     setIsConnecting(false);
@@ -85,7 +98,7 @@ export function WirelessAdbCard({ hasUsbDevice, defaultIp, onDevicesUpdated }: W
     } catch (error) {
       toast.error("Disconnect failed", {
         id: toastId,
-        description: String(error),
+        description: errorMessage(error),
       });
     }
     setIsDisconnecting(false);
@@ -126,6 +139,10 @@ export function WirelessAdbCard({ hasUsbDevice, defaultIp, onDevicesUpdated }: W
               Disconnect
             </Button>
           </div>
+          <Label className="font-normal text-muted-foreground">
+            <Checkbox checked={headless} onCheckedChange={(checked) => handleHeadlessChange(Boolean(checked))} disabled={isConnecting || isDisconnecting} />
+            Connect without an adb server (headless)
+          </Label>
         </div>
       </CardContent>
     </Card>
@@ -0,0 +1,45 @@
+import React from "react";
+import { backend } from "../../../wailsjs/go/models";
+import { AlertTriangle } from "lucide-react";
+import { formatBytes } from "@/lib/utils";
+
+const KIND_LABEL: Record<string, string> = {
+  boot: "Boot image",
+  init_boot: "init_boot image",
+  vendor_boot: "vendor_boot image",
+};
+
+export function BootImageDetails({ image }: { image: backend.BootImageInfo }) {
+  const details: [string, string][] = [
+    ["Header", `v${image.HeaderVersion}`],
+    ["Page size", String(image.PageSize)],
+    ["Kernel", image.KernelSize ? formatBytes(image.KernelSize) : "None"],
+    ["Ramdisk", image.RamdiskSize ? `${formatBytes(image.RamdiskSize)} (${image.RamdiskCompression})` : "None"],
+    ["OS version", image.OSVersion || "-"],
+    ["Patch level", image.OSPatchLevel || "-"],
+  ];
+
+  return (
+    <div className="space-y-3 rounded-lg border bg-muted/50 p-3 text-sm">
+      <div className="flex items-center justify-between">
+        <span className="font-semibold">{KIND_LABEL[image.Kind] ?? image.Kind}</span>
+        {image.MagiskPatched && <span className="font-semibold text-green-500">Magisk patched</span>}
+      </div>
+      <div className="grid grid-cols-2 gap-x-4 gap-y-1 md:grid-cols-3">
+        {details.map(([label, value]) => (
+          <div key={label}>
+            <span className="text-muted-foreground">{label}: </span>
+            {value}
+          </div>
+        ))}
+      </div>
+      {image.Cmdline && <p className="break-all font-mono text-xs text-muted-foreground">{image.Cmdline}</p>}
+      {image.Warnings.map((warning) => (
+        <p key={warning} className="flex items-start gap-2 text-yellow-600 dark:text-yellow-500">
+          <AlertTriangle className="mt-0.5 h-4 w-4 shrink-0" />
+          {warning}
+        </p>
+      ))}
+    </div>
+  );
+}
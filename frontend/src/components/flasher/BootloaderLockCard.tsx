@@ -0,0 +1,200 @@
+import React, { useEffect, useRef, useState } from "react";
+import { toast } from "sonner";
+import { CancelJob, ChangeBootloaderLock, GetBootloaderLockState, PrepareBootloaderLockChange } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { AlertDialog, AlertDialogAction, AlertDialogCancel, AlertDialogContent, AlertDialogDescription, AlertDialogFooter, AlertDialogHeader, AlertDialogTitle } from "@/components/ui/alert-dialog";
+import { AlertTriangle, Loader2, Lock, LockOpen } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { cn } from "@/lib/utils";
+
+const ACTIONS = [
+  { action: "unlock", label: "Unlock", needsUnlocked: false },
+  { action: "oem_unlock", label: "OEM Unlock (legacy)", needsUnlocked: false },
+  { action: "unlock_critical", label: "Unlock Critical", needsUnlocked: null },
+  { action: "lock", label: "Lock", needsUnlocked: true },
+];
+
+const ABILITY_LABEL: Record<string, string> = {
+  allowed: "Allowed",
+  disallowed: "Disabled in Developer options",
+  unknown: "Not reported",
+};
+
+interface BootloaderLockCardProps {
+  serial: string;
+}
+
+export function BootloaderLockCard({ serial }: BootloaderLockCardProps) {
+  const [state, setState] = useState<backend.BootloaderLockState | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+  const [plan, setPlan] = useState<backend.LockChangePlan | null>(null);
+  const [typed, setTyped] = useState("");
+  const [jobId, setJobId] = useState<string | null>(null);
+  const jobIdRef = useRef<string | null>(null);
+
+  // The change can fail before ChangeBootloaderLock resolves.
+  const finishedEarlyRef = useRef<Record<string, backend.Job>>({});
+
+  const loadState = async () => {
+    setIsLoading(true);
+    try {
+      setState(await GetBootloaderLockState(serial));
+    } catch (error) {
+      toast.error("Failed to read lock state", { description: errorMessage(error) });
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleFinished = (job: backend.Job) => {
+    jobIdRef.current = null;
+    setJobId(null);
+
+    if (job.Status === "succeeded") {
+      toast.success(job.Result);
+      loadState();
+    } else if (job.Status === "failed") {
+      toast.error("Lock change failed", { description: job.Error });
+    }
+  };
+
+  useEffect(() => {
+    setState(null);
+  }, [serial]);
+
+  useEffect(() => {
+    return EventsOn("job:finished", (job: backend.Job) => {
+      if (job.ID !== jobIdRef.current) {
+        if (job.Kind === "bootloader-lock") finishedEarlyRef.current[job.ID] = job;
+        return;
+      }
+      handleFinished(job);
+    });
+  }, [serial]);
+
+  const handlePrepare = async (action: string) => {
+    try {
+      setTyped("");
+      setPlan(await PrepareBootloaderLockChange(serial, action));
+    } catch (error) {
+      toast.error("Cannot change lock state", { description: errorMessage(error) });
+    }
+  };
+
+  const handleConfirm = async () => {
+    if (!plan) return;
+    const current = plan;
+    setPlan(null);
+    try {
+      const id = await ChangeBootloaderLock(current.Serial, current.Action, current.Token);
+      const finished = finishedEarlyRef.current[id];
+      finishedEarlyRef.current = {};
+      if (finished) {
+        handleFinished(finished);
+        return;
+      }
+      jobIdRef.current = id;
+      setJobId(id);
+      toast.info("Confirm on the device", { description: "Use the volume keys to choose and power to confirm." });
+    } catch (error) {
+      toast.error("Failed to start lock change", { description: errorMessage(error) });
+    }
+  };
+
+  const handleCancel = async () => {
+    if (!jobId) return;
+    try {
+      await CancelJob(jobId);
+    } catch (error) {
+      toast.error("Failed to cancel", { description: errorMessage(error) });
+    }
+  };
+
+  const isRunning = jobId !== null;
+  const confirmWord = plan?.Action.toUpperCase() ?? "";
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          {state?.Unlocked ? <LockOpen /> : <Lock />}
+          Bootloader Lock
+        </CardTitle>
+        <CardDescription>Unlock or relock the bootloader with `fastboot flashing`. Every change wipes the device.</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-4">
+        <Button className="self-start" onClick={loadState} disabled={!serial || isLoading || isRunning}>
+          {isLoading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+          Read Lock State
+        </Button>
+
+        {state && (
+          <div className="grid grid-cols-2 gap-2">
+            <div className="rounded-lg bg-muted p-3">
+              <p className="text-xs text-muted-foreground">Bootloader</p>
+              <p className={cn("font-semibold", state.Unlocked && "text-yellow-600 dark:text-yellow-500")}>{state.Unlocked ? "Unlocked" : "Locked"}</p>
+            </div>
+            <div className="rounded-lg bg-muted p-3">
+              <p className="text-xs text-muted-foreground">Unlock ability</p>
+              <p className="font-semibold">{ABILITY_LABEL[state.UnlockAbility] ?? state.UnlockAbility}</p>
+            </div>
+          </div>
+        )}
+
+        {isRunning ? (
+          <div className="flex items-center justify-between gap-4 text-sm">
+            <span className="flex items-center gap-2 text-muted-foreground">
+              <Loader2 className="h-4 w-4 animate-spin" />
+              Waiting for the device to confirm and return to fastboot...
+            </span>
+            <Button variant="outline" size="sm" onClick={handleCancel}>
+              Stop Waiting
+            </Button>
+          </div>
+        ) : (
+          state && (
+            <div className="flex flex-wrap gap-2">
+              {ACTIONS.filter(({ needsUnlocked }) => needsUnlocked === null || needsUnlocked === state.Unlocked).map(({ action, label }) => (
+                <Button key={action} variant={action === "lock" ? "destructive" : "outline"} onClick={() => handlePrepare(action)}>
+                  {label}
+                </Button>
+              ))}
+            </div>
+          )
+        )}
+
+        <AlertDialog open={plan !== null} onOpenChange={(open) => !open && setPlan(null)}>
+          <AlertDialogContent>
+            <AlertDialogHeader>
+              <AlertDialogTitle>Run {plan?.Command}?</AlertDialogTitle>
+              <AlertDialogDescription asChild>
+                <div className="space-y-2">
+                  {plan?.Warnings.map((warning) => (
+                    <p key={warning} className="flex items-start gap-2">
+                      <AlertTriangle className="mt-0.5 h-4 w-4 shrink-0 text-destructive" />
+                      {warning}
+                    </p>
+                  ))}
+                  <p>
+                    Type <span className="font-mono font-semibold">{confirmWord}</span> to continue.
+                  </p>
+                </div>
+              </AlertDialogDescription>
+            </AlertDialogHeader>
+            <Input value={typed} onChange={(e) => setTyped(e.target.value)} placeholder={confirmWord} />
+            <AlertDialogFooter>
+              <AlertDialogCancel>Cancel</AlertDialogCancel>
+              <AlertDialogAction className="bg-destructive hover:bg-destructive/90" disabled={typed !== confirmWord} onClick={handleConfirm}>
+                Erase and {plan?.Action === "lock" ? "Lock" : "Unlock"}
+              </AlertDialogAction>
+            </AlertDialogFooter>
+          </AlertDialogContent>
+        </AlertDialog>
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,209 @@
+import React, { useEffect, useRef, useState } from "react";
+import { toast } from "sonner";
+import { CancelJob, CheckFactoryImage, InspectFactoryImage, SelectFactoryImageFolder, SelectZipFile, StartFactoryFlash } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Label } from "@/components/ui/label";
+import { AlertDialog, AlertDialogAction, AlertDialogCancel, AlertDialogContent, AlertDialogDescription, AlertDialogFooter, AlertDialogHeader, AlertDialogTitle, AlertDialogTrigger } from "@/components/ui/alert-dialog";
+import { CheckCircle2, Circle, Factory, FolderOpen, Loader2, XCircle } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { cn } from "@/lib/utils";
+
+// Mirrors backend.FactoryFlashProgress event payloads.
+type FactoryFlashProgress = {
+  JobID: string;
+  Steps: string[];
+  Step: number;
+  State: "running" | "done" | "failed" | "cancelled";
+  Message: string;
+};
+
+interface FactoryImageCardProps {
+  serial: string;
+}
+
+export function FactoryImageCard({ serial }: FactoryImageCardProps) {
+  const [image, setImage] = useState<backend.FactoryImage | null>(null);
+  const [checks, setChecks] = useState<backend.FactoryRequirementCheck[] | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+  const [wipe, setWipe] = useState(false);
+  const [jobId, setJobId] = useState<string | null>(null);
+  const [progress, setProgress] = useState<FactoryFlashProgress | null>(null);
+  const jobIdRef = useRef<string | null>(null);
+
+  useEffect(() => {
+    return EventsOn("factoryflash:progress", (next: FactoryFlashProgress) => {
+      if (next.JobID !== jobIdRef.current) return;
+      setProgress(next);
+      if (next.State === "running") return;
+
+      jobIdRef.current = null;
+      setJobId(null);
+      if (next.State === "done") {
+        toast.success("Factory image flashed", { description: "The device reboots into the new system." });
+      } else if (next.State === "failed") {
+        toast.error("Factory flash aborted", { description: next.Message });
+      } else {
+        toast.info("Factory flash cancelled");
+      }
+    });
+  }, []);
+
+  const loadImage = async (path: string) => {
+    setIsLoading(true);
+    setChecks(null);
+    setProgress(null);
+    try {
+      const inspected = await InspectFactoryImage(path);
+      setImage(inspected);
+      setChecks((await CheckFactoryImage(path, serial)) || []);
+    } catch (error) {
+      toast.error("Failed to read factory image", { description: errorMessage(error) });
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleSelect = async (select: () => Promise<string>) => {
+    try {
+      const path = await select();
+      if (path) await loadImage(path);
+    } catch (error) {
+      toast.error("Failed to open file dialog", { description: errorMessage(error) });
+    }
+  };
+
+  const handleFlash = async () => {
+    if (!image) return;
+    try {
+      setProgress(null);
+      const id = await StartFactoryFlash(backend.FactoryFlashOptions.createFrom({ Path: image.Path, Serial: serial, Wipe: wipe }));
+      jobIdRef.current = id;
+      setJobId(id);
+    } catch (error) {
+      toast.error("Failed to start factory flash", { description: errorMessage(error) });
+    }
+  };
+
+  const handleCancel = async () => {
+    if (!jobId) return;
+    try {
+      await CancelJob(jobId);
+    } catch (error) {
+      toast.error("Failed to cancel", { description: errorMessage(error) });
+    }
+  };
+
+  const blocking = checks?.some((check) => !check.Satisfied && !check.Flashed) ?? false;
+  const isRunning = jobId !== null;
+
+  const stepIcon = (index: number) => {
+    if (!progress || index > progress.Step) return <Circle className="h-4 w-4 text-muted-foreground" />;
+    if (index < progress.Step || progress.State === "done") return <CheckCircle2 className="h-4 w-4 text-green-500" />;
+    if (progress.State === "running") return <Loader2 className="h-4 w-4 animate-spin" />;
+    return <XCircle className="h-4 w-4 text-destructive" />;
+  };
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Factory />
+          Factory Image
+        </CardTitle>
+        <CardDescription>Flash a Pixel-style factory image (zip or extracted folder) the way flash-all does, after checking android-info.txt against the device.</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-4">
+        <div className="flex gap-2">
+          <Button variant="outline" className="flex-1" onClick={() => handleSelect(SelectZipFile)} disabled={isLoading || isRunning}>
+            Select ZIP
+          </Button>
+          <Button variant="outline" className="flex-1" onClick={() => handleSelect(SelectFactoryImageFolder)} disabled={isLoading || isRunning}>
+            <FolderOpen className="mr-2 h-4 w-4" />
+            Select Folder
+          </Button>
+        </div>
+
+        {isLoading && (
+          <p className="flex items-center gap-2 text-sm text-muted-foreground">
+            <Loader2 className="h-4 w-4 animate-spin" />
+            Reading factory image...
+          </p>
+        )}
+
+        {image && (
+          <div className="space-y-1 text-sm">
+            <p className="truncate font-mono">{image.Path}</p>
+            <p className="text-muted-foreground">{[image.Bootloader, image.Radio, image.Image].filter(Boolean).join(" · ")}</p>
+          </div>
+        )}
+
+        {checks && checks.length > 0 && (
+          <div className="flex flex-col gap-2">
+            {checks.map((check) => (
+              <div key={check.Name} className={cn("rounded-lg border bg-muted p-3 text-sm", !check.Satisfied && !check.Flashed && "border-destructive")}>
+                <div className="flex items-center justify-between">
+                  <span className="font-semibold">{check.Name}</span>
+                  <span className={cn(check.Satisfied ? "text-green-500" : check.Flashed ? "text-muted-foreground" : "text-destructive")}>
+                    {check.Satisfied ? "OK" : check.Flashed ? "Updated by this image" : "Mismatch"}
+                  </span>
+                </div>
+                <p className="text-muted-foreground">
+                  Requires {check.Expected}, device reports {check.DeviceValue || "nothing"}
+                </p>
+              </div>
+            ))}
+          </div>
+        )}
+
+        {progress && (
+          <ol className="flex flex-col gap-2 text-sm">
+            {progress.Steps.map((step, index) => (
+              <li key={index} className="flex items-center gap-2">
+                {stepIcon(index)}
+                {step}
+              </li>
+            ))}
+          </ol>
+        )}
+
+        <div className="flex items-center gap-2">
+          <Checkbox id="factory-wipe" checked={wipe} onCheckedChange={(checked) => setWipe(Boolean(checked))} disabled={isRunning} />
+          <Label htmlFor="factory-wipe">Wipe data (-w)</Label>
+        </div>
+
+        {isRunning ? (
+          <Button variant="outline" onClick={handleCancel}>
+            Cancel
+          </Button>
+        ) : (
+          <AlertDialog>
+            <AlertDialogTrigger asChild>
+              <Button disabled={!image || !image.Image || isLoading || blocking}>
+                <Factory className="mr-2 h-4 w-4" />
+                Flash Factory Image
+              </Button>
+            </AlertDialogTrigger>
+            <AlertDialogContent>
+              <AlertDialogHeader>
+                <AlertDialogTitle>Flash factory image?</AlertDialogTitle>
+                <AlertDialogDescription>
+                  Every partition on {serial} is overwritten{wipe ? " and all user data is erased" : ""}. Do not unplug the device until the flash finishes.
+                </AlertDialogDescription>
+              </AlertDialogHeader>
+              <AlertDialogFooter>
+                <AlertDialogCancel>Cancel</AlertDialogCancel>
+                <AlertDialogAction className={cn(wipe && "bg-destructive hover:bg-destructive/90")} onClick={handleFlash}>
+                  Flash
+                </AlertDialogAction>
+              </AlertDialogFooter>
+            </AlertDialogContent>
+          </AlertDialog>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -1,8 +1,13 @@
-import React from "react";
+import React, { useState } from "react";
+import { toast } from "sonner";
+import { ConnectFastbootTcp, DisconnectFastbootTcp } from "../../../wailsjs/go/backend/App";
 import { backend } from "../../../wailsjs/go/models";
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
 import { Button } from "@/components/ui/button";
-import { Loader2, RefreshCw, Smartphone } from "lucide-react";
+import { Input } from "@/components/ui/input";
+import { Loader2, RefreshCw, Smartphone, Wifi, X } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { cn } from "@/lib/utils";
 
 type Device = backend.Device;
 
@@ -11,9 +16,34 @@ interface FastbootDevicesCardProps {
   isRefreshing: boolean;
   error: string | null;
   onRefresh: () => void;
+  selectedSerial: string;
+  onSelect: (serial: string) => void;
 }
 
-export function FastbootDevicesCard({ devices, isRefreshing, error, onRefresh }: FastbootDevicesCardProps) {
+export function FastbootDevicesCard({ devices, isRefreshing, error, onRefresh, selectedSerial, onSelect }: FastbootDevicesCardProps) {
+  const [address, setAddress] = useState("");
+  const [isConnecting, setIsConnecting] = useState(false);
+
+  const handleConnect = async () => {
+    setIsConnecting(true);
+    try {
+      const device = await ConnectFastbootTcp(address);
+      toast.success(`Connected to ${device.Serial}`, { description: device.Product || undefined });
+      setAddress("");
+      onSelect(device.Serial);
+      onRefresh();
+    } catch (error) {
+      toast.error("Failed to connect", { description: errorMessage(error) });
+    } finally {
+      setIsConnecting(false);
+    }
+  };
+
+  const handleDisconnect = async (serial: string) => {
+    await DisconnectFastbootTcp(serial);
+    onRefresh();
+  };
+
   return (
     <Card>
       <CardHeader className="flex flex-row items-center justify-between">
@@ -31,14 +61,32 @@ export function FastbootDevicesCard({ devices, isRefreshing, error, onRefresh }:
         ) : (
           <div className="flex flex-col gap-2">
             {devices.map((device) => (
-              <div key={device.Serial} className="flex items-center justify-between rounded-lg bg-muted p-3">
-                <span className="font-mono">{device.Serial}</span>
-                <span className="font-semibold text-blue-500">{device.Status}</span>
+              <div key={device.Serial} className="flex items-center gap-2">
+                <button
+                  type="button"
+                  className={cn("flex flex-1 items-center justify-between rounded-lg border border-transparent bg-muted p-3 text-left", devices.length > 1 && selectedSerial === device.Serial && "border-primary")}
+                  onClick={() => onSelect(device.Serial)}
+                >
+                  <span className="font-mono">{device.Serial}</span>
+                  <span className="font-semibold text-blue-500">{device.Status}</span>
+                </button>
+                {device.Serial.startsWith("tcp:") && (
+                  <Button variant="ghost" size="icon" onClick={() => handleDisconnect(device.Serial)} title="Forget network target">
+                    <X className="h-4 w-4" />
+                  </Button>
+                )}
               </div>
             ))}
           </div>
         )}
         {error && <p className="mt-2 text-sm text-destructive">{error}</p>}
+        <div className="mt-4 flex gap-2">
+          <Input placeholder="Network fastboot, e.g. 192.168.1.20 or emulator:5554" value={address} onChange={(e) => setAddress(e.target.value.trim())} onKeyDown={(e) => e.key === "Enter" && address && handleConnect()} disabled={isConnecting} />
+          <Button variant="outline" onClick={handleConnect} disabled={!address || isConnecting}>
+            {isConnecting ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : <Wifi className="mr-2 h-4 w-4" />}
+            Connect
+          </Button>
+        </div>
       </CardContent>
     </Card>
   );
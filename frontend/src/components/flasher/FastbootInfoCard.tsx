@@ -0,0 +1,102 @@
+import React, { useEffect, useState } from "react";
+import { toast } from "sonner";
+import { GetFastbootInfo } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { Info, Loader2 } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { formatBytes } from "@/lib/utils";
+
+interface FastbootInfoCardProps {
+  serial: string;
+}
+
+export function FastbootInfoCard({ serial }: FastbootInfoCardProps) {
+  const [info, setInfo] = useState<backend.FastbootInfo | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+
+  useEffect(() => {
+    setInfo(null);
+  }, [serial]);
+
+  const handleRead = async () => {
+    setIsLoading(true);
+    try {
+      setInfo(await GetFastbootInfo(serial));
+    } catch (error) {
+      toast.error("Failed to read bootloader variables", { description: errorMessage(error) });
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const summary: [string, string][] = info
+    ? [
+        ["Product", info.Product || "-"],
+        ["Mode", info.IsUserspace ? "fastbootd" : "bootloader"],
+        ["Bootloader", info.Unlocked ? "Unlocked" : "Locked"],
+        ["Secure boot", info.Secure ? "Yes" : "No"],
+        ["Current slot", info.SlotCount > 1 ? `${info.CurrentSlot || "-"} (${info.SlotCount} slots)` : "Not A/B"],
+        ["Max download", info.MaxDownloadSize ? formatBytes(info.MaxDownloadSize) : "-"],
+        ["Battery", info.BatteryVoltage ? `${info.BatteryVoltage} mV` : "-"],
+      ]
+    : [];
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Info />
+          Bootloader Info
+        </CardTitle>
+        <CardDescription>Read the variables reported by `fastboot getvar all` for {serial}.</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-4">
+        <Button className="self-start" onClick={handleRead} disabled={!serial || isLoading}>
+          {isLoading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+          Read Info
+        </Button>
+
+        {info && (
+          <>
+            <div className="grid grid-cols-2 gap-2 md:grid-cols-4">
+              {summary.map(([label, value]) => (
+                <div key={label} className="rounded-lg bg-muted p-3">
+                  <p className="text-xs text-muted-foreground">{label}</p>
+                  <p className="font-semibold">{value}</p>
+                </div>
+              ))}
+            </div>
+
+            {info.Partitions.length > 0 && (
+              <div className="max-h-80 overflow-y-auto rounded-md border">
+                <Table>
+                  <TableHeader>
+                    <TableRow>
+                      <TableHead>Partition</TableHead>
+                      <TableHead>Type</TableHead>
+                      <TableHead>Size</TableHead>
+                      <TableHead>Logical</TableHead>
+                    </TableRow>
+                  </TableHeader>
+                  <TableBody>
+                    {info.Partitions.map((partition) => (
+                      <TableRow key={partition.Name}>
+                        <TableCell className="font-mono">{partition.Name}</TableCell>
+                        <TableCell>{partition.Type || "-"}</TableCell>
+                        <TableCell>{formatBytes(partition.Size)}</TableCell>
+                        <TableCell>{partition.IsLogical ? "Yes" : "No"}</TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              </div>
+            )}
+          </>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -2,7 +2,11 @@ import React from "react";
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
 import { Input } from "@/components/ui/input";
 import { Button } from "@/components/ui/button";
-import { FileUp, Loader2 } from "lucide-react";
+import { FileUp, Loader2, Rocket } from "lucide-react";
+import { cn, formatBytes } from "@/lib/utils";
+import { backend } from "../../../wailsjs/go/models";
+import { BootImageDetails } from "@/components/flasher/BootImageDetails";
+import { FlashProgressBar, FlashTransfer } from "@/components/flasher/FlashProgressBar";
 
 interface FlashPartitionCardProps {
   partition: string;
@@ -10,11 +14,25 @@ interface FlashPartitionCardProps {
   filePath: string;
   onSelectFile: () => void;
   onFlash: () => void;
+  onBootOnce: () => void;
   isFlashing: boolean;
   canFlash: boolean;
+  slot: string;
+  onSlotChange: (value: string) => void;
+  bootImage: backend.BootImageInfo | null;
+  sparseImage: backend.SparseImageInfo | null;
+  transfer: FlashTransfer | null;
+  onCancel: () => void;
 }
 
-export function FlashPartitionCard({ partition, onPartitionChange, filePath, onSelectFile, onFlash, isFlashing, canFlash }: FlashPartitionCardProps) {
+const SLOT_OPTIONS = [
+  { value: "", label: "Default" },
+  { value: "a", label: "Slot A" },
+  { value: "b", label: "Slot B" },
+  { value: "all", label: "Both" },
+];
+
+export function FlashPartitionCard({ partition, onPartitionChange, filePath, onSelectFile, onFlash, onBootOnce, isFlashing, canFlash, slot, onSlotChange, bootImage, sparseImage, transfer, onCancel }: FlashPartitionCardProps) {
   return (
     <Card>
       <CardHeader>
@@ -32,6 +50,17 @@ export function FlashPartitionCard({ partition, onPartitionChange, filePath, onS
           <Input id="partition" placeholder="e.g., boot, recovery, vendor_boot" value={partition} onChange={(e) => onPartitionChange(e.target.value)} disabled={isFlashing} />
         </div>
 
+        <div className="space-y-2">
+          <label className="text-sm font-medium">Slot</label>
+          <div className="flex flex-wrap gap-2">
+            {SLOT_OPTIONS.map((option) => (
+              <Button key={option.value} variant="outline" size="sm" className={cn(slot === option.value && "border-primary bg-primary/10")} onClick={() => onSlotChange(option.value)} disabled={isFlashing}>
+                {option.label}
+              </Button>
+            ))}
+          </div>
+        </div>
+
         <div className="space-y-2">
           <label className="text-sm font-medium">Image File (.img)</label>
           <div className="flex gap-2">
@@ -40,12 +69,30 @@ export function FlashPartitionCard({ partition, onPartitionChange, filePath, onS
             </Button>
           </div>
           <p className="truncate text-sm text-muted-foreground">{filePath ? filePath : "No file selected."}</p>
+          {sparseImage?.IsSparse && (
+            <p className="text-sm text-muted-foreground">
+              Sparse image: {formatBytes(sparseImage.FileSize)} on disk, expands to {formatBytes(sparseImage.LogicalSize)} on the partition.
+            </p>
+          )}
         </div>
 
-        <Button variant="default" className="w-full" disabled={isFlashing || !partition || !filePath || !canFlash} onClick={onFlash}>
-          {isFlashing ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : <FileUp className="mr-2 h-4 w-4" />}
-          Flash Partition
-        </Button>
+        {bootImage && <BootImageDetails image={bootImage} />}
+
+        {transfer ? (
+          <FlashProgressBar transfer={transfer} onCancel={onCancel} />
+        ) : (
+          <Button variant="default" className="w-full" disabled={isFlashing || !partition || !filePath || !canFlash} onClick={onFlash}>
+            {isFlashing ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : <FileUp className="mr-2 h-4 w-4" />}
+            Flash Partition
+          </Button>
+        )}
+
+        {bootImage?.Kind === "boot" && (
+          <Button variant="outline" className="w-full" disabled={isFlashing || !canFlash} onClick={onBootOnce}>
+            <Rocket className="mr-2 h-4 w-4" />
+            Boot Once Without Flashing
+          </Button>
+        )}
       </CardContent>
     </Card>
   );
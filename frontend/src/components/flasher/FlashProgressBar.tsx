@@ -0,0 +1,34 @@
+import React from "react";
+import { Button } from "@/components/ui/button";
+import { Loader2 } from "lucide-react";
+
+export type FlashTransfer = {
+  jobId: string;
+  percent: number;
+  stage: string;
+};
+
+interface FlashProgressBarProps {
+  transfer: FlashTransfer;
+  onCancel: () => void;
+}
+
+export function FlashProgressBar({ transfer, onCancel }: FlashProgressBarProps) {
+  return (
+    <div className="space-y-2">
+      <div className="flex items-center gap-2">
+        <div className="h-2 flex-1 overflow-hidden rounded-full bg-muted">
+          <div className="h-full bg-primary transition-all" style={{ width: `${transfer.percent}%` }} />
+        </div>
+        <span className="text-sm text-muted-foreground">{Math.floor(transfer.percent)}%</span>
+        <Button variant="outline" size="sm" onClick={onCancel}>
+          Cancel
+        </Button>
+      </div>
+      <p className="flex items-center gap-2 truncate text-sm text-muted-foreground">
+        <Loader2 className="h-4 w-4 shrink-0 animate-spin" />
+        {transfer.stage || "Starting..."}
+      </p>
+    </div>
+  );
+}
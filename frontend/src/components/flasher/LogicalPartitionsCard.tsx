@@ -0,0 +1,163 @@
+import React, { useEffect, useState } from "react";
+import { toast } from "sonner";
+import { CreateLogicalPartition, DeleteLogicalPartition, GetPartitionLayout, RebootToFastbootd, ResizeLogicalPartition } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { AlertDialog, AlertDialogAction, AlertDialogCancel, AlertDialogContent, AlertDialogDescription, AlertDialogFooter, AlertDialogHeader, AlertDialogTitle, AlertDialogTrigger } from "@/components/ui/alert-dialog";
+import { Database, Loader2 } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { cn, formatBytes } from "@/lib/utils";
+
+const MIB = 1024 * 1024;
+
+interface LogicalPartitionsCardProps {
+  serial: string;
+}
+
+export function LogicalPartitionsCard({ serial }: LogicalPartitionsCardProps) {
+  const [layout, setLayout] = useState<backend.PartitionLayout | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+  const [isBusy, setIsBusy] = useState(false);
+  const [name, setName] = useState("");
+  const [sizeMiB, setSizeMiB] = useState("");
+
+  useEffect(() => {
+    setLayout(null);
+  }, [serial]);
+
+  const loadLayout = async () => {
+    setIsLoading(true);
+    try {
+      setLayout(await GetPartitionLayout(serial));
+    } catch (error) {
+      toast.error("Failed to read partitions", { description: errorMessage(error) });
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const runAction = async (label: string, action: () => Promise<void>) => {
+    setIsBusy(true);
+    const toastId = toast.loading(`${label}...`);
+    try {
+      await action();
+      toast.success(`${label} done`, { id: toastId });
+      setLayout(await GetPartitionLayout(serial));
+    } catch (error) {
+      toast.error(`${label} failed`, { description: errorMessage(error), id: toastId });
+    } finally {
+      setIsBusy(false);
+    }
+  };
+
+  const size = Math.round(Number(sizeMiB) * MIB);
+  const validSize = Number.isFinite(size) && size > 0;
+  const exists = layout?.Logical.some((partition) => partition.Name === name) ?? false;
+
+  const selectPartition = (partition: backend.FastbootPartition) => {
+    setName(partition.Name);
+    setSizeMiB(String(Math.ceil(partition.Size / MIB)));
+  };
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Database />
+          Logical Partitions
+        </CardTitle>
+        <CardDescription>Create, resize and delete dynamic partitions inside super. Changes need userspace fastboot (fastbootd).</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-4">
+        <Button className="self-start" onClick={loadLayout} disabled={!serial || isLoading || isBusy}>
+          {isLoading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+          Load Partitions
+        </Button>
+
+        {layout && !layout.IsUserspace && (
+          <div className="flex items-center justify-between gap-4 rounded-lg border border-yellow-500/50 bg-yellow-500/10 p-3 text-sm">
+            <span>The device is in bootloader fastboot, which cannot change logical partitions.</span>
+            <Button variant="outline" size="sm" disabled={isBusy} onClick={() => runAction("Rebooting to fastbootd", () => RebootToFastbootd(serial))}>
+              Reboot to fastbootd
+            </Button>
+          </div>
+        )}
+
+        {layout && (
+          <div className="grid grid-cols-1 gap-4 md:grid-cols-2">
+            {[
+              { title: "Logical", partitions: layout.Logical },
+              { title: "Physical", partitions: layout.Physical },
+            ].map(({ title, partitions }) => (
+              <div key={title} className="max-h-72 overflow-y-auto rounded-md border">
+                <Table>
+                  <TableHeader>
+                    <TableRow>
+                      <TableHead>{title}</TableHead>
+                      <TableHead>Size</TableHead>
+                    </TableRow>
+                  </TableHeader>
+                  <TableBody>
+                    {partitions.length === 0 ? (
+                      <TableRow>
+                        <TableCell colSpan={2} className="text-muted-foreground">
+                          None reported
+                        </TableCell>
+                      </TableRow>
+                    ) : (
+                      partitions.map((partition) => (
+                        <TableRow
+                          key={partition.Name}
+                          className={cn(partition.IsLogical && "cursor-pointer", partition.Name === name && partition.IsLogical && "bg-muted")}
+                          onClick={() => partition.IsLogical && selectPartition(partition)}
+                        >
+                          <TableCell className="font-mono">{partition.Name}</TableCell>
+                          <TableCell>{formatBytes(partition.Size)}</TableCell>
+                        </TableRow>
+                      ))
+                    )}
+                  </TableBody>
+                </Table>
+              </div>
+            ))}
+          </div>
+        )}
+
+        {layout?.IsUserspace && (
+          <div className="flex flex-col gap-2 md:flex-row">
+            <Input placeholder="Partition name, e.g. product_a" value={name} onChange={(e) => setName(e.target.value.trim())} disabled={isBusy} />
+            <Input type="number" min={1} placeholder="Size (MiB)" value={sizeMiB} onChange={(e) => setSizeMiB(e.target.value)} disabled={isBusy} className="md:w-40" />
+            <Button disabled={isBusy || !name || !validSize || exists} onClick={() => runAction(`Creating ${name}`, () => CreateLogicalPartition(serial, name, size))}>
+              Create
+            </Button>
+            <Button variant="outline" disabled={isBusy || !exists || !validSize} onClick={() => runAction(`Resizing ${name}`, () => ResizeLogicalPartition(serial, name, size))}>
+              Resize
+            </Button>
+            <AlertDialog>
+              <AlertDialogTrigger asChild>
+                <Button variant="destructive" disabled={isBusy || !exists}>
+                  Delete
+                </Button>
+              </AlertDialogTrigger>
+              <AlertDialogContent>
+                <AlertDialogHeader>
+                  <AlertDialogTitle>Delete {name}?</AlertDialogTitle>
+                  <AlertDialogDescription>The logical partition and its contents are removed from super. The system may not boot until it is recreated and flashed.</AlertDialogDescription>
+                </AlertDialogHeader>
+                <AlertDialogFooter>
+                  <AlertDialogCancel>Cancel</AlertDialogCancel>
+                  <AlertDialogAction className="bg-destructive hover:bg-destructive/90" onClick={() => runAction(`Deleting ${name}`, () => DeleteLogicalPartition(serial, name))}>
+                    Delete
+                  </AlertDialogAction>
+                </AlertDialogFooter>
+              </AlertDialogContent>
+            </AlertDialog>
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
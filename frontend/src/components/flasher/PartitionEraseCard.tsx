@@ -0,0 +1,111 @@
+import React, { useState } from "react";
+import { toast } from "sonner";
+import { ErasePartition, FormatPartition } from "../../../wailsjs/go/backend/App";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Label } from "@/components/ui/label";
+import { AlertDialog, AlertDialogAction, AlertDialogCancel, AlertDialogContent, AlertDialogDescription, AlertDialogFooter, AlertDialogHeader, AlertDialogTitle, AlertDialogTrigger } from "@/components/ui/alert-dialog";
+import { Eraser, Loader2 } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { cn } from "@/lib/utils";
+
+const FS_OPTIONS = [
+  { value: "", label: "Auto" },
+  { value: "ext4", label: "ext4" },
+  { value: "f2fs", label: "f2fs" },
+];
+
+interface PartitionEraseCardProps {
+  canRun: boolean;
+}
+
+export function PartitionEraseCard({ canRun }: PartitionEraseCardProps) {
+  const [partition, setPartition] = useState("");
+  const [fsType, setFsType] = useState("");
+  const [allowCritical, setAllowCritical] = useState(false);
+  const [busyAction, setBusyAction] = useState<"erase" | "format" | null>(null);
+
+  const run = async (action: "erase" | "format") => {
+    setBusyAction(action);
+    const verb = action === "erase" ? "Erasing" : "Formatting";
+    const toastId = toast.loading(`${verb} ${partition}...`);
+    try {
+      if (action === "erase") {
+        await ErasePartition(partition, allowCritical);
+      } else {
+        await FormatPartition(partition, fsType, allowCritical);
+      }
+      toast.success(`${partition} ${action === "erase" ? "erased" : "formatted"}`, { id: toastId });
+    } catch (error) {
+      toast.error(`${verb} failed`, { description: errorMessage(error), id: toastId });
+    } finally {
+      setBusyAction(null);
+      setAllowCritical(false);
+    }
+  };
+
+  const confirmButton = (action: "erase" | "format", label: string, description: string) => (
+    <AlertDialog>
+      <AlertDialogTrigger asChild>
+        <Button variant={action === "erase" ? "destructive" : "outline"} className="flex-1" disabled={!partition || !canRun || busyAction !== null}>
+          {busyAction === action ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : null}
+          {label}
+        </Button>
+      </AlertDialogTrigger>
+      <AlertDialogContent>
+        <AlertDialogHeader>
+          <AlertDialogTitle>
+            {label} {partition}?
+          </AlertDialogTitle>
+          <AlertDialogDescription>{description}</AlertDialogDescription>
+        </AlertDialogHeader>
+        <AlertDialogFooter>
+          <AlertDialogCancel>Cancel</AlertDialogCancel>
+          <AlertDialogAction className="bg-destructive hover:bg-destructive/90" onClick={() => run(action)}>
+            {label}
+          </AlertDialogAction>
+        </AlertDialogFooter>
+      </AlertDialogContent>
+    </AlertDialog>
+  );
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Eraser />
+          Erase / Format Partition
+        </CardTitle>
+        <CardDescription>Erase a partition, or format it with a fresh ext4/f2fs filesystem built by the bundled mke2fs/make_f2fs.</CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        <Input placeholder="e.g., userdata, cache, metadata" value={partition} onChange={(e) => setPartition(e.target.value.trim())} disabled={busyAction !== null} />
+
+        <div className="space-y-2">
+          <label className="text-sm font-medium">Filesystem (format only)</label>
+          <div className="flex flex-wrap gap-2">
+            {FS_OPTIONS.map((option) => (
+              <Button key={option.value} variant="outline" size="sm" className={cn(fsType === option.value && "border-primary bg-primary/10")} onClick={() => setFsType(option.value)} disabled={busyAction !== null}>
+                {option.label}
+              </Button>
+            ))}
+          </div>
+        </div>
+
+        <div className="flex items-center gap-2">
+          <Checkbox id="allow-critical" checked={allowCritical} onCheckedChange={(checked) => setAllowCritical(Boolean(checked))} disabled={busyAction !== null} />
+          <Label htmlFor="allow-critical" className="text-destructive">
+            Allow critical partitions (bootloader, modem, persist, frp...)
+          </Label>
+        </div>
+
+        <div className="flex gap-2">
+          {confirmButton("erase", "Erase", "Everything on the partition is lost. Critical partitions hold data a factory image cannot restore.")}
+          {confirmButton("format", "Format", "The partition is replaced with an empty filesystem and everything on it is lost.")}
+        </div>
+      </CardContent>
+    </Card>
+  );
+}
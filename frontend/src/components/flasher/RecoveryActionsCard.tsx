@@ -2,6 +2,7 @@ import React from "react";
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
 import { Button } from "@/components/ui/button";
 import { Loader2, Package, AlertTriangle } from "lucide-react";
+import { FlashProgressBar, FlashTransfer } from "@/components/flasher/FlashProgressBar";
 import { AlertDialog, AlertDialogAction, AlertDialogCancel, AlertDialogContent, AlertDialogDescription, AlertDialogFooter, AlertDialogHeader, AlertDialogTitle, AlertDialogTrigger } from "@/components/ui/alert-dialog";
 
 interface RecoveryActionsCardProps {
@@ -9,12 +10,14 @@ interface RecoveryActionsCardProps {
   onSelectSideloadFile: () => void;
   isSideloading: boolean;
   onSideload: () => void;
+  sideloadTransfer: FlashTransfer | null;
+  onCancelSideload: () => void;
   isWiping: boolean;
   onWipe: () => void;
   canWipe: boolean;
 }
 
-export function RecoveryActionsCard({ sideloadFilePath, onSelectSideloadFile, isSideloading, onSideload, isWiping, onWipe, canWipe }: RecoveryActionsCardProps) {
+export function RecoveryActionsCard({ sideloadFilePath, onSelectSideloadFile, isSideloading, onSideload, sideloadTransfer, onCancelSideload, isWiping, onWipe, canWipe }: RecoveryActionsCardProps) {
   return (
     <Card>
       <CardHeader>
@@ -34,10 +37,14 @@ export function RecoveryActionsCard({ sideloadFilePath, onSelectSideloadFile, is
           </div>
           <p className="truncate text-sm text-muted-foreground">{sideloadFilePath ? sideloadFilePath : "No ZIP selected."}</p>
           <p className="text-sm text-muted-foreground">Ensure the device shows recovery sideload mode before starting the transfer.</p>
-          <Button variant="default" className="w-full" disabled={isSideloading || !sideloadFilePath} onClick={onSideload}>
-            {isSideloading ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : <Package className="mr-2 h-4 w-4" />}
-            Sideload Package
-          </Button>
+          {sideloadTransfer ? (
+            <FlashProgressBar transfer={sideloadTransfer} onCancel={onCancelSideload} />
+          ) : (
+            <Button variant="default" className="w-full" disabled={isSideloading || !sideloadFilePath} onClick={onSideload}>
+              {isSideloading ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : <Package className="mr-2 h-4 w-4" />}
+              Sideload Package
+            </Button>
+          )}
         </div>
 
         <div className="rounded-lg border border-destructive/30 bg-destructive/5 p-4">
@@ -0,0 +1,92 @@
+import React, { useEffect, useState } from "react";
+import { toast } from "sonner";
+import { GetSlots, SetActiveSlot } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Layers, Loader2, RefreshCw } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { cn } from "@/lib/utils";
+
+interface SlotsCardProps {
+  serial: string;
+}
+
+export function SlotsCard({ serial }: SlotsCardProps) {
+  const [slots, setSlots] = useState<backend.SlotInfo[] | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+  const [switchingTo, setSwitchingTo] = useState<string | null>(null);
+
+  const refresh = async () => {
+    setIsLoading(true);
+    try {
+      setSlots((await GetSlots(serial)) || []);
+    } catch (error) {
+      toast.error("Failed to read slots", { description: errorMessage(error) });
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    setSlots(null);
+  }, [serial]);
+
+  const handleSetActive = async (slot: string) => {
+    setSwitchingTo(slot);
+    try {
+      await SetActiveSlot(serial, slot);
+      toast.success(`Slot ${slot} is now active`, { description: "It will be used on the next boot." });
+      await refresh();
+    } catch (error) {
+      toast.error("Failed to switch slot", { description: errorMessage(error) });
+    } finally {
+      setSwitchingTo(null);
+    }
+  };
+
+  return (
+    <Card>
+      <CardHeader className="flex flex-row items-center justify-between">
+        <div className="flex flex-col gap-1.5">
+          <CardTitle className="flex items-center gap-2">
+            <Layers />
+            A/B Slots
+          </CardTitle>
+          <CardDescription>Switch to the other slot to recover a device that bootloops after an OTA.</CardDescription>
+        </div>
+        <Button variant="ghost" size="icon" onClick={refresh} disabled={!serial || isLoading}>
+          {isLoading ? <Loader2 className="h-4 w-4 animate-spin" /> : <RefreshCw className="h-4 w-4" />}
+        </Button>
+      </CardHeader>
+      <CardContent>
+        {slots === null ? (
+          <p className="text-muted-foreground">Refresh to read the slot state.</p>
+        ) : slots.length === 0 ? (
+          <p className="text-muted-foreground">This device does not use A/B slots.</p>
+        ) : (
+          <div className="grid gap-2 md:grid-cols-2">
+            {slots.map((slot) => (
+              <div key={slot.Name} className={cn("flex items-center justify-between rounded-lg border bg-muted p-3", slot.Active && "border-primary")}>
+                <div className="flex flex-col">
+                  <span className="font-semibold">
+                    Slot {slot.Name.toUpperCase()}
+                    {slot.Active && <span className="ml-2 text-sm text-primary">active</span>}
+                  </span>
+                  <span className="text-sm text-muted-foreground">
+                    {slot.Unbootable ? "Unbootable" : slot.Successful ? "Booted successfully" : "Not marked successful"}
+                    {slot.RetryCount >= 0 && ` · ${slot.RetryCount} retries left`}
+                  </span>
+                </div>
+                <Button variant="outline" size="sm" disabled={slot.Active || switchingTo !== null} onClick={() => handleSetActive(slot.Name)}>
+                  {switchingTo === slot.Name && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+                  Set Active
+                </Button>
+              </div>
+            ))}
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,160 @@
+import React, { useEffect, useRef, useState } from "react";
+import { toast } from "sonner";
+import { CancelJob, ConvertSparseImage, InspectSparseImage, SelectImageFile, SelectSaveFile } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Layers, Loader2 } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { formatBytes } from "@/lib/utils";
+
+export function SparseImageCard() {
+  const [image, setImage] = useState<backend.SparseImageInfo | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+  const [jobId, setJobId] = useState<string | null>(null);
+  const [progress, setProgress] = useState(0);
+  const jobIdRef = useRef<string | null>(null);
+
+  // Small conversions can finish before ConvertSparseImage resolves.
+  const finishedEarlyRef = useRef<Record<string, backend.Job>>({});
+
+  const handleFinished = (job: backend.Job) => {
+    jobIdRef.current = null;
+    setJobId(null);
+
+    if (job.Status === "succeeded") {
+      toast.success("Conversion complete", { description: job.Result });
+    } else if (job.Status === "failed") {
+      toast.error("Conversion failed", { description: job.Error });
+    }
+  };
+
+  useEffect(() => {
+    const offUpdated = EventsOn("job:updated", (job: backend.Job) => {
+      if (job.ID === jobIdRef.current) setProgress(job.Progress);
+    });
+    const offFinished = EventsOn("job:finished", (job: backend.Job) => {
+      if (job.ID !== jobIdRef.current) {
+        if (job.Kind === "sparse-convert") finishedEarlyRef.current[job.ID] = job;
+        return;
+      }
+      handleFinished(job);
+    });
+    return () => {
+      offUpdated();
+      offFinished();
+    };
+  }, []);
+
+  const handleSelect = async () => {
+    try {
+      const path = await SelectImageFile();
+      if (!path) return;
+      setIsLoading(true);
+      setImage(await InspectSparseImage(path));
+    } catch (error) {
+      toast.error("Failed to read image", { description: errorMessage(error) });
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleConvert = async () => {
+    if (!image) return;
+    const toSparse = !image.IsSparse;
+    const name = image.Path.split(/[/\\]/).pop()?.replace(/\.img$/i, "") ?? "image";
+    try {
+      const destination = await SelectSaveFile(`${name}.${toSparse ? "sparse" : "raw"}.img`);
+      if (!destination) return;
+      setProgress(0);
+      const id = await ConvertSparseImage(image.Path, destination, toSparse);
+      const finished = finishedEarlyRef.current[id];
+      finishedEarlyRef.current = {};
+      if (finished) {
+        handleFinished(finished);
+        return;
+      }
+      jobIdRef.current = id;
+      setJobId(id);
+    } catch (error) {
+      toast.error("Failed to start conversion", { description: errorMessage(error) });
+    }
+  };
+
+  const handleCancel = async () => {
+    if (!jobId) return;
+    try {
+      await CancelJob(jobId);
+    } catch (error) {
+      toast.error("Failed to cancel", { description: errorMessage(error) });
+    }
+  };
+
+  const isRunning = jobId !== null;
+
+  const details: [string, string][] = image?.IsSparse
+    ? [
+        ["On disk", formatBytes(image.FileSize)],
+        ["Expanded", formatBytes(image.LogicalSize)],
+        ["Block size", String(image.BlockSize)],
+        ["Chunks", String(image.TotalChunks)],
+        ["Raw", `${image.RawChunks} (${formatBytes(image.RawBytes)})`],
+        ["Fill", String(image.FillChunks)],
+        ["Don't care", String(image.DontCareChunks)],
+        ["CRC32", String(image.CRCChunks)],
+      ]
+    : [];
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Layers />
+          Sparse Image
+        </CardTitle>
+        <CardDescription>Check whether an image is an Android sparse image and convert it between sparse and raw.</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-4">
+        <Button variant="outline" onClick={handleSelect} disabled={isLoading || isRunning}>
+          {isLoading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+          Select Image
+        </Button>
+
+        {image && (
+          <>
+            <div className="space-y-3 rounded-lg border bg-muted/50 p-3 text-sm">
+              <p className="truncate font-mono">{image.Path}</p>
+              {image.IsSparse ? (
+                <div className="grid grid-cols-2 gap-x-4 gap-y-1 md:grid-cols-4">
+                  {details.map(([label, value]) => (
+                    <div key={label}>
+                      <span className="text-muted-foreground">{label}: </span>
+                      {value}
+                    </div>
+                  ))}
+                </div>
+              ) : (
+                <p className="text-muted-foreground">Raw image, {formatBytes(image.FileSize)}.</p>
+              )}
+            </div>
+
+            {isRunning ? (
+              <div className="flex items-center gap-4">
+                <div className="h-2 flex-1 overflow-hidden rounded-full bg-muted">
+                  <div className="h-full bg-primary transition-all" style={{ width: `${progress}%` }} />
+                </div>
+                <span className="text-sm text-muted-foreground">{Math.floor(progress)}%</span>
+                <Button variant="outline" size="sm" onClick={handleCancel}>
+                  Cancel
+                </Button>
+              </div>
+            ) : (
+              <Button onClick={handleConvert}>{image.IsSparse ? "Convert to Raw" : "Convert to Sparse"}</Button>
+            )}
+          </>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,185 @@
+import React, { useEffect, useRef, useState } from "react";
+import { toast } from "sonner";
+import { CancelJob, ExtractSuperPartitions, InspectSuperImage, SelectDirectoryForPull, SelectImageFile } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Checkbox } from "@/components/ui/checkbox";
+import { FileUp, Layers3, Loader2 } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { formatBytes } from "@/lib/utils";
+
+interface SuperImageCardProps {
+  onUseImage: (partition: string, filePath: string) => void;
+}
+
+export function SuperImageCard({ onUseImage }: SuperImageCardProps) {
+  const [image, setImage] = useState<backend.SuperImageInfo | null>(null);
+  const [selected, setSelected] = useState<string[]>([]);
+  const [isLoading, setIsLoading] = useState(false);
+  const [jobId, setJobId] = useState<string | null>(null);
+  const [progress, setProgress] = useState(0);
+  const [extracted, setExtracted] = useState<{ partition: string; path: string }[]>([]);
+  const jobRef = useRef<{ id: string; outputDir: string; partitions: string[] } | null>(null);
+
+  // Small extractions can finish before ExtractSuperPartitions resolves.
+  const finishedEarlyRef = useRef<Record<string, backend.Job>>({});
+
+  const handleFinished = (job: backend.Job, outputDir: string, partitions: string[]) => {
+    jobRef.current = null;
+    setJobId(null);
+
+    if (job.Status === "succeeded") {
+      const separator = outputDir.includes("\\") ? "\\" : "/";
+      setExtracted(partitions.map((partition) => ({ partition, path: `${outputDir}${separator}${partition}.img` })));
+      toast.success("Extraction complete", { description: job.Result });
+    } else if (job.Status === "failed") {
+      toast.error("Extraction failed", { description: job.Error });
+    }
+  };
+
+  useEffect(() => {
+    const offUpdated = EventsOn("job:updated", (job: backend.Job) => {
+      if (job.ID === jobRef.current?.id) setProgress(job.Progress);
+    });
+    const offFinished = EventsOn("job:finished", (job: backend.Job) => {
+      const current = jobRef.current;
+      if (!current || job.ID !== current.id) {
+        if (job.Kind === "super-extract") finishedEarlyRef.current[job.ID] = job;
+        return;
+      }
+      handleFinished(job, current.outputDir, current.partitions);
+    });
+    return () => {
+      offUpdated();
+      offFinished();
+    };
+  }, []);
+
+  const handleSelectImage = async () => {
+    try {
+      const path = await SelectImageFile();
+      if (!path) return;
+      setIsLoading(true);
+      setExtracted([]);
+      const info = await InspectSuperImage(path);
+      setImage(info);
+      setSelected(info.Partitions.filter((p) => p.Size > 0).map((p) => p.Name));
+    } catch (error) {
+      toast.error("Failed to read super image", { description: errorMessage(error) });
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const togglePartition = (name: string, checked: boolean) => {
+    setSelected((prev) => (checked ? [...prev, name] : prev.filter((item) => item !== name)));
+  };
+
+  const handleExtract = async () => {
+    if (!image || selected.length === 0) return;
+    try {
+      const outputDir = await SelectDirectoryForPull();
+      if (!outputDir) return;
+      setProgress(0);
+      setExtracted([]);
+      const id = await ExtractSuperPartitions(image.Path, selected, outputDir);
+      const partitions = [...selected];
+      const finished = finishedEarlyRef.current[id];
+      finishedEarlyRef.current = {};
+      if (finished) {
+        handleFinished(finished, outputDir, partitions);
+        return;
+      }
+      jobRef.current = { id, outputDir, partitions };
+      setJobId(id);
+    } catch (error) {
+      toast.error("Failed to start extraction", { description: errorMessage(error) });
+    }
+  };
+
+  const handleCancel = async () => {
+    if (!jobId) return;
+    try {
+      await CancelJob(jobId);
+    } catch (error) {
+      toast.error("Failed to cancel", { description: errorMessage(error) });
+    }
+  };
+
+  const isRunning = jobId !== null;
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Layers3 />
+          Super Image Unpacker
+        </CardTitle>
+        <CardDescription>List the logical partitions inside a raw or sparse super.img and extract them to flash individually in fastbootd.</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-4">
+        <Button variant="outline" onClick={handleSelectImage} disabled={isLoading || isRunning}>
+          {isLoading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+          Select super.img
+        </Button>
+
+        {image && (
+          <>
+            <div className="space-y-1 text-sm">
+              <p className="truncate font-mono">{image.Path}</p>
+              <p className="text-muted-foreground">
+                {image.IsSparse ? "Sparse" : "Raw"} · LP metadata {image.MetadataVersion} · {image.SlotCount} metadata slots
+                {image.BlockDevices.map((device) => ` · ${device.Name} ${formatBytes(device.Size)}`).join("")}
+              </p>
+              <p className="text-muted-foreground">
+                Groups: {image.Groups.map((group) => (group.MaximumSize ? `${group.Name} (max ${formatBytes(group.MaximumSize)})` : group.Name)).join(", ")}
+              </p>
+            </div>
+            <div className="grid max-h-64 grid-cols-1 gap-2 overflow-y-auto md:grid-cols-2">
+              {image.Partitions.map((partition) => (
+                <label key={partition.Name} className="flex items-center gap-2 rounded-md bg-muted p-2 text-sm" title={partition.Attributes.join(", ") || undefined}>
+                  <Checkbox checked={selected.includes(partition.Name)} onCheckedChange={(checked) => togglePartition(partition.Name, Boolean(checked))} disabled={partition.Size === 0 || isRunning} />
+                  <span className="font-mono">{partition.Name}</span>
+                  <span className="text-xs text-muted-foreground">{partition.Group}</span>
+                  <span className="ml-auto text-muted-foreground">{partition.Size ? formatBytes(partition.Size) : "empty"}</span>
+                </label>
+              ))}
+            </div>
+
+            {isRunning ? (
+              <div className="flex items-center gap-4">
+                <div className="h-2 flex-1 overflow-hidden rounded-full bg-muted">
+                  <div className="h-full bg-primary transition-all" style={{ width: `${progress}%` }} />
+                </div>
+                <span className="text-sm text-muted-foreground">{Math.floor(progress)}%</span>
+                <Button variant="outline" size="sm" onClick={handleCancel}>
+                  Cancel
+                </Button>
+              </div>
+            ) : (
+              <Button onClick={handleExtract} disabled={selected.length === 0}>
+                Extract {selected.length} {selected.length === 1 ? "Partition" : "Partitions"}
+              </Button>
+            )}
+          </>
+        )}
+
+        {extracted.length > 0 && (
+          <div className="flex flex-col gap-2">
+            {extracted.map((entry) => (
+              <div key={entry.partition} className="flex items-center justify-between gap-2 rounded-md bg-muted p-2 text-sm">
+                <span className="truncate font-mono">{entry.path}</span>
+                <Button variant="outline" size="sm" onClick={() => onUseImage(entry.partition, entry.path)}>
+                  <FileUp className="mr-2 h-4 w-4" />
+                  Use for Flashing
+                </Button>
+              </div>
+            ))}
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
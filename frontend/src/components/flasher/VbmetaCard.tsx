@@ -0,0 +1,187 @@
+import React, { useEffect, useRef, useState } from "react";
+import { toast } from "sonner";
+import { FlashVbmeta, InspectVbmeta, SelectImageFile } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Label } from "@/components/ui/label";
+import { AlertDialog, AlertDialogAction, AlertDialogCancel, AlertDialogContent, AlertDialogDescription, AlertDialogFooter, AlertDialogHeader, AlertDialogTitle, AlertDialogTrigger } from "@/components/ui/alert-dialog";
+import { Loader2, ShieldOff } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { formatBytes } from "@/lib/utils";
+
+interface VbmetaCardProps {
+  canFlash: boolean;
+}
+
+const describeDescriptor = (descriptor: backend.VbmetaDescriptor): string => {
+  switch (descriptor.Type) {
+    case "hash":
+    case "hashtree":
+      return `${descriptor.Partition} · ${formatBytes(descriptor.ImageSize)} · ${descriptor.HashAlgorithm}`;
+    case "chain_partition":
+      return `${descriptor.Partition} · rollback location ${descriptor.RollbackIndexLocation} · key ${descriptor.PublicKeySHA1.slice(0, 12)}`;
+    case "property":
+      return `${descriptor.Key} = ${descriptor.Value}`;
+    default:
+      return descriptor.Cmdline;
+  }
+};
+
+export function VbmetaCard({ canFlash }: VbmetaCardProps) {
+  const [vbmeta, setVbmeta] = useState<backend.VbmetaInfo | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+  const [isFlashing, setIsFlashing] = useState(false);
+  const [disableVerity, setDisableVerity] = useState(true);
+  const [disableVerification, setDisableVerification] = useState(true);
+  const jobRef = useRef<{ id: string; toastId: string | number } | null>(null);
+
+  // A small image can finish flashing before FlashVbmeta resolves.
+  const finishedEarlyRef = useRef<Record<string, backend.Job>>({});
+
+  const handleFinished = (job: backend.Job, toastId: string | number) => {
+    jobRef.current = null;
+    setIsFlashing(false);
+    if (job.Status === "succeeded") {
+      toast.success("vbmeta flashed", { id: toastId });
+    } else if (job.Status === "cancelled") {
+      toast.info("Flash cancelled", { id: toastId });
+    } else {
+      toast.error("Flash Failed", { description: job.Error, id: toastId });
+    }
+  };
+
+  useEffect(() => {
+    return EventsOn("job:finished", (job: backend.Job) => {
+      const current = jobRef.current;
+      if (current && job.ID === current.id) {
+        handleFinished(job, current.toastId);
+      } else if (job.Kind === "vbmeta-flash") {
+        finishedEarlyRef.current[job.ID] = job;
+      }
+    });
+  }, []);
+
+  const handleSelect = async () => {
+    try {
+      const path = await SelectImageFile();
+      if (!path) return;
+      setIsLoading(true);
+      setVbmeta(await InspectVbmeta(path));
+    } catch (error) {
+      setVbmeta(null);
+      toast.error("Failed to read AVB metadata", { description: errorMessage(error) });
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const handleFlash = async () => {
+    if (!vbmeta) return;
+    setIsFlashing(true);
+    const toastId = toast.loading("Flashing vbmeta...");
+    try {
+      const id = await FlashVbmeta(vbmeta.Path, disableVerity, disableVerification);
+      const finished = finishedEarlyRef.current[id];
+      finishedEarlyRef.current = {};
+      if (finished) {
+        handleFinished(finished, toastId);
+        return;
+      }
+      jobRef.current = { id, toastId };
+    } catch (error) {
+      toast.error("Flash Failed", { description: errorMessage(error), id: toastId });
+      setIsFlashing(false);
+    }
+  };
+
+  const details: [string, string][] = vbmeta
+    ? [
+        ["Algorithm", vbmeta.Algorithm],
+        ["Rollback index", `${vbmeta.RollbackIndex} (location ${vbmeta.RollbackIndexLocation})`],
+        ["Flags", vbmeta.HashtreeDisabled || vbmeta.VerificationDisabled ? [vbmeta.HashtreeDisabled && "verity off", vbmeta.VerificationDisabled && "verification off"].filter(Boolean).join(", ") : "None"],
+        ["libavb", vbmeta.LibavbVersion],
+        ["Release", vbmeta.ReleaseString || "-"],
+        ["Public key", vbmeta.PublicKeySHA1 ? vbmeta.PublicKeySHA1.slice(0, 16) : "Unsigned"],
+      ]
+    : [];
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <ShieldOff />
+          vbmeta / AVB
+        </CardTitle>
+        <CardDescription>Read Android Verified Boot metadata from vbmeta or a signed partition image, and flash vbmeta with verity and verification disabled.</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-4">
+        <Button variant="outline" onClick={handleSelect} disabled={isLoading || isFlashing}>
+          {isLoading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+          Select Image
+        </Button>
+
+        {vbmeta && (
+          <div className="space-y-3 rounded-lg border bg-muted/50 p-3 text-sm">
+            <p className="truncate font-mono">{vbmeta.Path}</p>
+            {vbmeta.Footer && <p className="text-muted-foreground">AVB footer on a {formatBytes(vbmeta.OriginalImageSize)} partition image.</p>}
+            <div className="grid grid-cols-2 gap-x-4 gap-y-1 md:grid-cols-3">
+              {details.map(([label, value]) => (
+                <div key={label}>
+                  <span className="text-muted-foreground">{label}: </span>
+                  {value}
+                </div>
+              ))}
+            </div>
+            {vbmeta.Descriptors.length > 0 && (
+              <div className="flex max-h-64 flex-col gap-1 overflow-y-auto">
+                {vbmeta.Descriptors.map((descriptor, index) => (
+                  <div key={index} className="flex gap-2">
+                    <span className="w-32 shrink-0 text-muted-foreground">{descriptor.Type}</span>
+                    <span className="break-all font-mono text-xs">{describeDescriptor(descriptor)}</span>
+                  </div>
+                ))}
+              </div>
+            )}
+          </div>
+        )}
+
+        <div className="flex flex-wrap gap-4">
+          <div className="flex items-center gap-2">
+            <Checkbox id="vbmeta-verity" checked={disableVerity} onCheckedChange={(checked) => setDisableVerity(Boolean(checked))} disabled={isFlashing} />
+            <Label htmlFor="vbmeta-verity">--disable-verity</Label>
+          </div>
+          <div className="flex items-center gap-2">
+            <Checkbox id="vbmeta-verification" checked={disableVerification} onCheckedChange={(checked) => setDisableVerification(Boolean(checked))} disabled={isFlashing} />
+            <Label htmlFor="vbmeta-verification">--disable-verification</Label>
+          </div>
+        </div>
+
+        <AlertDialog>
+          <AlertDialogTrigger asChild>
+            <Button disabled={!vbmeta || vbmeta.Footer || !canFlash || isFlashing}>
+              {isFlashing ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : <ShieldOff className="mr-2 h-4 w-4" />}
+              Flash vbmeta
+            </Button>
+          </AlertDialogTrigger>
+          <AlertDialogContent>
+            <AlertDialogHeader>
+              <AlertDialogTitle>Flash vbmeta?</AlertDialogTitle>
+              <AlertDialogDescription>
+                {disableVerity || disableVerification
+                  ? "Turning verified boot off needs an unlocked bootloader, and switching between verified and unverified boot usually requires a data wipe before the device boots again."
+                  : "The vbmeta partition is replaced with the selected image."}
+              </AlertDialogDescription>
+            </AlertDialogHeader>
+            <AlertDialogFooter>
+              <AlertDialogCancel>Cancel</AlertDialogCancel>
+              <AlertDialogAction onClick={handleFlash}>Flash</AlertDialogAction>
+            </AlertDialogFooter>
+          </AlertDialogContent>
+        </AlertDialog>
+      </CardContent>
+    </Card>
+  );
+}
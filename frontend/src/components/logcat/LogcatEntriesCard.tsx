@@ -0,0 +1,96 @@
+import React, { useEffect, useRef } from "react";
+import { useVirtualizer } from "@tanstack/react-virtual";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { ScrollText } from "lucide-react";
+import { cn } from "@/lib/utils";
+
+// Mirrors backend.LogEntry.
+export type LogEntry = {
+  Time: string;
+  Pid: number;
+  Tid: number;
+  Level: string;
+  Tag: string;
+  Message: string;
+};
+
+const ROW_HEIGHT = 22;
+
+const LEVEL_CLASS: Record<string, string> = {
+  V: "text-muted-foreground",
+  D: "text-sky-500",
+  I: "text-emerald-500",
+  W: "text-amber-500",
+  E: "text-destructive",
+  F: "text-destructive font-bold",
+  A: "text-destructive font-bold",
+};
+
+interface LogcatEntriesCardProps {
+  entries: LogEntry[];
+  isRunning: boolean;
+  followTail: boolean;
+}
+
+export function LogcatEntriesCard({ entries, isRunning, followTail }: LogcatEntriesCardProps) {
+  const parentRef = useRef<HTMLDivElement>(null);
+
+  const virtualizer = useVirtualizer({
+    count: entries.length,
+    getScrollElement: () => parentRef.current,
+    estimateSize: () => ROW_HEIGHT,
+    overscan: 20,
+  });
+
+  useEffect(() => {
+    if (followTail && entries.length > 0) {
+      virtualizer.scrollToIndex(entries.length - 1, { align: "end" });
+    }
+  }, [entries.length, followTail, virtualizer]);
+
+  return (
+    <Card className="flex flex-1 flex-col overflow-hidden">
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <ScrollText />
+          Log Entries
+        </CardTitle>
+        <CardDescription>
+          {entries.length} entries{isRunning ? " · streaming" : ""}
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-1 flex-col overflow-hidden p-4 pt-0">
+        <div ref={parentRef} className="flex-1 overflow-auto rounded-md border bg-muted/50 font-mono text-xs custom-scroll">
+          {entries.length === 0 ? (
+            <div className="flex h-32 items-center justify-center text-muted-foreground">{isRunning ? "Waiting for log entries..." : "Start logcat to see entries."}</div>
+          ) : (
+            <div style={{ height: `${virtualizer.getTotalSize()}px`, width: "100%", position: "relative" }}>
+              {virtualizer.getVirtualItems().map((virtualRow) => {
+                const entry = entries[virtualRow.index];
+                return (
+                  <div
+                    key={virtualRow.index}
+                    className="absolute top-0 left-0 flex w-full items-center gap-3 whitespace-nowrap px-3 hover:bg-muted"
+                    style={{ height: `${ROW_HEIGHT}px`, transform: `translateY(${virtualRow.start}px)` }}
+                  >
+                    <span className="w-36 flex-shrink-0 text-muted-foreground">{entry.Time}</span>
+                    <span className="w-24 flex-shrink-0 text-right text-muted-foreground">
+                      {entry.Pid ? `${entry.Pid}/${entry.Tid}` : ""}
+                    </span>
+                    <span className={cn("w-3 flex-shrink-0 font-bold", LEVEL_CLASS[entry.Level])}>{entry.Level}</span>
+                    <span className="w-40 flex-shrink-0 truncate text-primary" title={entry.Tag}>
+                      {entry.Tag}
+                    </span>
+                    <span className={cn("truncate", ["E", "F", "A"].includes(entry.Level) && "text-destructive")} title={entry.Message}>
+                      {entry.Message}
+                    </span>
+                  </div>
+                );
+              })}
+            </div>
+          )}
+        </div>
+      </CardContent>
+    </Card>
+  );
+}
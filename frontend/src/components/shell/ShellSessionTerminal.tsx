@@ -0,0 +1,142 @@
+import React, { useEffect, useRef, useState } from "react";
+import { GetShellScrollback, ResizeShell, WriteShell } from "../../../wailsjs/go/backend/App";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
+import { TerminalBuffer, keyToInput } from "@/lib/terminal";
+import { cn } from "@/lib/utils";
+
+// Mirrors backend.ShellOutput / backend.ShellExit event payloads.
+type ShellOutput = {
+  SessionID: string;
+  Stream: string;
+  Data: string;
+};
+
+type ShellExit = {
+  SessionID: string;
+  ExitCode: number;
+  Error: string;
+};
+
+interface ShellSessionTerminalProps {
+  sessionId: string;
+  exited: boolean;
+  onExit: (sessionId: string, exit: ShellExit) => void;
+}
+
+const CHAR_WIDTH_PX = 8.4;
+const LINE_HEIGHT_PX = 20;
+
+export function ShellSessionTerminal({ sessionId, exited: alreadyExited, onExit }: ShellSessionTerminalProps) {
+  const [text, setText] = useState("");
+  const [exited, setExited] = useState<ShellExit | null>(null);
+  const containerRef = useRef<HTMLDivElement>(null);
+  const bufferRef = useRef(new TerminalBuffer());
+  const onExitRef = useRef(onExit);
+  onExitRef.current = onExit;
+
+  useEffect(() => {
+    const buffer = bufferRef.current;
+    buffer.clear();
+    setText("");
+    setExited(alreadyExited ? { SessionID: sessionId, ExitCode: -1, Error: "session closed" } : null);
+
+    let ready = false;
+    const queued: string[] = [];
+
+    const offOutput = EventsOn("shell:output", (output: ShellOutput) => {
+      if (output.SessionID !== sessionId) return;
+      if (!ready) {
+        queued.push(output.Data);
+        return;
+      }
+      buffer.write(output.Data);
+      setText(buffer.toString());
+    });
+
+    const offExit = EventsOn("shell:exit", (exit: ShellExit) => {
+      if (exit.SessionID !== sessionId) return;
+      setExited(exit);
+      onExitRef.current(sessionId, exit);
+    });
+
+    // Redraw what the session printed while this terminal was not mounted.
+    GetShellScrollback(sessionId)
+      .then((scrollback) => buffer.write(scrollback))
+      .catch(() => {})
+      .finally(() => {
+        ready = true;
+        queued.splice(0).forEach((data) => buffer.write(data));
+        setText(buffer.toString());
+      });
+
+    return () => {
+      offOutput();
+      offExit();
+    };
+    // alreadyExited only matters when the terminal is (re)mounted.
+  }, [sessionId]);
+
+  useEffect(() => {
+    const container = containerRef.current;
+    if (!container) return;
+
+    const resize = () => {
+      const cols = Math.max(20, Math.floor((container.clientWidth - 32) / CHAR_WIDTH_PX));
+      const rows = Math.max(5, Math.floor((container.clientHeight - 32) / LINE_HEIGHT_PX));
+      ResizeShell(sessionId, cols, rows).catch(() => {});
+    };
+
+    resize();
+    const observer = new ResizeObserver(resize);
+    observer.observe(container);
+    container.focus();
+    return () => observer.disconnect();
+  }, [sessionId]);
+
+  useEffect(() => {
+    const container = containerRef.current;
+    if (container) {
+      container.scrollTop = container.scrollHeight;
+    }
+  }, [text]);
+
+  const send = (data: string) => {
+    if (exited) return;
+    WriteShell(sessionId, data).catch(() => {});
+  };
+
+  const handleKeyDown = (e: React.KeyboardEvent<HTMLDivElement>) => {
+    // Leave Ctrl+Shift+C / Ctrl+Shift+V to the browser for copy and paste.
+    if (e.ctrlKey && e.shiftKey) return;
+
+    const input = keyToInput(e);
+    if (input === null) return;
+    e.preventDefault();
+    send(input);
+  };
+
+  const handlePaste = (e: React.ClipboardEvent<HTMLDivElement>) => {
+    e.preventDefault();
+    send(e.clipboardData.getData("text"));
+  };
+
+  return (
+    <div
+      ref={containerRef}
+      tabIndex={0}
+      onKeyDown={handleKeyDown}
+      onPaste={handlePaste}
+      className="flex-1 overflow-auto rounded-md border bg-muted/50 p-4 outline-none focus:ring-1 focus:ring-ring"
+    >
+      <pre className="whitespace-pre-wrap break-all font-mono text-sm leading-5">
+        {text}
+        {!exited && <span className="animate-pulse bg-primary">&nbsp;</span>}
+      </pre>
+      {exited && (
+        <p className={cn("mt-2 font-mono text-xs", exited.ExitCode === 0 ? "text-muted-foreground" : "text-destructive")}>
+          {exited.Error ? `[session ended: ${exited.Error}]` : `[process exited with code ${exited.ExitCode}]`}
+        </p>
+      )}
+    </div>
+  );
+}
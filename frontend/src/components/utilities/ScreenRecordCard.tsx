@@ -0,0 +1,155 @@
+import React, { useEffect, useRef, useState } from "react";
+import { toast } from "sonner";
+import { SelectSaveFile, StartScreenRecording, StopScreenRecording } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Circle, Loader2, Square, Video } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+
+// Mirrors backend.RecordingStatus event payloads.
+type RecordingStatus = {
+  JobID: string;
+  State: "recording" | "stopping" | "pulling" | "stitching" | "done" | "failed";
+  Segment: number;
+  ElapsedSeconds: number;
+  OutputPath: string;
+  Error: string;
+};
+
+const STATE_LABEL: Record<RecordingStatus["State"], string> = {
+  recording: "Recording",
+  stopping: "Stopping",
+  pulling: "Downloading segments",
+  stitching: "Joining segments",
+  done: "Saved",
+  failed: "Failed",
+};
+
+export function ScreenRecordCard({ canRecord }: { canRecord: boolean }) {
+  const [bitRateMbps, setBitRateMbps] = useState("");
+  const [size, setSize] = useState("");
+  const [timeLimit, setTimeLimit] = useState("");
+  const [jobId, setJobId] = useState<string | null>(null);
+  const [status, setStatus] = useState<RecordingStatus | null>(null);
+  const [startedAt, setStartedAt] = useState<number | null>(null);
+  const [now, setNow] = useState(Date.now());
+  // Status events can arrive before StartScreenRecording resolves.
+  const pendingStatus = useRef<Record<string, RecordingStatus>>({});
+  const jobIdRef = useRef<string | null>(null);
+
+  useEffect(() => {
+    return EventsOn("recording:status", (next: RecordingStatus) => {
+      if (next.JobID !== jobIdRef.current) {
+        pendingStatus.current[next.JobID] = next;
+        return;
+      }
+      setStatus(next);
+      if (next.State === "done") {
+        toast.success("Recording saved", { description: next.OutputPath });
+        jobIdRef.current = null;
+        setJobId(null);
+      } else if (next.State === "failed") {
+        toast.error("Recording failed", { description: next.Error });
+        jobIdRef.current = null;
+        setJobId(null);
+      }
+    });
+  }, []);
+
+  useEffect(() => {
+    if (status?.State !== "recording") return;
+    const timer = window.setInterval(() => setNow(Date.now()), 1000);
+    return () => window.clearInterval(timer);
+  }, [status?.State]);
+
+  const handleStart = async () => {
+    try {
+      const timestamp = new Date().toISOString().replace(/[:.]/g, "-");
+      const outputPath = await SelectSaveFile(`recording-${timestamp}.mp4`);
+      if (!outputPath) return;
+
+      const id = await StartScreenRecording(
+        backend.RecordingOptions.createFrom({
+          BitRate: Math.round((parseFloat(bitRateMbps) || 0) * 1_000_000),
+          Size: size.trim(),
+          TimeLimit: parseInt(timeLimit, 10) || 0,
+          DisplayID: "",
+          OutputPath: outputPath,
+        })
+      );
+      jobIdRef.current = id;
+      setJobId(id);
+      setStatus(pendingStatus.current[id] ?? null);
+      pendingStatus.current = {};
+      setStartedAt(Date.now());
+    } catch (error) {
+      toast.error("Failed to start recording", { description: errorMessage(error) });
+    }
+  };
+
+  const handleStop = async () => {
+    if (!jobId) return;
+    try {
+      await StopScreenRecording(jobId);
+    } catch (error) {
+      toast.error("Failed to stop recording", { description: errorMessage(error) });
+    }
+  };
+
+  const isActive = jobId !== null;
+  const elapsed = startedAt && status?.State === "recording" ? Math.floor((now - startedAt) / 1000) : Math.floor(status?.ElapsedSeconds ?? 0);
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Video />
+          Screen Recording
+        </CardTitle>
+        <CardDescription>Recordings longer than 3 minutes are recorded in segments and joined automatically.</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-4">
+        <div className="grid gap-4 md:grid-cols-3">
+          <div className="flex flex-col gap-2">
+            <Label htmlFor="record-bitrate">Bit rate (Mbps)</Label>
+            <Input id="record-bitrate" placeholder="Default (20)" value={bitRateMbps} onChange={(e) => setBitRateMbps(e.target.value)} disabled={isActive} />
+          </div>
+          <div className="flex flex-col gap-2">
+            <Label htmlFor="record-size">Size</Label>
+            <Input id="record-size" placeholder="Native (e.g. 1280x720)" value={size} onChange={(e) => setSize(e.target.value)} disabled={isActive} />
+          </div>
+          <div className="flex flex-col gap-2">
+            <Label htmlFor="record-limit">Time limit (seconds)</Label>
+            <Input id="record-limit" placeholder="Until stopped" value={timeLimit} onChange={(e) => setTimeLimit(e.target.value)} disabled={isActive} />
+          </div>
+        </div>
+
+        <div className="flex items-center gap-4">
+          {isActive ? (
+            <Button variant="destructive" onClick={handleStop} disabled={status?.State !== undefined && status.State !== "recording"}>
+              <Square className="mr-2 h-4 w-4" />
+              Stop
+            </Button>
+          ) : (
+            <Button onClick={handleStart} disabled={!canRecord}>
+              <Circle className="mr-2 h-4 w-4 fill-current text-destructive" />
+              Record
+            </Button>
+          )}
+
+          {status && (
+            <span className="flex items-center gap-2 text-sm text-muted-foreground">
+              {isActive && status.State !== "recording" && <Loader2 className="h-4 w-4 animate-spin" />}
+              {STATE_LABEL[status.State]}
+              {status.State === "recording" && ` · ${Math.floor(elapsed / 60)}:${String(elapsed % 60).padStart(2, "0")} · segment ${status.Segment}`}
+            </span>
+          )}
+        </div>
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,141 @@
+import React, { useEffect, useState } from "react";
+import { toast } from "sonner";
+import { CaptureScreenshot, GetDisplays, GetTargetDevice, SelectDirectoryForPull } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Label } from "@/components/ui/label";
+import { Camera, FolderOpen, Loader2, Monitor } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { getNickname } from "@/lib/nicknameStore";
+import { cn } from "@/lib/utils";
+
+const SETTINGS_STORAGE_KEY = "adb-kit-screenshot-settings";
+
+type ScreenshotSettings = {
+  save: boolean;
+  saveDir: string;
+  template: string;
+};
+
+function loadSettings(): ScreenshotSettings {
+  const defaults = { save: false, saveDir: "", template: "{nickname}_{timestamp}" };
+  try {
+    const stored = localStorage.getItem(SETTINGS_STORAGE_KEY);
+    return stored ? { ...defaults, ...JSON.parse(stored) } : defaults;
+  } catch {
+    return defaults;
+  }
+}
+
+export function ScreenshotCard({ canCapture }: { canCapture: boolean }) {
+  const [settings, setSettings] = useState<ScreenshotSettings>(loadSettings);
+  const [displays, setDisplays] = useState<backend.DisplayInfo[]>([]);
+  const [displayId, setDisplayId] = useState("");
+  const [preview, setPreview] = useState<backend.Screenshot | null>(null);
+  const [isCapturing, setIsCapturing] = useState(false);
+
+  useEffect(() => {
+    localStorage.setItem(SETTINGS_STORAGE_KEY, JSON.stringify(settings));
+  }, [settings]);
+
+  useEffect(() => {
+    if (!canCapture) return;
+    GetDisplays()
+      .then((list) => setDisplays(list || []))
+      .catch(() => setDisplays([]));
+  }, [canCapture]);
+
+  const updateSettings = (patch: Partial<ScreenshotSettings>) => setSettings((prev) => ({ ...prev, ...patch }));
+
+  const handleSelectFolder = async () => {
+    try {
+      const dir = await SelectDirectoryForPull();
+      if (dir) updateSettings({ saveDir: dir });
+    } catch (error) {
+      toast.error("Failed to select folder", { description: errorMessage(error) });
+    }
+  };
+
+  const handleCapture = async () => {
+    setIsCapturing(true);
+    try {
+      const serial = await GetTargetDevice();
+      const shot = await CaptureScreenshot(
+        backend.ScreenshotOptions.createFrom({
+          DisplayID: displayId,
+          Save: settings.save,
+          SaveDir: settings.saveDir,
+          FilenameTemplate: settings.template,
+          Nickname: (serial && getNickname(serial)) || "",
+        })
+      );
+      setPreview(shot);
+      if (shot.Path) {
+        toast.success("Screenshot saved", { description: shot.Path });
+      }
+    } catch (error) {
+      toast.error("Failed to capture screenshot", { description: errorMessage(error) });
+    } finally {
+      setIsCapturing(false);
+    }
+  };
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Camera />
+          Screenshot
+        </CardTitle>
+        <CardDescription>Capture the device screen. Filename template supports {"{nickname}"}, {"{model}"}, {"{serial}"} and {"{timestamp}"}.</CardDescription>
+      </CardHeader>
+      <CardContent className="grid gap-6 md:grid-cols-2">
+        <div className="flex flex-col gap-4">
+          {displays.length > 1 && (
+            <div className="flex flex-wrap items-center gap-2">
+              <Monitor className="h-4 w-4 text-muted-foreground" />
+              <Button variant="outline" size="sm" className={cn(displayId === "" && "border-primary bg-primary/10")} onClick={() => setDisplayId("")}>
+                Default
+              </Button>
+              {displays.map((display, index) => (
+                <Button key={display.ID} variant="outline" size="sm" className={cn(displayId === display.ID && "border-primary bg-primary/10")} onClick={() => setDisplayId(display.ID)} title={display.Description}>
+                  Display {index}
+                </Button>
+              ))}
+            </div>
+          )}
+
+          <div className="flex items-center gap-2">
+            <Checkbox id="screenshot-save" checked={settings.save} onCheckedChange={(checked) => updateSettings({ save: Boolean(checked) })} />
+            <Label htmlFor="screenshot-save">Save to folder</Label>
+          </div>
+
+          <div className="flex gap-2">
+            <Input placeholder="Screenshot folder" value={settings.saveDir} onChange={(e) => updateSettings({ saveDir: e.target.value })} className="font-mono" disabled={!settings.save} />
+            <Button variant="outline" size="icon" onClick={handleSelectFolder} disabled={!settings.save}>
+              <FolderOpen className="h-4 w-4" />
+            </Button>
+          </div>
+
+          <Input placeholder="{nickname}_{timestamp}" value={settings.template} onChange={(e) => updateSettings({ template: e.target.value })} className="font-mono" disabled={!settings.save} />
+
+          <Button onClick={handleCapture} disabled={!canCapture || isCapturing || (settings.save && !settings.saveDir)}>
+            {isCapturing ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : <Camera className="mr-2 h-4 w-4" />}
+            Capture
+          </Button>
+        </div>
+
+        <div className="flex min-h-48 items-center justify-center rounded-md border bg-muted/50 p-2">
+          {preview ? (
+            <img src={`data:image/png;base64,${preview.Base64}`} alt={`Screenshot ${preview.Width}x${preview.Height}`} className="max-h-96 rounded object-contain" />
+          ) : (
+            <span className="text-sm text-muted-foreground">No screenshot yet.</span>
+          )}
+        </div>
+      </CardContent>
+    </Card>
+  );
+}
@@ -1,7 +1,10 @@
 import React, { useState, useEffect } from "react";
 
-import { GetDevices, GetDeviceInfo } from "../../../wailsjs/go/backend/App";
+import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
+import { GetDevices, GetDeviceInfo, GetTargetDevice, SetTargetDevice } from "../../../wailsjs/go/backend/App";
 import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
 import { DeviceListCard } from "../dashboard/DeviceListCard";
 import { WirelessAdbCard } from "../dashboard/WirelessAdbCard";
 import { DeviceInfoCard } from "../dashboard/DeviceInfoCard";
@@ -14,6 +17,7 @@ export function ViewDashboard({ activeView }: { activeView: string }) {
   const [deviceInfo, setDeviceInfo] = useState<DeviceInfo | null>(null);
   const [isRefreshingDevices, setIsRefreshingDevices] = useState(false);
   const [isRefreshingInfo, setIsRefreshingInfo] = useState(false);
+  const [selectedSerial, setSelectedSerial] = useState("");
 
   const refreshDevices = async () => {
     setIsRefreshingDevices(true);
@@ -44,27 +48,39 @@ export function ViewDashboard({ activeView }: { activeView: string }) {
     setIsRefreshingInfo(false);
   };
 
+  const selectDevice = async (serial: string) => {
+    try {
+      await SetTargetDevice(serial);
+      setSelectedSerial(serial);
+      setDeviceInfo(null);
+      if (serial) {
+        toast.success(`Target device set to ${serial}`);
+      }
+    } catch (error) {
+      toast.error("Failed to select device", { description: errorMessage(error) });
+    }
+  };
+
   useEffect(() => {
     if (activeView === "dashboard") {
+      GetTargetDevice()
+        .then((serial) => setSelectedSerial(serial || ""))
+        .catch(() => setSelectedSerial(""));
       refreshDevices();
     }
   }, [activeView]);
 
   useEffect(() => {
     if (activeView === "dashboard") {
-      const interval = setInterval(() => {
-        if (!isRefreshingDevices) {
-          refreshDevices();
-        }
-      }, 3000);
+      const unsubscribers = ["device:attached", "device:detached", "device:state-changed"].map((eventName) => EventsOn(eventName, () => refreshDevices()));
 
-      return () => clearInterval(interval);
+      return () => unsubscribers.forEach((unsubscribe) => unsubscribe());
     }
-  }, [activeView, isRefreshingDevices]);
+  }, [activeView]);
 
   return (
     <div className="flex flex-col gap-6">
-      <DeviceListCard devices={devices} isRefreshing={isRefreshingDevices} onRefresh={refreshDevices} />
+      <DeviceListCard devices={devices} isRefreshing={isRefreshingDevices} onRefresh={refreshDevices} selectedSerial={selectedSerial} onSelect={selectDevice} />
 
       <WirelessAdbCard hasUsbDevice={devices.length > 0} defaultIp={deviceInfo?.IPAddress} onDevicesUpdated={refreshDevices} />
 
@@ -1,14 +1,34 @@
 import React, { useState, useEffect, useCallback, useRef } from "react";
-import { WipeData, FlashPartition, SelectImageFile, GetFastbootDevices, SelectZipFile, SideloadPackage } from "../../../wailsjs/go/backend/App";
+import { WipeData, BootImage, CancelJob, FlashPartition, InspectBootImage, InspectSparseImage, SelectImageFile, GetFastbootDevices, SelectZipFile, SideloadPackage } from "../../../wailsjs/go/backend/App";
 import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
 
 import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
 import { FastbootDevicesCard } from "@/components/flasher/FastbootDevicesCard";
+import { FastbootInfoCard } from "@/components/flasher/FastbootInfoCard";
+import { SlotsCard } from "@/components/flasher/SlotsCard";
+import { BootloaderLockCard } from "@/components/flasher/BootloaderLockCard";
+import { LogicalPartitionsCard } from "@/components/flasher/LogicalPartitionsCard";
+import { FactoryImageCard } from "@/components/flasher/FactoryImageCard";
+import { PayloadExtractCard } from "@/components/flasher/PayloadExtractCard";
+import { SparseImageCard } from "@/components/flasher/SparseImageCard";
+import { SuperImageCard } from "@/components/flasher/SuperImageCard";
+import { VbmetaCard } from "@/components/flasher/VbmetaCard";
 import { FlashPartitionCard } from "@/components/flasher/FlashPartitionCard";
+import { PartitionEraseCard } from "@/components/flasher/PartitionEraseCard";
 import { RecoveryActionsCard } from "@/components/flasher/RecoveryActionsCard";
+import { FlashTransfer } from "@/components/flasher/FlashProgressBar";
 
 type Device = backend.Device;
 
+// Mirrors backend.FlashProgress event payloads.
+type FlashProgress = {
+  JobID: string;
+  Percent: number;
+  Stage: string;
+};
+
 const sanitizeFastbootDevices = (devices: Device[] | null | undefined): Device[] => {
   if (!Array.isArray(devices)) {
     return [];
@@ -16,10 +36,12 @@ const sanitizeFastbootDevices = (devices: Device[] | null | undefined): Device[]
 
   return devices
     .filter((device): device is Device => !!device && typeof device.Serial === "string")
-    .map((device) => ({
-      Serial: device.Serial,
-      Status: device.Status ?? "fastboot",
-    }));
+    .map((device) =>
+      backend.Device.createFrom({
+        ...device,
+        Status: device.Status ?? "fastboot",
+      })
+    );
 };
 
 const areDeviceListsEqual = (a: Device[], b: Device[]): boolean => {
@@ -39,10 +61,20 @@ const areDeviceListsEqual = (a: Device[], b: Device[]): boolean => {
 export function ViewFlasher({ activeView }: { activeView: string }) {
   const [partition, setPartition] = useState("");
   const [filePath, setFilePath] = useState("");
+  const [slot, setSlot] = useState("");
+  const [selectedSerial, setSelectedSerial] = useState("");
+  const [bootImage, setBootImage] = useState<backend.BootImageInfo | null>(null);
+  const [sparseImage, setSparseImage] = useState<backend.SparseImageInfo | null>(null);
   const [sideloadFilePath, setSideloadFilePath] = useState("");
   const [isFlashing, setIsFlashing] = useState(false);
   const [isWiping, setIsWiping] = useState(false);
-  const [isSideloading, setIsSideloading] = useState(false);
+  const [flashTransfer, setFlashTransfer] = useState<FlashTransfer | null>(null);
+  const [sideloadTransfer, setSideloadTransfer] = useState<FlashTransfer | null>(null);
+  const flashJobRef = useRef<{ id: string; partition: string; slot: string } | null>(null);
+  const sideloadJobRef = useRef<{ id: string; fileName: string } | null>(null);
+
+  // Failures such as a missing device can finish a job before its id is known.
+  const finishedEarlyRef = useRef<Record<string, backend.Job>>({});
 
   const [fastbootDevices, setFastbootDevices] = useState<Device[]>([]);
   const [isRefreshingFastboot, setIsRefreshingFastboot] = useState(false);
@@ -139,6 +171,103 @@ export function ViewFlasher({ activeView }: { activeView: string }) {
     };
   }, [activeView, refreshFastbootDevices]);
 
+  useEffect(() => {
+    if (!filePath) {
+      setBootImage(null);
+      return;
+    }
+    // Debounced so typing a partition name does not reparse on every key.
+    let cancelled = false;
+    const timer = window.setTimeout(() => {
+      InspectBootImage(filePath, partition)
+        .then((info) => !cancelled && setBootImage(info))
+        .catch(() => !cancelled && setBootImage(null));
+    }, 300);
+    return () => {
+      cancelled = true;
+      window.clearTimeout(timer);
+    };
+  }, [filePath, partition]);
+
+  useEffect(() => {
+    if (!filePath) {
+      setSparseImage(null);
+      return;
+    }
+    let cancelled = false;
+    InspectSparseImage(filePath)
+      .then((info) => !cancelled && setSparseImage(info))
+      .catch(() => !cancelled && setSparseImage(null));
+    return () => {
+      cancelled = true;
+    };
+  }, [filePath]);
+
+  const handleFlashFinished = (job: backend.Job, flashedPartition: string, flashedSlot: string) => {
+    flashJobRef.current = null;
+    setFlashTransfer(null);
+
+    if (job.Status === "succeeded") {
+      const target = flashedSlot === "all" ? " to both slots" : flashedSlot ? ` to slot ${flashedSlot}` : "";
+      toast.success("Flash Complete", { description: `${flashedPartition} flashed successfully${target}.` });
+    } else if (job.Status === "failed") {
+      toast.error("Flash Failed", { description: job.Error });
+    } else if (job.Status === "cancelled") {
+      toast.info("Flash cancelled", { description: `${flashedPartition} may be left partially written.` });
+    }
+  };
+
+  const handleSideloadFinished = (job: backend.Job, fileName: string) => {
+    sideloadJobRef.current = null;
+    setSideloadTransfer(null);
+
+    if (job.Status === "succeeded") {
+      toast.success("Sideload Complete", { description: `${fileName} sideloaded successfully.` });
+    } else if (job.Status === "failed") {
+      toast.error("Sideload Failed", { description: job.Error });
+    } else if (job.Status === "cancelled") {
+      toast.info("Sideload cancelled");
+    }
+  };
+
+  useEffect(() => {
+    const offProgress = EventsOn("flash:progress", (progress: FlashProgress) => {
+      const next = { jobId: progress.JobID, percent: progress.Percent, stage: progress.Stage };
+      if (progress.JobID === flashJobRef.current?.id) setFlashTransfer(next);
+      if (progress.JobID === sideloadJobRef.current?.id) setSideloadTransfer(next);
+    });
+    const offFinished = EventsOn("job:finished", (job: backend.Job) => {
+      const flashJob = flashJobRef.current;
+      const sideloadJob = sideloadJobRef.current;
+      if (flashJob && job.ID === flashJob.id) {
+        handleFlashFinished(job, flashJob.partition, flashJob.slot);
+      } else if (sideloadJob && job.ID === sideloadJob.id) {
+        handleSideloadFinished(job, sideloadJob.fileName);
+      } else if (job.Kind === "fastboot-flash" || job.Kind === "sideload") {
+        finishedEarlyRef.current[job.ID] = job;
+      }
+    });
+    return () => {
+      offProgress();
+      offFinished();
+    };
+  }, []);
+
+  const takeFinishedEarly = (id: string) => {
+    const finished = finishedEarlyRef.current[id];
+    delete finishedEarlyRef.current[id];
+    return finished;
+  };
+
+  const handleCancelJob = async (transfer: FlashTransfer | null) => {
+    if (!transfer) return;
+    try {
+      await CancelJob(transfer.jobId);
+    } catch (error) {
+      toast.error("Failed to cancel", { description: errorMessage(error) });
+    }
+  };
+
   const handleSelectFile = async () => {
     try {
       const selectedPath = await SelectImageFile();
@@ -149,7 +278,7 @@ export function ViewFlasher({ activeView }: { activeView: string }) {
       }
     } catch (error) {
       console.error("File selection error:", error);
-      toast.error("Failed to open file dialog", { description: String(error) });
+      toast.error("Failed to open file dialog", { description: errorMessage(error) });
     }
   };
 
@@ -163,7 +292,7 @@ export function ViewFlasher({ activeView }: { activeView: string }) {
       }
     } catch (error) {
       console.error("ZIP selection error:", error);
-      toast.error("Failed to open file dialog", { description: String(error) });
+      toast.error("Failed to open file dialog", { description: errorMessage(error) });
     }
   };
 
@@ -177,15 +306,33 @@ export function ViewFlasher({ activeView }: { activeView: string }) {
       return;
     }
 
+    try {
+      const id = await FlashPartition(partition, filePath, slot);
+      const finished = takeFinishedEarly(id);
+      if (finished) {
+        handleFlashFinished(finished, partition, slot);
+        return;
+      }
+      flashJobRef.current = { id, partition, slot };
+      setFlashTransfer({ jobId: id, percent: 0, stage: "" });
+    } catch (error) {
+      console.error("Flash error:", error);
+      toast.error("Flash Failed", { description: errorMessage(error) });
+    }
+  };
+
+  const handleBootOnce = async () => {
+    if (!filePath) return;
+
     setIsFlashing(true);
-    const toastId = toast.loading(`Flashing ${partition} partition...`);
+    const toastId = toast.loading("Booting image...");
 
     try {
-      await FlashPartition(partition, filePath);
-      toast.success("Flash Complete", { description: `${partition} flashed successfully.`, id: toastId });
+      await BootImage(filePath);
+      toast.success("Image booted", { description: "Nothing was flashed; a normal reboot returns to the installed boot image.", id: toastId });
     } catch (error) {
-      console.error("Flash error:", error);
-      toast.error("Flash Failed", { description: String(error), id: toastId });
+      console.error("Boot error:", error);
+      toast.error("Boot Failed", { description: errorMessage(error), id: toastId });
     } finally {
       setIsFlashing(false);
     }
@@ -198,18 +345,18 @@ export function ViewFlasher({ activeView }: { activeView: string }) {
     }
 
     const fileName = sideloadFilePath.split(/[/\\]/).pop() ?? "update.zip";
-    setIsSideloading(true);
-    const toastId = toast.loading(`Sideloading ${fileName}...`);
-
     try {
-      const output = await SideloadPackage(sideloadFilePath);
-      const description = output ? output : `${fileName} sideloaded successfully.`;
-      toast.success("Sideload Complete", { description, id: toastId });
+      const id = await SideloadPackage(sideloadFilePath);
+      const finished = takeFinishedEarly(id);
+      if (finished) {
+        handleSideloadFinished(finished, fileName);
+        return;
+      }
+      sideloadJobRef.current = { id, fileName };
+      setSideloadTransfer({ jobId: id, percent: 0, stage: "" });
     } catch (error) {
       console.error("Sideload error:", error);
-      toast.error("Sideload Failed", { description: String(error), id: toastId });
-    } finally {
-      setIsSideloading(false);
+      toast.error("Sideload Failed", { description: errorMessage(error) });
     }
   };
 
@@ -222,23 +369,60 @@ export function ViewFlasher({ activeView }: { activeView: string }) {
       toast.success("Wipe Complete", { description: "Device data has been erased.", id: toastId });
     } catch (error) {
       console.error("Wipe error:", error);
-      toast.error("Wipe Failed", { description: String(error), id: toastId });
+      toast.error("Wipe Failed", { description: errorMessage(error), id: toastId });
     } finally {
       setIsWiping(false);
     }
   };
 
+  const handleUseImage = (name: string, path: string) => {
+    setPartition(name);
+    setFilePath(path);
+    toast.info(`${name}.img selected for flashing`);
+  };
+
+  const activeSerial = fastbootDevices.some((device) => device.Serial === selectedSerial) ? selectedSerial : (fastbootDevices[0]?.Serial ?? "");
+
   return (
     <div className="flex flex-col gap-6">
-      <FastbootDevicesCard devices={fastbootDevices} isRefreshing={isRefreshingFastboot} error={fastbootError} onRefresh={() => refreshFastbootDevices()} />
+      <FastbootDevicesCard
+        devices={fastbootDevices}
+        isRefreshing={isRefreshingFastboot}
+        error={fastbootError}
+        onRefresh={() => refreshFastbootDevices()}
+        selectedSerial={activeSerial}
+        onSelect={setSelectedSerial}
+      />
+
+      {activeSerial && (
+        <>
+          <FastbootInfoCard serial={activeSerial} />
+          <BootloaderLockCard serial={activeSerial} />
+          <SlotsCard serial={activeSerial} />
+          <LogicalPartitionsCard serial={activeSerial} />
+          <FactoryImageCard serial={activeSerial} />
+        </>
+      )}
+
+      <FlashPartitionCard partition={partition} onPartitionChange={setPartition} filePath={filePath} onSelectFile={handleSelectFile} onFlash={handleFlash} onBootOnce={handleBootOnce} isFlashing={isFlashing || flashTransfer !== null} canFlash={fastbootDevices.length > 0} slot={slot} onSlotChange={setSlot} bootImage={bootImage} sparseImage={sparseImage} transfer={flashTransfer} onCancel={() => handleCancelJob(flashTransfer)} />
+
+      <VbmetaCard canFlash={fastbootDevices.length > 0} />
+
+      <PayloadExtractCard onUseImage={handleUseImage} />
+
+      <SuperImageCard onUseImage={handleUseImage} />
+
+      <SparseImageCard />
 
-      <FlashPartitionCard partition={partition} onPartitionChange={setPartition} filePath={filePath} onSelectFile={handleSelectFile} onFlash={handleFlash} isFlashing={isFlashing} canFlash={fastbootDevices.length > 0} />
+      <PartitionEraseCard canRun={fastbootDevices.length > 0} />
 
       <RecoveryActionsCard
         sideloadFilePath={sideloadFilePath}
         onSelectSideloadFile={handleSelectSideloadFile}
-        isSideloading={isSideloading}
+        isSideloading={sideloadTransfer !== null}
         onSideload={handleSideload}
+        sideloadTransfer={sideloadTransfer}
+        onCancelSideload={() => handleCancelJob(sideloadTransfer)}
         isWiping={isWiping}
         onWipe={handleWipe}
         canWipe={fastbootDevices.length > 0}
@@ -0,0 +1,221 @@
+import React, { useEffect, useRef, useState } from "react";
+import { toast } from "sonner";
+import { StartLogcat, StopLogcat, DiscardLogcat, SaveLogcat, ClearLogcat, SetLogcatBufferSize, SelectSaveFile } from "../../../wailsjs/go/backend/App";
+import { backend } from "../../../wailsjs/go/models";
+import { EventsOn } from "../../../wailsjs/runtime/runtime";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Label } from "@/components/ui/label";
+import { Eraser, Filter, Play, Save, Square, Trash2 } from "lucide-react";
+import { errorMessage } from "@/lib/errors";
+import { cn } from "@/lib/utils";
+import { LogcatEntriesCard, type LogEntry } from "@/components/logcat/LogcatEntriesCard";
+
+// Mirrors backend.LogcatBatch / backend.LogcatStopped event payloads.
+type LogcatBatch = {
+  SessionID: string;
+  Entries: LogEntry[];
+};
+
+type LogcatStopped = {
+  SessionID: string;
+  Error: string;
+};
+
+const MAX_VISIBLE_ENTRIES = 20000;
+const BUFFERS = ["main", "system", "crash", "events"];
+const LEVELS = ["V", "D", "I", "W", "E", "F"];
+
+export function ViewLogcat({ activeView }: { activeView: string }) {
+  const [entries, setEntries] = useState<LogEntry[]>([]);
+  const [sessionId, setSessionId] = useState<string | null>(null);
+  const [isRunning, setIsRunning] = useState(false);
+  const [followTail, setFollowTail] = useState(true);
+
+  const [buffers, setBuffers] = useState<string[]>(["main", "system", "crash"]);
+  const [packageName, setPackageName] = useState("");
+  const [tag, setTag] = useState("");
+  const [minLevel, setMinLevel] = useState("V");
+  const [regex, setRegex] = useState("");
+  const [bufferSize, setBufferSize] = useState("");
+
+  const sessionRef = useRef<string | null>(null);
+
+  useEffect(() => {
+    const offEntries = EventsOn("logcat:entries", (batch: LogcatBatch) => {
+      if (batch.SessionID !== sessionRef.current) return;
+      setEntries((prev) => {
+        const next = prev.concat(batch.Entries);
+        return next.length > MAX_VISIBLE_ENTRIES ? next.slice(next.length - MAX_VISIBLE_ENTRIES) : next;
+      });
+    });
+
+    const offStopped = EventsOn("logcat:stopped", (stopped: LogcatStopped) => {
+      if (stopped.SessionID !== sessionRef.current) return;
+      setIsRunning(false);
+      if (stopped.Error) {
+        toast.error("Logcat stopped", { description: stopped.Error });
+      }
+    });
+
+    return () => {
+      offEntries();
+      offStopped();
+      if (sessionRef.current) {
+        DiscardLogcat(sessionRef.current).catch(() => {});
+        sessionRef.current = null;
+      }
+    };
+  }, []);
+
+  const handleStart = async () => {
+    if (sessionRef.current) {
+      await DiscardLogcat(sessionRef.current).catch(() => {});
+    }
+
+    setEntries([]);
+    try {
+      const id = await StartLogcat(
+        backend.LogcatFilter.createFrom({
+          Buffers: buffers,
+          Package: packageName.trim(),
+          Pid: 0,
+          Tag: tag.trim(),
+          MinLevel: minLevel,
+          Regex: regex,
+        })
+      );
+      sessionRef.current = id;
+      setSessionId(id);
+      setIsRunning(true);
+    } catch (error) {
+      toast.error("Failed to start logcat", { description: errorMessage(error) });
+    }
+  };
+
+  const handleStop = async () => {
+    if (!sessionRef.current) return;
+    try {
+      await StopLogcat(sessionRef.current);
+    } catch (error) {
+      toast.error("Failed to stop logcat", { description: errorMessage(error) });
+    }
+  };
+
+  const handleSave = async () => {
+    if (!sessionId) return;
+    try {
+      const timestamp = new Date().toISOString().replace(/[:.]/g, "-");
+      const filePath = await SelectSaveFile(`logcat-${timestamp}.txt`);
+      if (!filePath) return;
+      const result = await SaveLogcat(sessionId, filePath);
+      toast.success("Log saved", { description: result });
+    } catch (error) {
+      toast.error("Failed to save log", { description: errorMessage(error) });
+    }
+  };
+
+  const handleClearBuffers = async () => {
+    try {
+      await ClearLogcat(buffers);
+      setEntries([]);
+      toast.success("Device log buffers cleared");
+    } catch (error) {
+      toast.error("Failed to clear log buffers", { description: errorMessage(error) });
+    }
+  };
+
+  const handleSetBufferSize = async () => {
+    if (!bufferSize.trim()) return;
+    try {
+      const result = await SetLogcatBufferSize(bufferSize, buffers);
+      toast.success(result);
+    } catch (error) {
+      toast.error("Failed to set buffer size", { description: errorMessage(error) });
+    }
+  };
+
+  const toggleBuffer = (buffer: string, checked: boolean) => {
+    setBuffers((prev) => (checked ? [...prev, buffer] : prev.filter((b) => b !== buffer)));
+  };
+
+  return (
+    <div className="flex h-[calc(100vh-4.5rem)] flex-col gap-4">
+      <Card>
+        <CardHeader className="flex flex-row items-start justify-between">
+          <div className="flex-1">
+            <CardTitle className="flex items-center gap-2">
+              <Filter />
+              Logcat
+            </CardTitle>
+            <CardDescription>Filters apply when the stream starts. Restart to change them.</CardDescription>
+          </div>
+          <div className="flex gap-2">
+            {isRunning ? (
+              <Button variant="destructive" size="sm" onClick={handleStop}>
+                <Square className="mr-2 h-4 w-4" />
+                Stop
+              </Button>
+            ) : (
+              <Button size="sm" onClick={handleStart} disabled={buffers.length === 0}>
+                <Play className="mr-2 h-4 w-4" />
+                Start
+              </Button>
+            )}
+            <Button variant="outline" size="sm" onClick={handleSave} disabled={!sessionId}>
+              <Save className="mr-2 h-4 w-4" />
+              Save
+            </Button>
+            <Button variant="outline" size="sm" onClick={() => setEntries([])}>
+              <Eraser className="mr-2 h-4 w-4" />
+              Clear View
+            </Button>
+            <Button variant="outline" size="sm" onClick={handleClearBuffers}>
+              <Trash2 className="mr-2 h-4 w-4" />
+              Clear Buffers
+            </Button>
+          </div>
+        </CardHeader>
+        <CardContent className="grid gap-4 md:grid-cols-2">
+          <div className="flex flex-col gap-3">
+            <div className="flex gap-2">
+              <Input placeholder="Package (e.g. com.android.chrome)" value={packageName} onChange={(e) => setPackageName(e.target.value)} className="font-mono" />
+              <Input placeholder="Tag contains..." value={tag} onChange={(e) => setTag(e.target.value)} className="font-mono" />
+            </div>
+            <Input placeholder="Regex on tag or message" value={regex} onChange={(e) => setRegex(e.target.value)} className="font-mono" />
+          </div>
+
+          <div className="flex flex-col gap-3">
+            <div className="flex flex-wrap items-center gap-4">
+              {BUFFERS.map((buffer) => (
+                <div key={buffer} className="flex items-center gap-2">
+                  <Checkbox id={`buffer-${buffer}`} checked={buffers.includes(buffer)} onCheckedChange={(checked) => toggleBuffer(buffer, Boolean(checked))} />
+                  <Label htmlFor={`buffer-${buffer}`}>{buffer}</Label>
+                </div>
+              ))}
+              <div className="flex items-center gap-2">
+                <Checkbox id="follow-tail" checked={followTail} onCheckedChange={(checked) => setFollowTail(Boolean(checked))} />
+                <Label htmlFor="follow-tail">Follow</Label>
+              </div>
+            </div>
+            <div className="flex flex-wrap items-center gap-2">
+              {LEVELS.map((level) => (
+                <Button key={level} variant="outline" size="sm" className={cn("w-9 font-mono", minLevel === level && "border-primary bg-primary/10")} onClick={() => setMinLevel(level)}>
+                  {level}
+                </Button>
+              ))}
+              <Input placeholder="Buffer size (e.g. 16M)" value={bufferSize} onChange={(e) => setBufferSize(e.target.value)} className="ml-auto w-40 font-mono" />
+              <Button variant="outline" size="sm" onClick={handleSetBufferSize} disabled={!bufferSize.trim()}>
+                Apply
+              </Button>
+            </div>
+          </div>
+        </CardContent>
+      </Card>
+
+      <LogcatEntriesCard entries={entries} isRunning={isRunning} followTail={followTail} />
+    </div>
+  );
+}
@@ -1,8 +1,14 @@
 import React, { useState, useRef, useEffect } from "react";
-import { RunShellCommand, RunAdbHostCommand, RunFastbootHostCommand } from "../../../wailsjs/go/backend/App";
+import { toast } from "sonner";
+import { RunShellCommand, RunAdbHostCommand, RunFastbootHostCommand, OpenShell, CloseShell, ListShells } from "../../../wailsjs/go/backend/App";
+import { Button } from "@/components/ui/button";
+import { Plus, SquareTerminal, Terminal, X } from "lucide-react";
+import { cn } from "@/lib/utils";
 
 import type { HistoryEntry } from "../MainLayout";
+import { errorMessage } from "@/lib/errors";
 import { ShellTerminalCard } from "@/components/shell/ShellTerminalCard";
+import { ShellSessionTerminal } from "@/components/shell/ShellSessionTerminal";
 
 interface ViewShellProps {
   activeView: string;
@@ -15,6 +21,14 @@ interface ViewShellProps {
 const MAX_OUTPUT_HISTORY = 500;
 const MAX_COMMAND_HISTORY = 100;
 
+const COMMANDS_TAB = "commands";
+
+type SessionTab = {
+  id: string;
+  serial: string;
+  exited: boolean;
+};
+
 function limitHistory<T>(arr: T[], max: number): T[] {
   if (arr.length <= max) return arr;
   return arr.slice(arr.length - max);
@@ -25,6 +39,40 @@ export function ViewShell({ activeView, history, setHistory, commandHistory, set
   const [isLoading, setIsLoading] = useState(false);
   const [historyIndex, setHistoryIndex] = useState(commandHistory.length);
   const scrollAreaRef = useRef<HTMLDivElement>(null);
+  const [sessions, setSessions] = useState<SessionTab[]>([]);
+  const [activeTab, setActiveTab] = useState(COMMANDS_TAB);
+  const [isOpening, setIsOpening] = useState(false);
+
+  useEffect(() => {
+    ListShells()
+      .then((open) => setSessions((open || []).map((s) => ({ id: s.ID, serial: s.Serial, exited: false }))))
+      .catch(() => {});
+  }, []);
+
+  const handleOpenSession = async () => {
+    setIsOpening(true);
+    try {
+      const id = await OpenShell("");
+      const open = await ListShells();
+      const serial = open.find((s) => s.ID === id)?.Serial || "device";
+      setSessions((prev) => [...prev, { id, serial, exited: false }]);
+      setActiveTab(id);
+    } catch (err) {
+      toast.error("Failed to open shell", { description: errorMessage(err) });
+    } finally {
+      setIsOpening(false);
+    }
+  };
+
+  const handleCloseSession = (id: string) => {
+    CloseShell(id).catch(() => {});
+    setSessions((prev) => prev.filter((s) => s.id !== id));
+    if (activeTab === id) setActiveTab(COMMANDS_TAB);
+  };
+
+  const handleSessionExit = (id: string) => {
+    setSessions((prev) => prev.map((s) => (s.id === id ? { ...s, exited: true } : s)));
+  };
 
   const handleKeyDown = async (e: React.KeyboardEvent<HTMLInputElement>) => {
     if (e.key === "ArrowUp") {
@@ -91,8 +139,7 @@ export function ViewShell({ activeView, history, setHistory, commandHistory, set
       const resultEntry: HistoryEntry = { type: "result", text: result.trim() || "(No output)" };
       setHistory((prev) => limitHistory([...prev, resultEntry], MAX_OUTPUT_HISTORY));
     } catch (err) {
-      const error = err as Error;
-      const errorEntry: HistoryEntry = { type: "error", text: error.message };
+      const errorEntry: HistoryEntry = { type: "error", text: errorMessage(err) };
       setHistory((prev) => limitHistory([...prev, errorEntry], MAX_OUTPUT_HISTORY));
     } finally {
       setIsLoading(false);
@@ -129,7 +176,33 @@ export function ViewShell({ activeView, history, setHistory, commandHistory, set
 
   return (
     <div className="flex h-[calc(100vh-4.5rem)] flex-col gap-4">
-      <ShellTerminalCard command={command} onCommandChange={handleCommandChange} onKeyDown={handleKeyDown} isLoading={isLoading} history={history} onClearLog={handleClearLog} scrollAreaRef={scrollAreaRef} />
+      <div className="flex items-center gap-2 overflow-x-auto">
+        <Button variant={activeTab === COMMANDS_TAB ? "default" : "outline"} size="sm" onClick={() => setActiveTab(COMMANDS_TAB)}>
+          <Terminal className="mr-2 h-4 w-4" />
+          Commands
+        </Button>
+        {sessions.map((session) => (
+          <div key={session.id} className={cn("flex items-center rounded-md border", activeTab === session.id ? "border-primary bg-primary/10" : "border-border")}>
+            <button className={cn("flex items-center gap-2 px-3 py-1.5 font-mono text-sm", session.exited && "text-muted-foreground line-through")} onClick={() => setActiveTab(session.id)}>
+              <SquareTerminal className="h-4 w-4" />
+              {session.serial}
+            </button>
+            <button className="px-2 py-1.5 text-muted-foreground hover:text-destructive" onClick={() => handleCloseSession(session.id)} title="Close session">
+              <X className="h-3.5 w-3.5" />
+            </button>
+          </div>
+        ))}
+        <Button variant="ghost" size="sm" onClick={handleOpenSession} disabled={isOpening}>
+          <Plus className="mr-2 h-4 w-4" />
+          {isOpening ? "Opening..." : "New Shell"}
+        </Button>
+      </div>
+
+      {activeTab === COMMANDS_TAB ? (
+        <ShellTerminalCard command={command} onCommandChange={handleCommandChange} onKeyDown={handleKeyDown} isLoading={isLoading} history={history} onClearLog={handleClearLog} scrollAreaRef={scrollAreaRef} />
+      ) : (
+        <ShellSessionTerminal key={activeTab} sessionId={activeTab} exited={sessions.find((s) => s.id === activeTab)?.exited ?? false} onExit={handleSessionExit} />
+      )}
     </div>
   );
 }
@@ -3,8 +3,11 @@ import React, { useState, useCallback, useEffect, useRef } from "react";
 import { GetDeviceMode, Reboot } from "../../../wailsjs/go/backend/App";
 
 import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
 import { RebootOptionsCard } from "@/components/utilities/RebootOptionsCard";
 import type { RebootMode } from "@/components/utilities/RebootOptionsCard";
+import { ScreenshotCard } from "@/components/utilities/ScreenshotCard";
+import { ScreenRecordCard } from "@/components/utilities/ScreenRecordCard";
 
 type DeviceConnectionMode = "adb" | "fastboot" | "unknown";
 
@@ -58,7 +61,7 @@ export function ViewUtilities({ activeView }: { activeView: string }) {
     } catch (error) {
       console.error(`Error rebooting to ${modeId}:`, error);
       toast.error("Failed to send reboot command", {
-        description: String(error),
+        description: errorMessage(error),
       });
     }
 
@@ -115,6 +118,8 @@ export function ViewUtilities({ activeView }: { activeView: string }) {
           { label: "Reboot to Fastbootd", mode: "fastboot", modeId: "fastboot" },
         ]}
       />
+      <ScreenshotCard canCapture={deviceMode === "adb"} />
+      <ScreenRecordCard canRecord={deviceMode === "adb"} />
     </div>
   );
 }
@@ -1,5 +1,6 @@
 import { useState, useEffect, useCallback, useMemo } from "react";
 import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
 import { SelectApkFile } from "../../wailsjs/go/backend/App";
 import type { QuickControlSelect } from "@/components/appManager/AppManagerQuickControlsCard";
 import { usePackageList } from "./usePackageList";
@@ -72,7 +73,7 @@ export function useAppManager({ activeView }: UseAppManagerOptions) {
     } catch (error) {
       console.error("File selection error:", error);
       toast.error("Failed to open file dialog", {
-        description: String(error),
+        description: errorMessage(error),
       });
     }
   }, []);
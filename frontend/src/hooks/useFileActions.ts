@@ -1,7 +1,20 @@
 import { useState, useCallback } from "react";
 import path from "path-browserify";
 import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
+import { formatBytes } from "@/lib/utils";
 import { PushFile, CreateFolder, RenameFile, DeleteMultipleFiles, PullMultipleFiles, SelectFilesToPush, SelectFoldersToPush } from "../../wailsjs/go/backend/App";
+import { EventsOn } from "../../wailsjs/runtime/runtime";
+
+type TransferProgress = {
+  CurrentFile: string;
+  BytesDone: number;
+  BytesTotal: number;
+  FilesDone: number;
+  FilesTotal: number;
+  BytesPerSecond: number;
+  ETASeconds: number;
+};
 
 type BatchFailure = {
   name: string;
@@ -18,6 +31,16 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
 
   const getBasename = (fullPath: string) => fullPath.replace(/\\/g, "/").split("/").pop() || fullPath;
 
+  const trackTransferProgress = (toastId: string | number, title: string) =>
+    EventsOn("transfer:progress", (progress: TransferProgress) => {
+      const percent = progress.BytesTotal > 0 ? Math.floor((progress.BytesDone / progress.BytesTotal) * 100) : 100;
+      const eta = progress.ETASeconds > 0 ? `, ${Math.ceil(progress.ETASeconds)}s left` : "";
+      toast.loading(title, {
+        id: toastId,
+        description: `${getBasename(progress.CurrentFile)} (${progress.FilesDone}/${progress.FilesTotal}) - ${percent}% of ${formatBytes(progress.BytesTotal)} at ${formatBytes(progress.BytesPerSecond)}/s${eta}`,
+      });
+    });
+
   const showBatchToast = (toastId: string | number, entityLabel: string, total: number, failures: BatchFailure[]) => {
     if (total === 0) return;
 
@@ -49,7 +72,9 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
       if (!localPaths || localPaths.length === 0) return;
 
       const description = localPaths.length === 1 ? `To: ${path.posix.join(currentPath, getBasename(localPaths[0]))}` : undefined;
-      toastId = toast.loading(localPaths.length === 1 ? `Pushing ${getBasename(localPaths[0])}...` : `Pushing ${localPaths.length} files...`, { description });
+      const title = localPaths.length === 1 ? `Pushing ${getBasename(localPaths[0])}...` : `Pushing ${localPaths.length} files...`;
+      toastId = toast.loading(title, { description });
+      const stopTracking = trackTransferProgress(toastId, title);
 
       const failures: BatchFailure[] = [];
       for (const localPath of localPaths) {
@@ -59,18 +84,19 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
           await PushFile(localPath, remotePath);
         } catch (error) {
           console.error("Import file error:", error);
-          failures.push({ name: fileName, error: String(error) });
+          failures.push({ name: fileName, error: errorMessage(error) });
         }
       }
 
+      stopTracking();
       showBatchToast(toastId, "file", localPaths.length, failures);
       refreshFiles(currentPath);
     } catch (error) {
       console.error("Import file error:", error);
       if (toastId) {
-        toast.error("File Import Failed", { description: String(error), id: toastId });
+        toast.error("File Import Failed", { description: errorMessage(error), id: toastId });
       } else {
-        toast.error("File Import Failed", { description: String(error) });
+        toast.error("File Import Failed", { description: errorMessage(error) });
       }
     } finally {
       setIsPushingFile(false);
@@ -84,9 +110,11 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
       const localFolders = await SelectFoldersToPush();
       if (!localFolders || localFolders.length === 0) return;
 
-      toastId = toast.loading(localFolders.length === 1 ? `Pushing folder ${getBasename(localFolders[0])}...` : `Pushing ${localFolders.length} folders...`, {
+      const title = localFolders.length === 1 ? `Pushing folder ${getBasename(localFolders[0])}...` : `Pushing ${localFolders.length} folders...`;
+      toastId = toast.loading(title, {
         description: localFolders.length === 1 ? `To: ${currentPath}` : undefined,
       });
+      const stopTracking = trackTransferProgress(toastId, title);
 
       const failures: BatchFailure[] = [];
       for (const localFolderPath of localFolders) {
@@ -95,18 +123,19 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
           await PushFile(localFolderPath, currentPath);
         } catch (error) {
           console.error("Import folder error:", error);
-          failures.push({ name: folderName, error: String(error) });
+          failures.push({ name: folderName, error: errorMessage(error) });
         }
       }
 
+      stopTracking();
       showBatchToast(toastId, "folder", localFolders.length, failures);
       refreshFiles(currentPath);
     } catch (error) {
       console.error("Import folder error:", error);
       if (toastId) {
-        toast.error("Folder Import Failed", { description: String(error), id: toastId });
+        toast.error("Folder Import Failed", { description: errorMessage(error), id: toastId });
       } else {
-        toast.error("Folder Import Failed", { description: String(error) });
+        toast.error("Folder Import Failed", { description: errorMessage(error) });
       }
     } finally {
       setIsPushingFolder(false);
@@ -122,10 +151,13 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
 
       setIsPulling(true);
       const remotePaths = selectedFileNames.map((name) => path.posix.join(currentPath, name));
-      const toastId = toast.loading(`Exporting ${selectedFileNames.length} items...`);
+      const title = `Exporting ${selectedFileNames.length} items...`;
+      const toastId = toast.loading(title);
+      const stopTracking = trackTransferProgress(toastId, title);
 
       try {
         const output = await PullMultipleFiles(remotePaths);
+        stopTracking();
 
         if (output.includes("cancelled by user")) {
           toast.info("Export Cancelled", { id: toastId });
@@ -139,8 +171,9 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
         onSuccess();
       } catch (error) {
         console.error("Batch export error:", error);
+        stopTracking();
         toast.error("Batch Export Failed", {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -173,7 +206,7 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
       } catch (error) {
         console.error("Batch delete error:", error);
         toast.error("Batch Delete Failed", {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -206,7 +239,7 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
       } catch (error) {
         console.error("Rename error:", error);
         toast.error("Rename Failed", {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -236,7 +269,7 @@ export function useFileActions(currentPath: string, refreshFiles: (path: string)
       } catch (error) {
         console.error("Create folder error:", error);
         toast.error("Create Folder Failed", {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -1,5 +1,6 @@
 import { useState, useCallback, useMemo } from "react";
 import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
 import { ListFiles } from "../../wailsjs/go/backend/App";
 import { backend } from "../../wailsjs/go/models";
 
@@ -35,7 +36,7 @@ export function useFileList() {
       setCurrentPath(targetPath);
     } catch (error) {
       console.error("Failed to list files:", error);
-      toast.error("Failed to list files", { description: String(error) });
+      toast.error("Failed to list files", { description: errorMessage(error) });
       // Keep current path if failed? Or update to target?
       // Current implementation kept old path if error, but updating state with error might be better?
       // Matches original behavior:
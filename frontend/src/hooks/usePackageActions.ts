@@ -1,5 +1,6 @@
 import { useState, useCallback } from "react";
 import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
 import { InstallPackage, ClearData, DisablePackage, EnablePackage, PullApk, UninstallMultiplePackages, DisableMultiplePackages, EnableMultiplePackages } from "../../wailsjs/go/backend/App";
 import { backend } from "../../wailsjs/go/models";
 
@@ -41,7 +42,7 @@ export function usePackageActions(refreshList: (filter: any) => void, currentFil
       } catch (error) {
         console.error("Install error:", error);
         toast.error("Install Failed", {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -69,7 +70,7 @@ export function usePackageActions(refreshList: (filter: any) => void, currentFil
     } catch (error) {
       console.error("Clear data error:", error);
       toast.error("Clear Data Failed", {
-        description: String(error),
+        description: errorMessage(error),
         id: toastId,
       });
     } finally {
@@ -102,7 +103,7 @@ export function usePackageActions(refreshList: (filter: any) => void, currentFil
       } catch (error) {
         console.error(`${action} error:`, error);
         toast.error(`Failed to ${action.toLowerCase()} package`, {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -135,7 +136,7 @@ export function usePackageActions(refreshList: (filter: any) => void, currentFil
     } catch (error) {
       console.error("Pull APK error:", error);
       toast.error("Failed to pull APK", {
-        description: String(error),
+        description: errorMessage(error),
         id: toastId,
       });
     } finally {
@@ -165,7 +166,7 @@ export function usePackageActions(refreshList: (filter: any) => void, currentFil
       } catch (error) {
         console.error("Batch uninstall error:", error);
         toast.error("Batch Uninstall Failed", {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -191,7 +192,7 @@ export function usePackageActions(refreshList: (filter: any) => void, currentFil
         onSuccess();
       } catch (error) {
         toast.error("Batch Disable Failed", {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -217,7 +218,7 @@ export function usePackageActions(refreshList: (filter: any) => void, currentFil
         onSuccess();
       } catch (error) {
         toast.error("Batch Enable Failed", {
-          description: String(error),
+          description: errorMessage(error),
           id: toastId,
         });
       } finally {
@@ -1,5 +1,6 @@
 import { useState, useMemo, useCallback } from "react";
 import { toast } from "sonner";
+import { errorMessage } from "@/lib/errors";
 import { ListPackages } from "../../wailsjs/go/backend/App";
 import { backend } from "../../wailsjs/go/models";
 
@@ -23,7 +24,7 @@ export function usePackageList() {
     } catch (error) {
       console.error("Failed to list packages:", error);
       toast.error("Failed to load package list", {
-        description: String(error),
+        description: errorMessage(error),
       });
       setPackageList([]);
     } finally {
@@ -0,0 +1,26 @@
+// Mirrors backend.CommandError, which the Go side sends for adb/fastboot failures.
+export type BackendError = {
+  Code: string;
+  Message: string;
+  Command: string;
+  ExitCode: number;
+  Stderr: string;
+};
+
+export function isBackendError(error: unknown): error is BackendError {
+  return typeof error === "object" && error !== null && "Code" in error && "Message" in error;
+}
+
+export function errorMessage(error: unknown): string {
+  if (isBackendError(error)) {
+    return error.Message;
+  }
+  if (error instanceof Error) {
+    return error.message;
+  }
+  return String(error);
+}
+
+export function errorCode(error: unknown): string | null {
+  return isBackendError(error) ? error.Code : null;
+}
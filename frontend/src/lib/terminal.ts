@@ -0,0 +1,134 @@
+// Minimal terminal text model for PTY output: escape sequences are dropped,
+// carriage return rewrites the current line and backspace erases a character.
+// It is enough for shells, top-style redraws and progress bars, not full-screen apps.
+
+const MAX_TERMINAL_CHARS = 200_000;
+
+export class TerminalBuffer {
+  private lines: string[] = [""];
+  private pendingEscape = "";
+  private carriageReturn = false;
+
+  write(chunk: string) {
+    const text = this.pendingEscape + chunk;
+    this.pendingEscape = "";
+
+    let i = 0;
+    while (i < text.length) {
+      const ch = text[i];
+
+      if (ch === "\x1b") {
+        const end = escapeEnd(text, i);
+        if (end === -1) {
+          this.pendingEscape = text.slice(i);
+          break;
+        }
+        i = end;
+        continue;
+      }
+
+      const last = this.lines.length - 1;
+      if (this.carriageReturn && ch !== "\n" && ch !== "\r") {
+        this.lines[last] = "";
+      }
+      this.carriageReturn = ch === "\r";
+
+      if (ch === "\n") {
+        this.lines.push("");
+      } else if (ch === "\r") {
+        // Resolved by the next character: "\r\n" is a newline, anything else redraws the line.
+      } else if (ch === "\b") {
+        this.lines[last] = this.lines[last].slice(0, -1);
+      } else if (ch >= " " || ch === "\t") {
+        this.lines[last] += ch;
+      }
+      i++;
+    }
+
+    this.trim();
+  }
+
+  clear() {
+    this.lines = [""];
+    this.pendingEscape = "";
+    this.carriageReturn = false;
+  }
+
+  toString() {
+    return this.lines.join("\n");
+  }
+
+  private trim() {
+    let total = this.lines.reduce((sum, line) => sum + line.length + 1, 0);
+    while (total > MAX_TERMINAL_CHARS && this.lines.length > 1) {
+      total -= this.lines.shift()!.length + 1;
+    }
+  }
+}
+
+// escapeEnd returns the index after the escape sequence starting at start,
+// or -1 when the sequence continues in the next chunk.
+function escapeEnd(text: string, start: number): number {
+  const kind = text[start + 1];
+  if (kind === undefined) return -1;
+
+  if (kind === "[") {
+    for (let i = start + 2; i < text.length; i++) {
+      const code = text.charCodeAt(i);
+      if (code >= 0x40 && code <= 0x7e) return i + 1;
+    }
+    return -1;
+  }
+
+  if (kind === "]") {
+    for (let i = start + 2; i < text.length; i++) {
+      if (text[i] === "\x07") return i + 1;
+      if (text[i] === "\x1b" && text[i + 1] === "\\") return i + 2;
+    }
+    return -1;
+  }
+
+  if (kind === "(" || kind === ")") {
+    return start + 2 < text.length ? start + 3 : -1;
+  }
+
+  return start + 2;
+}
+
+const KEY_SEQUENCES: Record<string, string> = {
+  Enter: "\r",
+  Backspace: "\x7f",
+  Tab: "\t",
+  Escape: "\x1b",
+  ArrowUp: "\x1b[A",
+  ArrowDown: "\x1b[B",
+  ArrowRight: "\x1b[C",
+  ArrowLeft: "\x1b[D",
+  Home: "\x1b[H",
+  End: "\x1b[F",
+  Delete: "\x1b[3~",
+  PageUp: "\x1b[5~",
+  PageDown: "\x1b[6~",
+};
+
+// keyToInput maps a keyboard event to the bytes a terminal would send,
+// or null when the key should be left to the browser.
+export function keyToInput(e: { key: string; ctrlKey: boolean; altKey: boolean; metaKey: boolean }): string | null {
+  if (e.metaKey) return null;
+
+  if (e.ctrlKey && e.key.length === 1) {
+    const upper = e.key.toUpperCase();
+    if (upper >= "A" && upper <= "Z") {
+      return String.fromCharCode(upper.charCodeAt(0) - 64);
+    }
+    return null;
+  }
+
+  const sequence = KEY_SEQUENCES[e.key];
+  if (sequence) return sequence;
+
+  if (e.key.length === 1) {
+    return e.altKey ? "\x1b" + e.key : e.key;
+  }
+  return null;
+}
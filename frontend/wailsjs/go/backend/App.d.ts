@@ -2,24 +2,52 @@
 // This file is automatically generated. DO NOT EDIT
 import {backend} from '../models';
 
+export function BootImage(arg1:string):Promise<void>;
+
+export function CancelAll():Promise<number>;
+
+export function CancelJob(arg1:string):Promise<void>;
+
 export function CancelOperation():Promise<string>;
 
+export function CaptureScreenshot(arg1:backend.ScreenshotOptions):Promise<backend.Screenshot>;
+
+export function ChangeBootloaderLock(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function CheckFactoryImage(arg1:string,arg2:string):Promise<Array<backend.FactoryRequirementCheck>>;
+
 export function CheckSystemRequirements():Promise<string>;
 
 export function ClearData(arg1:string):Promise<string>;
 
+export function ClearLogcat(arg1:Array<string>):Promise<string>;
+
+export function CloseShell(arg1:string):Promise<void>;
+
+export function ConnectFastbootTcp(arg1:string):Promise<backend.Device>;
+
 export function ConnectWirelessAdb(arg1:string,arg2:string):Promise<string>;
 
+export function ConvertSparseImage(arg1:string,arg2:string,arg3:boolean):Promise<string>;
+
 export function CreateFolder(arg1:string):Promise<string>;
 
+export function CreateLogicalPartition(arg1:string,arg2:string,arg3:number):Promise<void>;
+
 export function DeleteFile(arg1:string):Promise<string>;
 
+export function DeleteLogicalPartition(arg1:string,arg2:string):Promise<void>;
+
 export function DeleteMultipleFiles(arg1:Array<string>):Promise<string>;
 
 export function DisableMultiplePackages(arg1:Array<string>):Promise<string>;
 
 export function DisablePackage(arg1:string):Promise<string>;
 
+export function DiscardLogcat(arg1:string):Promise<void>;
+
+export function DisconnectFastbootTcp(arg1:string):Promise<void>;
+
 export function DisconnectWirelessAdb(arg1:string,arg2:string):Promise<string>;
 
 export function EnableMultiplePackages(arg1:Array<string>):Promise<string>;
@@ -28,24 +56,74 @@ export function EnablePackage(arg1:string):Promise<string>;
 
 export function EnableWirelessAdb(arg1:string):Promise<string>;
 
-export function FlashPartition(arg1:string,arg2:string):Promise<void>;
+export function ErasePartition(arg1:string,arg2:boolean):Promise<void>;
+
+export function ExtractPayloadPartitions(arg1:string,arg2:Array<string>,arg3:string):Promise<string>;
+
+export function ExtractSuperPartitions(arg1:string,arg2:Array<string>,arg3:string):Promise<string>;
+
+export function FlashPartition(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function FlashVbmeta(arg1:string,arg2:boolean,arg3:boolean):Promise<string>;
+
+export function FormatPartition(arg1:string,arg2:string,arg3:boolean):Promise<void>;
+
+export function GetBootloaderLockState(arg1:string):Promise<backend.BootloaderLockState>;
 
 export function GetDeviceInfo():Promise<backend.DeviceInfo>;
 
 export function GetDeviceMode():Promise<string>;
 
+export function GetDeviceSnapshot():Promise<Array<backend.TrackedDevice>>;
+
 export function GetDevices():Promise<Array<backend.Device>>;
 
+export function GetDisplays():Promise<Array<backend.DisplayInfo>>;
+
 export function GetFastbootDevices():Promise<Array<backend.Device>>;
 
+export function GetFastbootInfo(arg1:string):Promise<backend.FastbootInfo>;
+
+export function GetLogcatBufferSizes():Promise<string>;
+
+export function GetPartitionLayout(arg1:string):Promise<backend.PartitionLayout>;
+
+export function GetShellScrollback(arg1:string):Promise<string>;
+
+export function GetSlots(arg1:string):Promise<Array<backend.SlotInfo>>;
+
+export function GetTargetDevice():Promise<string>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function InspectBootImage(arg1:string,arg2:string):Promise<backend.BootImageInfo>;
+
+export function InspectFactoryImage(arg1:string):Promise<backend.FactoryImage>;
+
+export function InspectSparseImage(arg1:string):Promise<backend.SparseImageInfo>;
+
+export function InspectSuperImage(arg1:string):Promise<backend.SuperImageInfo>;
+
+export function InspectVbmeta(arg1:string):Promise<backend.VbmetaInfo>;
+
 export function InstallPackage(arg1:string):Promise<string>;
 
+export function IsHeadlessAdb():Promise<boolean>;
+
 export function ListFiles(arg1:string):Promise<Array<backend.FileEntry>>;
 
+export function ListJobs():Promise<Array<backend.Job>>;
+
 export function ListPackages(arg1:string):Promise<Array<backend.PackageInfo>>;
 
+export function ListPayloadPartitions(arg1:string):Promise<backend.PayloadInfo>;
+
+export function ListShells():Promise<Array<backend.ShellSession>>;
+
+export function OpenShell(arg1:string):Promise<string>;
+
+export function PrepareBootloaderLockChange(arg1:string,arg2:string):Promise<backend.LockChangePlan>;
+
 export function PullApk(arg1:string):Promise<string>;
 
 export function PullFile(arg1:string,arg2:string):Promise<string>;
@@ -56,20 +134,30 @@ export function PushFile(arg1:string,arg2:string):Promise<string>;
 
 export function Reboot(arg1:string):Promise<void>;
 
+export function RebootToFastbootd(arg1:string):Promise<void>;
+
 export function RenameFile(arg1:string,arg2:string):Promise<string>;
 
+export function ResizeLogicalPartition(arg1:string,arg2:string,arg3:number):Promise<void>;
+
+export function ResizeShell(arg1:string,arg2:number,arg3:number):Promise<void>;
+
 export function RunAdbHostCommand(arg1:string):Promise<string>;
 
 export function RunFastbootHostCommand(arg1:string):Promise<string>;
 
 export function RunShellCommand(arg1:string):Promise<string>;
 
+export function SaveLogcat(arg1:string,arg2:string):Promise<string>;
+
 export function SelectApkFile():Promise<string>;
 
 export function SelectDirectoryForPull():Promise<string>;
 
 export function SelectDirectoryToPush():Promise<string>;
 
+export function SelectFactoryImageFolder():Promise<string>;
+
 export function SelectFileToPush():Promise<string>;
 
 export function SelectFilesToPush():Promise<Array<string>>;
@@ -78,16 +166,38 @@ export function SelectFoldersToPush():Promise<Array<string>>;
 
 export function SelectImageFile():Promise<string>;
 
+export function SelectOtaPackage():Promise<string>;
+
 export function SelectSaveDirectory(arg1:string):Promise<string>;
 
 export function SelectSaveFile(arg1:string):Promise<string>;
 
 export function SelectZipFile():Promise<string>;
 
+export function SetActiveSlot(arg1:string,arg2:string):Promise<void>;
+
+export function SetHeadlessAdb(arg1:boolean):Promise<void>;
+
+export function SetLogcatBufferSize(arg1:string,arg2:Array<string>):Promise<string>;
+
+export function SetTargetDevice(arg1:string):Promise<void>;
+
 export function SideloadPackage(arg1:string):Promise<string>;
 
+export function StartFactoryFlash(arg1:backend.FactoryFlashOptions):Promise<string>;
+
+export function StartLogcat(arg1:backend.LogcatFilter):Promise<string>;
+
+export function StartScreenRecording(arg1:backend.RecordingOptions):Promise<string>;
+
+export function StopLogcat(arg1:string):Promise<void>;
+
+export function StopScreenRecording(arg1:string):Promise<void>;
+
 export function UninstallMultiplePackages(arg1:Array<string>):Promise<string>;
 
 export function UninstallPackage(arg1:string):Promise<string>;
 
 export function WipeData():Promise<void>;
+
+export function WriteShell(arg1:string,arg2:string):Promise<void>;
@@ -2,10 +2,34 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function BootImage(arg1) {
+  return window['go']['backend']['App']['BootImage'](arg1);
+}
+
+export function CancelAll() {
+  return window['go']['backend']['App']['CancelAll']();
+}
+
+export function CancelJob(arg1) {
+  return window['go']['backend']['App']['CancelJob'](arg1);
+}
+
 export function CancelOperation() {
   return window['go']['backend']['App']['CancelOperation']();
 }
 
+export function CaptureScreenshot(arg1) {
+  return window['go']['backend']['App']['CaptureScreenshot'](arg1);
+}
+
+export function ChangeBootloaderLock(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['ChangeBootloaderLock'](arg1, arg2, arg3);
+}
+
+export function CheckFactoryImage(arg1, arg2) {
+  return window['go']['backend']['App']['CheckFactoryImage'](arg1, arg2);
+}
+
 export function CheckSystemRequirements() {
   return window['go']['backend']['App']['CheckSystemRequirements']();
 }
@@ -14,18 +38,42 @@ export function ClearData(arg1) {
   return window['go']['backend']['App']['ClearData'](arg1);
 }
 
+export function ClearLogcat(arg1) {
+  return window['go']['backend']['App']['ClearLogcat'](arg1);
+}
+
+export function CloseShell(arg1) {
+  return window['go']['backend']['App']['CloseShell'](arg1);
+}
+
+export function ConnectFastbootTcp(arg1) {
+  return window['go']['backend']['App']['ConnectFastbootTcp'](arg1);
+}
+
 export function ConnectWirelessAdb(arg1, arg2) {
   return window['go']['backend']['App']['ConnectWirelessAdb'](arg1, arg2);
 }
 
+export function ConvertSparseImage(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['ConvertSparseImage'](arg1, arg2, arg3);
+}
+
 export function CreateFolder(arg1) {
   return window['go']['backend']['App']['CreateFolder'](arg1);
 }
 
+export function CreateLogicalPartition(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['CreateLogicalPartition'](arg1, arg2, arg3);
+}
+
 export function DeleteFile(arg1) {
   return window['go']['backend']['App']['DeleteFile'](arg1);
 }
 
+export function DeleteLogicalPartition(arg1, arg2) {
+  return window['go']['backend']['App']['DeleteLogicalPartition'](arg1, arg2);
+}
+
 export function DeleteMultipleFiles(arg1) {
   return window['go']['backend']['App']['DeleteMultipleFiles'](arg1);
 }
@@ -38,6 +86,14 @@ export function DisablePackage(arg1) {
   return window['go']['backend']['App']['DisablePackage'](arg1);
 }
 
+export function DiscardLogcat(arg1) {
+  return window['go']['backend']['App']['DiscardLogcat'](arg1);
+}
+
+export function DisconnectFastbootTcp(arg1) {
+  return window['go']['backend']['App']['DisconnectFastbootTcp'](arg1);
+}
+
 export function DisconnectWirelessAdb(arg1, arg2) {
   return window['go']['backend']['App']['DisconnectWirelessAdb'](arg1, arg2);
 }
@@ -54,8 +110,32 @@ export function EnableWirelessAdb(arg1) {
   return window['go']['backend']['App']['EnableWirelessAdb'](arg1);
 }
 
-export function FlashPartition(arg1, arg2) {
-  return window['go']['backend']['App']['FlashPartition'](arg1, arg2);
+export function ErasePartition(arg1, arg2) {
+  return window['go']['backend']['App']['ErasePartition'](arg1, arg2);
+}
+
+export function ExtractPayloadPartitions(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['ExtractPayloadPartitions'](arg1, arg2, arg3);
+}
+
+export function ExtractSuperPartitions(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['ExtractSuperPartitions'](arg1, arg2, arg3);
+}
+
+export function FlashPartition(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['FlashPartition'](arg1, arg2, arg3);
+}
+
+export function FlashVbmeta(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['FlashVbmeta'](arg1, arg2, arg3);
+}
+
+export function FormatPartition(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['FormatPartition'](arg1, arg2, arg3);
+}
+
+export function GetBootloaderLockState(arg1) {
+  return window['go']['backend']['App']['GetBootloaderLockState'](arg1);
 }
 
 export function GetDeviceInfo() {
@@ -66,30 +146,106 @@ export function GetDeviceMode() {
   return window['go']['backend']['App']['GetDeviceMode']();
 }
 
+export function GetDeviceSnapshot() {
+  return window['go']['backend']['App']['GetDeviceSnapshot']();
+}
+
 export function GetDevices() {
   return window['go']['backend']['App']['GetDevices']();
 }
 
+export function GetDisplays() {
+  return window['go']['backend']['App']['GetDisplays']();
+}
+
 export function GetFastbootDevices() {
   return window['go']['backend']['App']['GetFastbootDevices']();
 }
 
+export function GetFastbootInfo(arg1) {
+  return window['go']['backend']['App']['GetFastbootInfo'](arg1);
+}
+
+export function GetLogcatBufferSizes() {
+  return window['go']['backend']['App']['GetLogcatBufferSizes']();
+}
+
+export function GetPartitionLayout(arg1) {
+  return window['go']['backend']['App']['GetPartitionLayout'](arg1);
+}
+
+export function GetShellScrollback(arg1) {
+  return window['go']['backend']['App']['GetShellScrollback'](arg1);
+}
+
+export function GetSlots(arg1) {
+  return window['go']['backend']['App']['GetSlots'](arg1);
+}
+
+export function GetTargetDevice() {
+  return window['go']['backend']['App']['GetTargetDevice']();
+}
+
 export function Greet(arg1) {
   return window['go']['backend']['App']['Greet'](arg1);
 }
 
+export function InspectBootImage(arg1, arg2) {
+  return window['go']['backend']['App']['InspectBootImage'](arg1, arg2);
+}
+
+export function InspectFactoryImage(arg1) {
+  return window['go']['backend']['App']['InspectFactoryImage'](arg1);
+}
+
+export function InspectSparseImage(arg1) {
+  return window['go']['backend']['App']['InspectSparseImage'](arg1);
+}
+
+export function InspectSuperImage(arg1) {
+  return window['go']['backend']['App']['InspectSuperImage'](arg1);
+}
+
+export function InspectVbmeta(arg1) {
+  return window['go']['backend']['App']['InspectVbmeta'](arg1);
+}
+
 export function InstallPackage(arg1) {
   return window['go']['backend']['App']['InstallPackage'](arg1);
 }
 
+export function IsHeadlessAdb() {
+  return window['go']['backend']['App']['IsHeadlessAdb']();
+}
+
 export function ListFiles(arg1) {
   return window['go']['backend']['App']['ListFiles'](arg1);
 }
 
+export function ListJobs() {
+  return window['go']['backend']['App']['ListJobs']();
+}
+
 export function ListPackages(arg1) {
   return window['go']['backend']['App']['ListPackages'](arg1);
 }
 
+export function ListPayloadPartitions(arg1) {
+  return window['go']['backend']['App']['ListPayloadPartitions'](arg1);
+}
+
+export function ListShells() {
+  return window['go']['backend']['App']['ListShells']();
+}
+
+export function OpenShell(arg1) {
+  return window['go']['backend']['App']['OpenShell'](arg1);
+}
+
+export function PrepareBootloaderLockChange(arg1, arg2) {
+  return window['go']['backend']['App']['PrepareBootloaderLockChange'](arg1, arg2);
+}
+
 export function PullApk(arg1) {
   return window['go']['backend']['App']['PullApk'](arg1);
 }
@@ -110,10 +266,22 @@ export function Reboot(arg1) {
   return window['go']['backend']['App']['Reboot'](arg1);
 }
 
+export function RebootToFastbootd(arg1) {
+  return window['go']['backend']['App']['RebootToFastbootd'](arg1);
+}
+
 export function RenameFile(arg1, arg2) {
   return window['go']['backend']['App']['RenameFile'](arg1, arg2);
 }
 
+export function ResizeLogicalPartition(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['ResizeLogicalPartition'](arg1, arg2, arg3);
+}
+
+export function ResizeShell(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['ResizeShell'](arg1, arg2, arg3);
+}
+
 export function RunAdbHostCommand(arg1) {
   return window['go']['backend']['App']['RunAdbHostCommand'](arg1);
 }
@@ -126,6 +294,10 @@ export function RunShellCommand(arg1) {
   return window['go']['backend']['App']['RunShellCommand'](arg1);
 }
 
+export function SaveLogcat(arg1, arg2) {
+  return window['go']['backend']['App']['SaveLogcat'](arg1, arg2);
+}
+
 export function SelectApkFile() {
   return window['go']['backend']['App']['SelectApkFile']();
 }
@@ -138,6 +310,10 @@ export function SelectDirectoryToPush() {
   return window['go']['backend']['App']['SelectDirectoryToPush']();
 }
 
+export function SelectFactoryImageFolder() {
+  return window['go']['backend']['App']['SelectFactoryImageFolder']();
+}
+
 export function SelectFileToPush() {
   return window['go']['backend']['App']['SelectFileToPush']();
 }
@@ -154,6 +330,10 @@ export function SelectImageFile() {
   return window['go']['backend']['App']['SelectImageFile']();
 }
 
+export function SelectOtaPackage() {
+  return window['go']['backend']['App']['SelectOtaPackage']();
+}
+
 export function SelectSaveDirectory(arg1) {
   return window['go']['backend']['App']['SelectSaveDirectory'](arg1);
 }
@@ -166,10 +346,46 @@ export function SelectZipFile() {
   return window['go']['backend']['App']['SelectZipFile']();
 }
 
+export function SetActiveSlot(arg1, arg2) {
+  return window['go']['backend']['App']['SetActiveSlot'](arg1, arg2);
+}
+
+export function SetHeadlessAdb(arg1) {
+  return window['go']['backend']['App']['SetHeadlessAdb'](arg1);
+}
+
+export function SetLogcatBufferSize(arg1, arg2) {
+  return window['go']['backend']['App']['SetLogcatBufferSize'](arg1, arg2);
+}
+
+export function SetTargetDevice(arg1) {
+  return window['go']['backend']['App']['SetTargetDevice'](arg1);
+}
+
 export function SideloadPackage(arg1) {
   return window['go']['backend']['App']['SideloadPackage'](arg1);
 }
 
+export function StartFactoryFlash(arg1) {
+  return window['go']['backend']['App']['StartFactoryFlash'](arg1);
+}
+
+export function StartLogcat(arg1) {
+  return window['go']['backend']['App']['StartLogcat'](arg1);
+}
+
+export function StartScreenRecording(arg1) {
+  return window['go']['backend']['App']['StartScreenRecording'](arg1);
+}
+
+export function StopLogcat(arg1) {
+  return window['go']['backend']['App']['StopLogcat'](arg1);
+}
+
+export function StopScreenRecording(arg1) {
+  return window['go']['backend']['App']['StopScreenRecording'](arg1);
+}
+
 export function UninstallMultiplePackages(arg1) {
   return window['go']['backend']['App']['UninstallMultiplePackages'](arg1);
 }
@@ -181,3 +397,7 @@ export function UninstallPackage(arg1) {
 export function WipeData() {
   return window['go']['backend']['App']['WipeData']();
 }
+
+export function WriteShell(arg1, arg2) {
+  return window['go']['backend']['App']['WriteShell'](arg1, arg2);
+}
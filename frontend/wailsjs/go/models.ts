@@ -1,8 +1,69 @@
 export namespace backend {
 	
+	export class BootImageInfo {
+	    Path: string;
+	    Kind: string;
+	    HeaderVersion: number;
+	    PageSize: number;
+	    KernelSize: number;
+	    RamdiskSize: number;
+	    SecondSize: number;
+	    DtbSize: number;
+	    OSVersion: string;
+	    OSPatchLevel: string;
+	    Name: string;
+	    Cmdline: string;
+	    RamdiskCompression: string;
+	    MagiskPatched: boolean;
+	    Warnings: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BootImageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Kind = source["Kind"];
+	        this.HeaderVersion = source["HeaderVersion"];
+	        this.PageSize = source["PageSize"];
+	        this.KernelSize = source["KernelSize"];
+	        this.RamdiskSize = source["RamdiskSize"];
+	        this.SecondSize = source["SecondSize"];
+	        this.DtbSize = source["DtbSize"];
+	        this.OSVersion = source["OSVersion"];
+	        this.OSPatchLevel = source["OSPatchLevel"];
+	        this.Name = source["Name"];
+	        this.Cmdline = source["Cmdline"];
+	        this.RamdiskCompression = source["RamdiskCompression"];
+	        this.MagiskPatched = source["MagiskPatched"];
+	        this.Warnings = source["Warnings"];
+	    }
+	}
+	export class BootloaderLockState {
+	    Serial: string;
+	    Unlocked: boolean;
+	    UnlockAbility: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BootloaderLockState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Serial = source["Serial"];
+	        this.Unlocked = source["Unlocked"];
+	        this.UnlockAbility = source["UnlockAbility"];
+	    }
+	}
 	export class Device {
 	    Serial: string;
 	    Status: string;
+	    Product: string;
+	    Model: string;
+	    DeviceName: string;
+	    TransportID: string;
+	    USB: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Device(source);
@@ -12,6 +73,11 @@ export namespace backend {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.Serial = source["Serial"];
 	        this.Status = source["Status"];
+	        this.Product = source["Product"];
+	        this.Model = source["Model"];
+	        this.DeviceName = source["DeviceName"];
+	        this.TransportID = source["TransportID"];
+	        this.USB = source["USB"];
 	    }
 	}
 	export class DeviceInfo {
@@ -27,6 +93,7 @@ export namespace backend {
 	    StorageInfo: string;
 	    Brand: string;
 	    DeviceName: string;
+	    SecurityPatch: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new DeviceInfo(source);
@@ -46,8 +113,183 @@ export namespace backend {
 	        this.StorageInfo = source["StorageInfo"];
 	        this.Brand = source["Brand"];
 	        this.DeviceName = source["DeviceName"];
+	        this.SecurityPatch = source["SecurityPatch"];
+	    }
+	}
+	export class DisplayInfo {
+	    ID: string;
+	    Description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DisplayInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Description = source["Description"];
 	    }
 	}
+	export class FactoryFlashOptions {
+	    Path: string;
+	    Serial: string;
+	    Wipe: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FactoryFlashOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Serial = source["Serial"];
+	        this.Wipe = source["Wipe"];
+	    }
+	}
+	export class FactoryRequirement {
+	    Name: string;
+	    Values: string[];
+	    Product: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FactoryRequirement(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Values = source["Values"];
+	        this.Product = source["Product"];
+	    }
+	}
+	export class FactoryImage {
+	    Path: string;
+	    Bootloader: string;
+	    Radio: string;
+	    Image: string;
+	    Requirements: FactoryRequirement[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FactoryImage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Bootloader = source["Bootloader"];
+	        this.Radio = source["Radio"];
+	        this.Image = source["Image"];
+	        this.Requirements = this.convertValues(source["Requirements"], FactoryRequirement);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class FactoryRequirementCheck {
+	    Name: string;
+	    Expected: string;
+	    DeviceValue: string;
+	    Satisfied: boolean;
+	    Flashed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FactoryRequirementCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Expected = source["Expected"];
+	        this.DeviceValue = source["DeviceValue"];
+	        this.Satisfied = source["Satisfied"];
+	        this.Flashed = source["Flashed"];
+	    }
+	}
+	export class FastbootPartition {
+	    Name: string;
+	    Size: number;
+	    Type: string;
+	    IsLogical: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FastbootPartition(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Size = source["Size"];
+	        this.Type = source["Type"];
+	        this.IsLogical = source["IsLogical"];
+	    }
+	}
+	export class FastbootInfo {
+	    Serial: string;
+	    Product: string;
+	    Unlocked: boolean;
+	    Secure: boolean;
+	    CurrentSlot: string;
+	    SlotCount: number;
+	    IsUserspace: boolean;
+	    MaxDownloadSize: number;
+	    BatteryVoltage: number;
+	    Partitions: FastbootPartition[];
+	    Vars: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new FastbootInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Serial = source["Serial"];
+	        this.Product = source["Product"];
+	        this.Unlocked = source["Unlocked"];
+	        this.Secure = source["Secure"];
+	        this.CurrentSlot = source["CurrentSlot"];
+	        this.SlotCount = source["SlotCount"];
+	        this.IsUserspace = source["IsUserspace"];
+	        this.MaxDownloadSize = source["MaxDownloadSize"];
+	        this.BatteryVoltage = source["BatteryVoltage"];
+	        this.Partitions = this.convertValues(source["Partitions"], FastbootPartition);
+	        this.Vars = source["Vars"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
 	export class FileEntry {
 	    Name: string;
 	    Type: string;
@@ -70,6 +312,100 @@ export namespace backend {
 	        this.Time = source["Time"];
 	    }
 	}
+	export class Job {
+	    ID: string;
+	    ParentID: string;
+	    Kind: string;
+	    Description: string;
+	    Status: string;
+	    Progress: number;
+	    // Go type: time
+	    StartedAt: any;
+	    // Go type: time
+	    EndedAt: any;
+	    Result: string;
+	    Error: string;
+	    Children: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.ParentID = source["ParentID"];
+	        this.Kind = source["Kind"];
+	        this.Description = source["Description"];
+	        this.Status = source["Status"];
+	        this.Progress = source["Progress"];
+	        this.StartedAt = this.convertValues(source["StartedAt"], null);
+	        this.EndedAt = this.convertValues(source["EndedAt"], null);
+	        this.Result = source["Result"];
+	        this.Error = source["Error"];
+	        this.Children = source["Children"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LockChangePlan {
+	    Serial: string;
+	    Action: string;
+	    Command: string;
+	    Warnings: string[];
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LockChangePlan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Serial = source["Serial"];
+	        this.Action = source["Action"];
+	        this.Command = source["Command"];
+	        this.Warnings = source["Warnings"];
+	        this.Token = source["Token"];
+	    }
+	}
+	export class LogcatFilter {
+	    Buffers: string[];
+	    Package: string;
+	    Pid: number;
+	    Tag: string;
+	    MinLevel: string;
+	    Regex: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LogcatFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Buffers = source["Buffers"];
+	        this.Package = source["Package"];
+	        this.Pid = source["Pid"];
+	        this.Tag = source["Tag"];
+	        this.MinLevel = source["MinLevel"];
+	        this.Regex = source["Regex"];
+	    }
+	}
 	export class PackageInfo {
 	    PackageName: string;
 	    IsEnabled: boolean;
@@ -84,6 +420,455 @@ export namespace backend {
 	        this.IsEnabled = source["IsEnabled"];
 	    }
 	}
+	export class PartitionLayout {
+	    IsUserspace: boolean;
+	    Logical: FastbootPartition[];
+	    Physical: FastbootPartition[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PartitionLayout(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.IsUserspace = source["IsUserspace"];
+	        this.Logical = this.convertValues(source["Logical"], FastbootPartition);
+	        this.Physical = this.convertValues(source["Physical"], FastbootPartition);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PayloadPartition {
+	    Name: string;
+	    Size: number;
+	    Incremental: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PayloadPartition(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Size = source["Size"];
+	        this.Incremental = source["Incremental"];
+	    }
+	}
+	export class PayloadInfo {
+	    Path: string;
+	    Version: number;
+	    BlockSize: number;
+	    Partitions: PayloadPartition[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PayloadInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Version = source["Version"];
+	        this.BlockSize = source["BlockSize"];
+	        this.Partitions = this.convertValues(source["Partitions"], PayloadPartition);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RecordingOptions {
+	    BitRate: number;
+	    Size: string;
+	    TimeLimit: number;
+	    DisplayID: string;
+	    OutputPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.BitRate = source["BitRate"];
+	        this.Size = source["Size"];
+	        this.TimeLimit = source["TimeLimit"];
+	        this.DisplayID = source["DisplayID"];
+	        this.OutputPath = source["OutputPath"];
+	    }
+	}
+	export class Screenshot {
+	    Base64: string;
+	    Width: number;
+	    Height: number;
+	    Path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Screenshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Base64 = source["Base64"];
+	        this.Width = source["Width"];
+	        this.Height = source["Height"];
+	        this.Path = source["Path"];
+	    }
+	}
+	export class ScreenshotOptions {
+	    DisplayID: string;
+	    Save: boolean;
+	    SaveDir: string;
+	    FilenameTemplate: string;
+	    Nickname: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScreenshotOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.DisplayID = source["DisplayID"];
+	        this.Save = source["Save"];
+	        this.SaveDir = source["SaveDir"];
+	        this.FilenameTemplate = source["FilenameTemplate"];
+	        this.Nickname = source["Nickname"];
+	    }
+	}
+	export class ShellSession {
+	    ID: string;
+	    Serial: string;
+	    Interactive: boolean;
+	    // Go type: time
+	    StartedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShellSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Serial = source["Serial"];
+	        this.Interactive = source["Interactive"];
+	        this.StartedAt = this.convertValues(source["StartedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SlotInfo {
+	    Name: string;
+	    Active: boolean;
+	    Successful: boolean;
+	    Unbootable: boolean;
+	    RetryCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SlotInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Active = source["Active"];
+	        this.Successful = source["Successful"];
+	        this.Unbootable = source["Unbootable"];
+	        this.RetryCount = source["RetryCount"];
+	    }
+	}
+	export class SparseImageInfo {
+	    Path: string;
+	    IsSparse: boolean;
+	    FileSize: number;
+	    LogicalSize: number;
+	    BlockSize: number;
+	    TotalBlocks: number;
+	    TotalChunks: number;
+	    RawChunks: number;
+	    FillChunks: number;
+	    DontCareChunks: number;
+	    CRCChunks: number;
+	    RawBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SparseImageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.IsSparse = source["IsSparse"];
+	        this.FileSize = source["FileSize"];
+	        this.LogicalSize = source["LogicalSize"];
+	        this.BlockSize = source["BlockSize"];
+	        this.TotalBlocks = source["TotalBlocks"];
+	        this.TotalChunks = source["TotalChunks"];
+	        this.RawChunks = source["RawChunks"];
+	        this.FillChunks = source["FillChunks"];
+	        this.DontCareChunks = source["DontCareChunks"];
+	        this.CRCChunks = source["CRCChunks"];
+	        this.RawBytes = source["RawBytes"];
+	    }
+	}
+	export class SuperBlockDevice {
+	    Name: string;
+	    Size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SuperBlockDevice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Size = source["Size"];
+	    }
+	}
+	export class SuperGroup {
+	    Name: string;
+	    MaximumSize: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SuperGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.MaximumSize = source["MaximumSize"];
+	    }
+	}
+	export class SuperPartition {
+	    Name: string;
+	    Group: string;
+	    Size: number;
+	    Attributes: string[];
+	    Extents: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SuperPartition(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Group = source["Group"];
+	        this.Size = source["Size"];
+	        this.Attributes = source["Attributes"];
+	        this.Extents = source["Extents"];
+	    }
+	}
+	export class SuperImageInfo {
+	    Path: string;
+	    IsSparse: boolean;
+	    MetadataVersion: string;
+	    MetadataMaxSize: number;
+	    SlotCount: number;
+	    BlockDevices: SuperBlockDevice[];
+	    Groups: SuperGroup[];
+	    Partitions: SuperPartition[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SuperImageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.IsSparse = source["IsSparse"];
+	        this.MetadataVersion = source["MetadataVersion"];
+	        this.MetadataMaxSize = source["MetadataMaxSize"];
+	        this.SlotCount = source["SlotCount"];
+	        this.BlockDevices = this.convertValues(source["BlockDevices"], SuperBlockDevice);
+	        this.Groups = this.convertValues(source["Groups"], SuperGroup);
+	        this.Partitions = this.convertValues(source["Partitions"], SuperPartition);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class TrackedDevice {
+	    Device: Device;
+	    Mode: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrackedDevice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Device = this.convertValues(source["Device"], Device);
+	        this.Mode = source["Mode"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class VbmetaDescriptor {
+	    Type: string;
+	    Partition: string;
+	    ImageSize: number;
+	    HashAlgorithm: string;
+	    Salt: string;
+	    Digest: string;
+	    Flags: number;
+	    RollbackIndexLocation: number;
+	    PublicKeySHA1: string;
+	    Key: string;
+	    Value: string;
+	    Cmdline: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VbmetaDescriptor(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Type = source["Type"];
+	        this.Partition = source["Partition"];
+	        this.ImageSize = source["ImageSize"];
+	        this.HashAlgorithm = source["HashAlgorithm"];
+	        this.Salt = source["Salt"];
+	        this.Digest = source["Digest"];
+	        this.Flags = source["Flags"];
+	        this.RollbackIndexLocation = source["RollbackIndexLocation"];
+	        this.PublicKeySHA1 = source["PublicKeySHA1"];
+	        this.Key = source["Key"];
+	        this.Value = source["Value"];
+	        this.Cmdline = source["Cmdline"];
+	    }
+	}
+	export class VbmetaInfo {
+	    Path: string;
+	    Footer: boolean;
+	    OriginalImageSize: number;
+	    LibavbVersion: string;
+	    Algorithm: string;
+	    RollbackIndex: number;
+	    RollbackIndexLocation: number;
+	    Flags: number;
+	    HashtreeDisabled: boolean;
+	    VerificationDisabled: boolean;
+	    ReleaseString: string;
+	    PublicKeySHA1: string;
+	    Descriptors: VbmetaDescriptor[];
+	
+	    static createFrom(source: any = {}) {
+	        return new VbmetaInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Footer = source["Footer"];
+	        this.OriginalImageSize = source["OriginalImageSize"];
+	        this.LibavbVersion = source["LibavbVersion"];
+	        this.Algorithm = source["Algorithm"];
+	        this.RollbackIndex = source["RollbackIndex"];
+	        this.RollbackIndexLocation = source["RollbackIndexLocation"];
+	        this.Flags = source["Flags"];
+	        this.HashtreeDisabled = source["HashtreeDisabled"];
+	        this.VerificationDisabled = source["VerificationDisabled"];
+	        this.ReleaseString = source["ReleaseString"];
+	        this.PublicKeySHA1 = source["PublicKeySHA1"];
+	        this.Descriptors = this.convertValues(source["Descriptors"], VbmetaDescriptor);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 